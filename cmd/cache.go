@@ -0,0 +1,178 @@
+/*
+Copyright © 2025 Dmitry Mozzherin <dmozzherin@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/internal/iocache"
+	"github.com/gnames/gndb/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// getCacheCmd returns the cache command with its subcommands.
+func getCacheCmd() *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of downloaded SFGA archives",
+		Long: `Manage SFGA archives kept between populate runs.
+
+Populate downloads remote SFGA archives to ~/.cache/gndb/archives and
+reuses them while the remote file keeps the same name, size and ETag.
+Least recently used archives are removed when the cache grows over
+cache.max_size_gb of config.yaml.
+
+Examples:
+  # Show cached archives
+  gndb cache list
+
+  # Shrink the cache to 5 GB
+  gndb cache prune --max-size-gb 5
+
+  # Check checksums of cached archives
+  gndb cache verify
+
+  # Remove all cached archives
+  gndb cache clear`,
+	}
+
+	cacheCmd.AddCommand(getCacheListCmd())
+	cacheCmd.AddCommand(getCachePruneCmd())
+	cacheCmd.AddCommand(getCacheClearCmd())
+	cacheCmd.AddCommand(getCacheVerifyCmd())
+
+	return cacheCmd
+}
+
+func getCacheListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List cached SFGA archives",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := iocache.New(cfg).List()
+			if err != nil {
+				gn.PrintErrorMessage(err)
+				return err
+			}
+			if len(entries) == 0 {
+				gn.Info("SFGA cache is empty")
+				return nil
+			}
+			printCacheEntries(os.Stdout, entries)
+			return nil
+		},
+	}
+}
+
+func getCachePruneCmd() *cobra.Command {
+	var maxSizeGB int
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove broken and least recently used SFGA archives",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("max-size-gb") {
+				cfg.Update([]config.Option{
+					config.OptCacheMaxSizeGB(maxSizeGB),
+				})
+			}
+			removed, err := iocache.New(cfg).Prune()
+			if err != nil {
+				gn.PrintErrorMessage(err)
+				return err
+			}
+			var size int64
+			for _, e := range removed {
+				size += e.Size
+			}
+			gn.Info("Removed <em>%d</em> archives, freed <em>%s</em>",
+				len(removed), humanize.Bytes(uint64(size)))
+			return nil
+		},
+	}
+
+	pruneCmd.Flags().IntVar(
+		&maxSizeGB, "max-size-gb", 0,
+		"size limit in GB (default cache.max_size_gb of config.yaml)",
+	)
+
+	return pruneCmd
+}
+
+func getCacheClearCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached SFGA archives",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := iocache.New(cfg).Clear(); err != nil {
+				gn.PrintErrorMessage(err)
+				return err
+			}
+			gn.Info("SFGA cache <em>%s</em> is cleared",
+				iocache.Dir(cfg.HomeDir))
+			return nil
+		},
+	}
+}
+
+func getCacheVerifyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify",
+		Short: "Check SHA-256 checksums of cached SFGA archives",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checked, corrupted, err := iocache.New(cfg).Verify()
+			if err != nil {
+				gn.PrintErrorMessage(err)
+				return err
+			}
+			for _, e := range corrupted {
+				gn.Warn("<warn>Removed corrupted archive</warn> %s", e.File)
+			}
+			gn.Info("Checked <em>%d</em> archives, corrupted: <em>%d</em>",
+				checked, len(corrupted))
+			return nil
+		},
+	}
+}
+
+// printCacheEntries shows cached archives as a table.
+func printCacheEntries(out io.Writer, entries []iocache.Entry) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tFILE\tSIZE\tLAST USED")
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			e.Key, e.File, humanize.Bytes(uint64(e.Size)),
+			e.LastUsed.Local().Format(time.DateTime),
+		)
+	}
+	w.Flush()
+	fmt.Fprintf(out, "\n%d archives, %s\n",
+		len(entries), humanize.Bytes(uint64(total)))
+}
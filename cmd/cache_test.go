@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/gnames/gndb/internal/iocache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetCacheCmd_Subcommands verifies the cache command has
+// list, prune, clear and verify subcommands.
+func TestGetCacheCmd_Subcommands(t *testing.T) {
+	cmd := getCacheCmd()
+	require.NotNil(t, cmd, "Cache command should exist")
+	assert.Equal(t, "cache", cmd.Use)
+
+	for _, name := range []string{"list", "prune", "clear", "verify"} {
+		sub, _, err := cmd.Find([]string{name})
+		require.NoError(t, err)
+		assert.Equal(t, name, sub.Use,
+			"Subcommand %s should exist", name)
+		assert.NotNil(t, sub.RunE,
+			"Subcommand %s should have RunE", name)
+	}
+}
+
+// TestGetCachePruneCmd_MaxSizeFlag verifies the --max-size-gb flag
+// of the prune subcommand.
+func TestGetCachePruneCmd_MaxSizeFlag(t *testing.T) {
+	cmd := getCachePruneCmd()
+
+	flag := cmd.Flags().Lookup("max-size-gb")
+	require.NotNil(t, flag, "--max-size-gb flag should exist")
+	assert.Equal(t, "0", flag.DefValue,
+		"Default should keep the limit from config")
+}
+
+// TestPrintCacheEntries verifies the table of cached archives.
+func TestPrintCacheEntries(t *testing.T) {
+	entries := []iocache.Entry{
+		{
+			Key:      "abc",
+			File:     "0001-col-2025-01-01.sqlite.zip",
+			Size:     2_000_000,
+			LastUsed: time.Now(),
+		},
+	}
+
+	var buf bytes.Buffer
+	printCacheEntries(&buf, entries)
+
+	out := buf.String()
+	assert.Contains(t, out, "0001-col-2025-01-01.sqlite.zip")
+	assert.Contains(t, out, "2.0 MB")
+	assert.Contains(t, out, "1 archives, 2.0 MB")
+}
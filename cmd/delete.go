@@ -49,8 +49,8 @@ The command lists each dataset that will be deleted (ID and title) and asks
 for confirmation before proceeding. No data is changed unless you confirm.
 
 Records are removed from name_string_indices, vernacular_string_indices,
-and data_sources. Orphaned name strings and canonical forms are cleaned up
-by running 'gndb optimize' afterwards.
+source_references and data_sources. Orphaned name strings and canonical
+forms are cleaned up by running 'gndb optimize' afterwards.
 
 Examples:
   # Delete datasets 5 and 12
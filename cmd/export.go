@@ -59,6 +59,11 @@ directory:
 A consolidated sources-export.yaml listing all exported sources is also
 written to the output directory.
 
+The output directory can be an s3://bucket/prefix/ URL of S3-compatible
+storage. Files are written to the cache directory first and uploaded
+when a source is exported. Storage settings come from the s3 section of
+config.yaml or the standard AWS_* environment variables.
+
 The --parent flag sets the value of the 'parent' field in companion YAML
 files. It should be set to the URL or path from which the exported files
 will be served, so that the YAML is ready to use without editing.
@@ -74,7 +79,10 @@ Examples:
   gndb export --output-dir /tmp/sfga --zip
 
   # Set parent URL for re-import YAML
-  gndb export -o /tmp/sfga -p http://myserver.org/sfga/`,
+  gndb export -o /tmp/sfga -p http://myserver.org/sfga/
+
+  # Upload to a MinIO bucket
+  GNDB_S3_ENDPOINT=http://localhost:9000 gndb export -o s3://sfga/exports/`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			err := runExport(cmd, sourceIDs, outputDir, parentDir, withZip)
 			if err != nil {
@@ -90,7 +98,7 @@ Examples:
 	)
 	exportCmd.Flags().StringVarP(
 		&outputDir, "output-dir", "o", ".",
-		"directory where exported files are written",
+		"directory or s3://bucket/prefix/ URL where exported files are written",
 	)
 	exportCmd.Flags().StringVarP(
 		&parentDir, "parent", "p", "",
@@ -114,6 +122,11 @@ func runExport(
 ) error {
 	ctx := context.Background()
 
+	progress, err := progressSink(cmd)
+	if err != nil {
+		return err
+	}
+
 	var opts []config.Option
 
 	if cmd.Flags().Changed("source-ids") {
@@ -156,7 +169,7 @@ func runExport(
 		}
 	}
 
-	exporter := ioexport.New(cfg, op)
+	exporter := ioexport.New(cfg, op, progress)
 
 	gn.Info("Starting export...")
 	return exporter.Export()
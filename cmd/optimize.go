@@ -43,14 +43,19 @@ Examples:
 }
 
 func runOptimize(
-	_ *cobra.Command,
+	cmd *cobra.Command,
 	_ []string,
 ) error {
 	ctx := context.Background()
 
+	progress, err := progressSink(cmd)
+	if err != nil {
+		return err
+	}
+
 	// Create database operator
 	op := iodb.NewPgxOperator()
-	err := op.Connect(ctx, &cfg.Database)
+	err = op.Connect(ctx, &cfg.Database)
 	if err != nil {
 		gn.PrintErrorMessage(err)
 		return err
@@ -75,7 +80,7 @@ Run 'gndb create' first to initialize the schema.`)
 	}
 
 	// Create optimizer
-	optimizer := iooptimize.NewOptimizer(op)
+	optimizer := iooptimize.NewOptimizer(op, progress)
 
 	// Run optimize
 	gn.Info("Starting database optimization...")
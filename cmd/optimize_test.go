@@ -173,8 +173,8 @@ func TestOptimize_EndToEnd_VASCAN(t *testing.T) {
 	require.NoError(t, err)
 
 	// Populate database with VASCAN data
-	populator := iopopulate.New(testCfg, op)
-	err = populator.Populate()
+	populator := iopopulate.New(testCfg, op, nil)
+	err = populator.Populate(ctx)
 	require.NoError(t, err, "Populate should succeed")
 
 	// Verify pre-optimization state
@@ -211,7 +211,7 @@ func TestOptimize_EndToEnd_VASCAN(t *testing.T) {
 		"Verification view should not exist yet")
 
 	// Run optimize
-	optimizer := iooptimize.NewOptimizer(op)
+	optimizer := iooptimize.NewOptimizer(op, nil)
 	err = optimizer.Optimize(ctx, testCfg)
 	require.NoError(t, err,
 		"Optimize should succeed")
@@ -360,7 +360,7 @@ func TestOptimize_EmptyDatabase(t *testing.T) {
 	require.NoError(t, err)
 
 	// Run optimize on empty database
-	optimizer := iooptimize.NewOptimizer(op)
+	optimizer := iooptimize.NewOptimizer(op, nil)
 	err = optimizer.Optimize(ctx, testCfg)
 
 	// Should succeed even with no data
@@ -463,12 +463,12 @@ func TestOptimize_Idempotent(t *testing.T) {
 	require.NoError(t, err)
 
 	// Populate database
-	populator := iopopulate.New(testCfg, op)
-	err = populator.Populate()
+	populator := iopopulate.New(testCfg, op, nil)
+	err = populator.Populate(ctx)
 	require.NoError(t, err)
 
 	// Run optimize first time
-	optimizer := iooptimize.NewOptimizer(op)
+	optimizer := iooptimize.NewOptimizer(op, nil)
 	err = optimizer.Optimize(ctx, testCfg)
 	require.NoError(t, err, "First optimize should succeed")
 
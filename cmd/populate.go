@@ -26,6 +26,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/gnames/gn"
 	"github.com/spf13/cobra"
@@ -36,16 +40,32 @@ import (
 	"github.com/gnames/gndb/pkg/errcode"
 )
 
+// populateFlags holds values of the populate command flags. Only flags
+// set by the user change the config.
+type populateFlags struct {
+	sourceIDs          []int
+	releaseVersion     string
+	releaseDate        string
+	flatClassification bool
+	resume             bool
+	parallelSources    int
+	delta              bool
+	nonInteractive     bool
+	onEmptyNameString  string
+	onDuplicateID      string
+	onDanglingSynonym  string
+	dryRun             bool
+	strictHierarchy    bool
+	maxDefectRatio     float64
+	force              bool
+	fileSource         config.FileSourceConfig
+}
+
 // getPopulateCmd returns the populate command.
 // Extracted as a function to facilitate testing and dynamic
 // command registration.
 func getPopulateCmd() *cobra.Command {
-	var (
-		sourceIDs          []int
-		releaseVersion     string
-		releaseDate        string
-		flatClassification bool
-	)
+	var flags populateFlags
 
 	populateCmd := &cobra.Command{
 		Use:   "populate",
@@ -62,13 +82,26 @@ This command:
      - Vernacular names (VernacularString)
      - Taxonomic hierarchy (classification tree)
      - Name indices (NameStringIndex)
+     - Bibliographic references (SourceReference)
   5. Reports progress and statistics
 
 SFGA data sources configured in: ~/.config/gndb/sources.yaml
 Each source has an ID (< 1000 official, >= 1000 custom).
 
-Override flags (--release-version, --release-date) only work
-when importing a single source.
+Release flags (--release-version, --release-date) select the file
+of this release instead of the latest one, and only work when
+importing a single source. Releases can also be pinned per source
+with release_date and version in sources.yaml.
+
+A file that is not in sources.yaml can be imported with --file and
+--id (custom IDs are >= 1000). Its title and outlinks are optional,
+sources.yaml is not read and --source-ids and release flags are
+ignored. After the import populate offers to add the source to
+custom_sources.yaml.
+
+Sources whose release is in the database already are skipped. The
+version and date of the resolved file (and its checksum, if known)
+are compared with the imported ones, --force imports them anyway.
 
 Examples:
   # Import all sources from sources.yaml
@@ -78,17 +111,46 @@ Examples:
   gndb populate --source-ids 1,11,132
   gndb populate -s 1,11,132
 
-  # Override release version for single source
+  # Import a specific release of a single source
   gndb populate -s 1 -r "2024.01" -d "2024-01-15"
 
   # Use flat classification
-  gndb populate --flat-classification`,
+  gndb populate --flat-classification
+
+  # Continue an interrupted run, skipping finished sources and stages
+  gndb populate --resume
+
+  # Import four sources at a time
+  gndb populate --parallel-sources 4
+
+  # Write only records that changed since the previous import
+  gndb populate -s 1 --delta
+
+  # Run without prompts, skipping sources with empty name-strings
+  gndb populate --non-interactive --on-empty-name-string skip
+
+  # Keep the first record when a source repeats record IDs
+  gndb populate -s 1 --on-duplicate-record-id keep-first
+
+  # Import synonyms without accepted taxa as bare names
+  gndb populate -s 1 --on-dangling-synonym bare-name
+
+  # Check sources and show expected record counts without importing
+  gndb populate --dry-run
+
+  # Fail sources where more than 1% of hierarchy nodes are broken
+  gndb populate --strict-hierarchy --max-hierarchy-defect-ratio 0.01
+
+  # Import sources again even if their releases did not change
+  gndb populate -s 1 --force
+
+  # Try out a file without adding it to custom_sources.yaml
+  gndb populate --file ~/Downloads/mydata.sqlite.zip --id 1500 \
+    --title "My Data" --outlink-url "https://example.org/{}" \
+    --outlink-id-column taxon.col__id`,
 		Aliases: []string{"add"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := runPopulate(
-				cmd, sourceIDs, releaseVersion,
-				releaseDate, flatClassification,
-			)
+			err := runPopulate(cmd, flags)
 			if err != nil {
 				gn.PrintErrorMessage(err)
 			}
@@ -97,40 +159,107 @@ Examples:
 	}
 
 	populateCmd.Flags().IntSliceVarP(
-		&sourceIDs, "source-ids", "s", []int{},
+		&flags.sourceIDs, "source-ids", "s", []int{},
 		"data source IDs to import (empty = all)",
 	)
 	populateCmd.Flags().StringVarP(
-		&releaseVersion, "release-version", "r", "",
-		"override version (single source only)",
+		&flags.releaseVersion, "release-version", "r", "",
+		"import release with this version (single source only)",
 	)
 	populateCmd.Flags().StringVarP(
-		&releaseDate, "release-date", "d", "",
-		"override date YYYY-MM-DD (single source only)",
+		&flags.releaseDate, "release-date", "d", "",
+		"import release with this date YYYY-MM-DD (single source only)",
 	)
 	populateCmd.Flags().BoolVarP(
-		&flatClassification, "flat-classification", "f", false,
+		&flags.flatClassification, "flat-classification", "f", false,
 		"use flat classification",
 	)
+	populateCmd.Flags().BoolVar(
+		&flags.resume, "resume", false,
+		"continue interrupted run from saved checkpoints",
+	)
+	populateCmd.Flags().IntVarP(
+		&flags.parallelSources, "parallel-sources", "p", 1,
+		"number of sources to import at the same time",
+	)
+	populateCmd.Flags().BoolVar(
+		&flags.delta, "delta", false,
+		"write only records that changed since previous import",
+	)
+	populateCmd.Flags().BoolVar(
+		&flags.nonInteractive, "non-interactive", false,
+		"never prompt, use policies or their defaults",
+	)
+	populateCmd.Flags().StringVar(
+		&flags.onEmptyNameString, "on-empty-name-string", "",
+		"policy for empty gn__scientific_name_string: fallback|skip|abort",
+	)
+	populateCmd.Flags().StringVar(
+		&flags.onDuplicateID, "on-duplicate-record-id", "",
+		"policy for duplicate record IDs: fail|keep-first|suffix",
+	)
+	populateCmd.Flags().StringVar(
+		&flags.onDanglingSynonym, "on-dangling-synonym", "",
+		"policy for synonyms without accepted taxa: drop|bare-name|keep",
+	)
+	populateCmd.Flags().BoolVar(
+		&flags.dryRun, "dry-run", false,
+		"check sources and report expected counts without importing",
+	)
+	populateCmd.Flags().BoolVar(
+		&flags.strictHierarchy, "strict-hierarchy", false,
+		"fail a source if its hierarchy defect ratio is too high",
+	)
+	populateCmd.Flags().Float64Var(
+		&flags.maxDefectRatio, "max-hierarchy-defect-ratio", 0,
+		"allowed ratio (0-1) of hierarchy nodes with broken parents",
+	)
+	populateCmd.Flags().BoolVar(
+		&flags.force, "force", false,
+		"import sources even if their release is in the database already",
+	)
+	populateCmd.Flags().StringVar(
+		&flags.fileSource.Path, "file", "",
+		"import this SFGA file (path or URL) instead of sources.yaml",
+	)
+	populateCmd.Flags().IntVar(
+		&flags.fileSource.ID, "id", 0,
+		"data source ID of the --file",
+	)
+	populateCmd.Flags().StringVar(
+		&flags.fileSource.Title, "title", "",
+		"title of the --file data source",
+	)
+	populateCmd.Flags().StringVar(
+		&flags.fileSource.OutlinkURL, "outlink-url", "",
+		"outlink URL template with {} of the --file data source",
+	)
+	populateCmd.Flags().StringVar(
+		&flags.fileSource.OutlinkIDColumn, "outlink-id-column", "",
+		"outlink ID table.column of the --file data source",
+	)
 
 	return populateCmd
 }
 
-func runPopulate(
-	cmd *cobra.Command,
-	sourceIDs []int,
-	releaseVersion string,
-	releaseDate string,
-	flatClassification bool,
-) error {
-	ctx := context.Background()
+func runPopulate(cmd *cobra.Command, flags populateFlags) error {
+	// Ctrl-C stops the import after the current source is cleaned up
+	ctx, stop := signal.NotifyContext(
+		context.Background(), os.Interrupt, syscall.SIGTERM,
+	)
+	defer stop()
+
+	progress, err := progressSink(cmd)
+	if err != nil {
+		return err
+	}
 
 	// Validate override flags (single source constraint)
 	hasVersion := cmd.Flags().Changed("release-version")
 	hasDate := cmd.Flags().Changed("release-date")
 	hasSourceIDs := cmd.Flags().Changed("source-ids")
 
-	if hasSourceIDs && len(sourceIDs) > 1 {
+	if hasSourceIDs && len(flags.sourceIDs) > 1 {
 		if hasVersion {
 			gn.Warn(`<warn>Cannot override release version with multiple sources</warn>
    <warn>Use --source-ids to select a single source</warn>`)
@@ -147,27 +276,49 @@ func runPopulate(
 		}
 	}
 
+	hasFile := cmd.Flags().Changed("file")
+	fileSource := flags.fileSource
+	// Otherwise all sources of sources.yaml would be imported
+	if hasFile && (fileSource.ID <= 0 || strings.TrimSpace(fileSource.Path) == "") {
+		gn.Warn(`<warn>Cannot import --file without file and data source ID</warn>
+   <warn>Use --id to set the ID of the file (1000 or more for custom data)</warn>`)
+		err := fmt.Errorf("invalid flag combination")
+		slog.Error("invalid flag combination", "error", err)
+		return err
+	}
+	if hasFile && (hasSourceIDs || hasVersion || hasDate) {
+		gn.Warn(`<warn>--source-ids and release flags are ignored with --file</warn>`)
+		hasSourceIDs, hasVersion, hasDate = false, false, false
+	}
+
 	// Build options from explicitly set flags
 	var populateOpts []config.Option
 
+	if hasFile {
+		populateOpts = append(
+			populateOpts,
+			config.OptPopulateFile(fileSource),
+		)
+	}
+
 	if hasSourceIDs {
 		populateOpts = append(
 			populateOpts,
-			config.OptPopulateSourceIDs(sourceIDs),
+			config.OptPopulateSourceIDs(flags.sourceIDs),
 		)
 	}
 
 	if hasVersion {
 		populateOpts = append(
 			populateOpts,
-			config.OptPopulateReleaseVersion(releaseVersion),
+			config.OptPopulateReleaseVersion(flags.releaseVersion),
 		)
 	}
 
 	if hasDate {
 		populateOpts = append(
 			populateOpts,
-			config.OptPopulateReleaseDate(releaseDate),
+			config.OptPopulateReleaseDate(flags.releaseDate),
 		)
 	}
 
@@ -176,7 +327,7 @@ func runPopulate(
 		populateOpts = append(
 			populateOpts,
 			config.OptPopulateWithFlatClassification(
-				&flatClassification,
+				&flags.flatClassification,
 			),
 		)
 		gn.Info(
@@ -185,11 +336,93 @@ func runPopulate(
 		)
 	}
 
+	if cmd.Flags().Changed("resume") {
+		populateOpts = append(
+			populateOpts,
+			config.OptPopulateResume(flags.resume),
+		)
+	}
+
+	if cmd.Flags().Changed("parallel-sources") {
+		populateOpts = append(
+			populateOpts,
+			config.OptPopulateParallelSources(flags.parallelSources),
+		)
+	}
+
+	if cmd.Flags().Changed("delta") {
+		populateOpts = append(
+			populateOpts,
+			config.OptPopulateDelta(flags.delta),
+		)
+	}
+
+	if cmd.Flags().Changed("non-interactive") {
+		populateOpts = append(
+			populateOpts,
+			config.OptPopulateNonInteractive(flags.nonInteractive),
+		)
+	}
+
+	if cmd.Flags().Changed("on-empty-name-string") {
+		populateOpts = append(
+			populateOpts,
+			config.OptPopulateOnEmptyNameString(flags.onEmptyNameString),
+		)
+	}
+
+	if cmd.Flags().Changed("on-duplicate-record-id") {
+		populateOpts = append(
+			populateOpts,
+			config.OptPopulateOnDuplicateRecordID(flags.onDuplicateID),
+		)
+	}
+
+	if cmd.Flags().Changed("on-dangling-synonym") {
+		populateOpts = append(
+			populateOpts,
+			config.OptPopulateOnDanglingSynonym(flags.onDanglingSynonym),
+		)
+	}
+
+	if cmd.Flags().Changed("dry-run") {
+		populateOpts = append(
+			populateOpts,
+			config.OptPopulateDryRun(flags.dryRun),
+		)
+	}
+
+	if cmd.Flags().Changed("strict-hierarchy") {
+		populateOpts = append(
+			populateOpts,
+			config.OptPopulateStrictHierarchy(flags.strictHierarchy),
+		)
+	}
+
+	if cmd.Flags().Changed("max-hierarchy-defect-ratio") {
+		populateOpts = append(
+			populateOpts,
+			config.OptPopulateMaxHierarchyDefectRatio(flags.maxDefectRatio),
+		)
+	}
+
+	if cmd.Flags().Changed("force") {
+		populateOpts = append(
+			populateOpts,
+			config.OptPopulateForce(flags.force),
+		)
+	}
+
 	// Apply populate-specific options to config
 	if len(populateOpts) > 0 {
 		cfg.Update(populateOpts)
 	}
 
+	// Dry run reads SFGA files only, it does not need the database
+	if cfg.Populate.DryRun {
+		return iopopulate.New(cfg, iodb.NewPgxOperator(), progress).Populate(ctx)
+	}
+
 	// Create database operator
 	op := iodb.NewPgxOperator()
 	if err := op.Connect(ctx, &cfg.Database); err != nil {
@@ -218,11 +451,11 @@ func runPopulate(
 	}
 
 	// Create populator
-	populator := iopopulate.New(cfg, op)
+	populator := iopopulate.New(cfg, op, progress)
 
 	// Run populate
 	gn.Info("Starting data population from SFGA sources...")
-	if err := populator.Populate(); err != nil {
+	if err := populator.Populate(ctx); err != nil {
 		return err
 	}
 
@@ -117,6 +117,143 @@ func TestGetPopulateCmd_FlatClassificationFlag(t *testing.T) {
 		"Usage should mention classification")
 }
 
+// TestGetPopulateCmd_ResumeFlag verifies --resume flag exists.
+func TestGetPopulateCmd_ResumeFlag(t *testing.T) {
+	cmd := getPopulateCmd()
+
+	flag := cmd.Flags().Lookup("resume")
+	require.NotNil(t, flag,
+		"--resume flag should exist")
+
+	assert.Equal(t, "false", flag.DefValue,
+		"Resume should be off by default")
+	assert.Contains(t, flag.Usage, "checkpoints",
+		"Usage should mention checkpoints")
+}
+
+// TestGetPopulateCmd_ParallelSourcesFlag verifies the
+// --parallel-sources flag exists with correct settings.
+func TestGetPopulateCmd_ParallelSourcesFlag(t *testing.T) {
+	cmd := getPopulateCmd()
+
+	flag := cmd.Flags().Lookup("parallel-sources")
+	require.NotNil(t, flag,
+		"--parallel-sources flag should exist")
+
+	assert.Equal(t, "p", flag.Shorthand,
+		"Should have -p shorthand")
+	assert.Equal(t, "1", flag.DefValue,
+		"Sources should be imported one at a time by default")
+}
+
+// TestGetPopulateCmd_DeltaFlag verifies the --delta flag
+// exists and is off by default.
+func TestGetPopulateCmd_DeltaFlag(t *testing.T) {
+	cmd := getPopulateCmd()
+
+	flag := cmd.Flags().Lookup("delta")
+	require.NotNil(t, flag,
+		"--delta flag should exist")
+
+	assert.Equal(t, "false", flag.DefValue,
+		"Delta should be off by default")
+}
+
+// TestGetPopulateCmd_PolicyFlags verifies the --non-interactive,
+// --on-empty-name-string, --on-duplicate-record-id and
+// --on-dangling-synonym flags exist and have empty defaults.
+func TestGetPopulateCmd_PolicyFlags(t *testing.T) {
+	cmd := getPopulateCmd()
+
+	flag := cmd.Flags().Lookup("non-interactive")
+	require.NotNil(t, flag,
+		"--non-interactive flag should exist")
+	assert.Equal(t, "false", flag.DefValue,
+		"Populate should be interactive by default")
+
+	flag = cmd.Flags().Lookup("on-empty-name-string")
+	require.NotNil(t, flag,
+		"--on-empty-name-string flag should exist")
+	assert.Equal(t, "", flag.DefValue,
+		"Empty name-string policy should not be set by default")
+
+	flag = cmd.Flags().Lookup("on-duplicate-record-id")
+	require.NotNil(t, flag,
+		"--on-duplicate-record-id flag should exist")
+	assert.Equal(t, "", flag.DefValue,
+		"Duplicate record ID policy should not be set by default")
+
+	flag = cmd.Flags().Lookup("on-dangling-synonym")
+	require.NotNil(t, flag,
+		"--on-dangling-synonym flag should exist")
+	assert.Equal(t, "", flag.DefValue,
+		"Dangling synonym policy should not be set by default")
+}
+
+// TestGetPopulateCmd_DryRunFlag verifies the --dry-run flag
+// exists and is off by default.
+func TestGetPopulateCmd_DryRunFlag(t *testing.T) {
+	cmd := getPopulateCmd()
+
+	flag := cmd.Flags().Lookup("dry-run")
+	require.NotNil(t, flag,
+		"--dry-run flag should exist")
+
+	assert.Equal(t, "false", flag.DefValue,
+		"Dry run should be off by default")
+}
+
+// TestGetPopulateCmd_StrictHierarchyFlags verifies hierarchy
+// defect flags exist with their defaults.
+func TestGetPopulateCmd_StrictHierarchyFlags(t *testing.T) {
+	cmd := getPopulateCmd()
+
+	strict := cmd.Flags().Lookup("strict-hierarchy")
+	require.NotNil(t, strict,
+		"--strict-hierarchy flag should exist")
+	assert.Equal(t, "false", strict.DefValue,
+		"Strict hierarchy should be off by default")
+
+	ratio := cmd.Flags().Lookup("max-hierarchy-defect-ratio")
+	require.NotNil(t, ratio,
+		"--max-hierarchy-defect-ratio flag should exist")
+	assert.Equal(t, "0", ratio.DefValue,
+		"Default ratio should allow no defects")
+}
+
+// TestGetPopulateCmd_ForceFlag verifies --force flag exists
+// and is off by default.
+func TestGetPopulateCmd_ForceFlag(t *testing.T) {
+	cmd := getPopulateCmd()
+
+	flag := cmd.Flags().Lookup("force")
+	require.NotNil(t, flag,
+		"--force flag should exist")
+	assert.Equal(t, "false", flag.DefValue,
+		"Force should be off by default")
+	assert.Contains(t, flag.Usage, "release",
+		"Usage should mention release")
+}
+
+// TestGetPopulateCmd_FileFlags verifies flags of an ad-hoc
+// source imported from a file.
+func TestGetPopulateCmd_FileFlags(t *testing.T) {
+	cmd := getPopulateCmd()
+
+	for _, name := range []string{
+		"file", "id", "title", "outlink-url", "outlink-id-column",
+	} {
+		flag := cmd.Flags().Lookup(name)
+		require.NotNil(t, flag, "--%s flag should exist", name)
+	}
+
+	id := cmd.Flags().Lookup("id")
+	assert.Equal(t, "0", id.DefValue,
+		"File source should have no ID by default")
+	assert.Contains(t, cmd.Flags().Lookup("file").Usage, "sources.yaml",
+		"Usage should mention sources.yaml")
+}
+
 // TestGetPopulateCmd_IndependentInstances verifies each
 // call returns independent instance.
 func TestGetPopulateCmd_IndependentInstances(t *testing.T) {
@@ -220,8 +357,8 @@ func TestPopulate_EndToEnd_VASCAN(t *testing.T) {
 	require.NoError(t, err)
 
 	// Run populate
-	populator := iopopulate.New(testCfg, op)
-	err = populator.Populate()
+	populator := iopopulate.New(testCfg, op, nil)
+	err = populator.Populate(ctx)
 	require.NoError(t, err, "Populate should succeed")
 
 	// Verify data was imported
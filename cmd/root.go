@@ -30,6 +30,7 @@ import (
 	"github.com/gnames/gn"
 	"github.com/gnames/gndb/internal/iofs"
 	"github.com/gnames/gndb/internal/iologger"
+	"github.com/gnames/gndb/internal/ioprogress"
 	"github.com/gnames/gndb/pkg/config"
 	"github.com/gnames/gndb/pkg/gndb"
 	"github.com/spf13/cobra"
@@ -80,6 +81,22 @@ For more information, see the project's README file.`,
 	// Override version flag to use -V (consistent with other gn projects)
 	rootCmd.Flags().BoolP("version", "V", false, "version of gndb")
 
+	// Run reports of populate, optimize and export
+	rootCmd.PersistentFlags().String(
+		"report-dir", "",
+		"directory for run reports (default ~/.local/share/gndb/reports)",
+	)
+	rootCmd.PersistentFlags().Bool(
+		"report-markdown", false,
+		"also write run reports in Markdown format",
+	)
+
+	// Progress output of populate, optimize and export
+	rootCmd.PersistentFlags().String(
+		"progress", "text",
+		"progress output: text|json (JSON lines to stdout)",
+	)
+
 	// Add subcommands
 	rootCmd.AddCommand(getCreateCmd())
 	rootCmd.AddCommand(getMigrateCmd())
@@ -87,6 +104,8 @@ For more information, see the project's README file.`,
 	rootCmd.AddCommand(getOptimizeCmd())
 	rootCmd.AddCommand(getExportCmd())
 	rootCmd.AddCommand(getDeleteCmd())
+	rootCmd.AddCommand(getCacheCmd())
+	rootCmd.AddCommand(getSourcesCmd())
 
 	return rootCmd
 }
@@ -166,6 +185,7 @@ func bootstrap(cmd *cobra.Command, args []string) error {
 	cfg.Update(opts)
 	// Set HomeDir after config is loaded
 	cfg.Update([]config.Option{config.OptHomeDir(homeDir)})
+	cfg.Update(reportOptions(cmd))
 
 	// Reconfigure logging with user's settings and proper log file location
 	if err = reconfigureLogging(cfg); err != nil {
@@ -188,6 +208,36 @@ func bootstrap(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// reportOptions returns options for run reports set by persistent flags.
+func reportOptions(cmd *cobra.Command) []config.Option {
+	var res []config.Option
+	if cmd.Flags().Changed("report-dir") {
+		dir, _ := cmd.Flags().GetString("report-dir")
+		res = append(res, config.OptReportDir(dir))
+	}
+	if cmd.Flags().Changed("report-markdown") {
+		md, _ := cmd.Flags().GetBool("report-markdown")
+		res = append(res, config.OptReportWithMarkdown(md))
+	}
+	return res
+}
+
+// progressSink returns the progress sink set by the --progress flag.
+func progressSink(cmd *cobra.Command) (gndb.ProgressSink, error) {
+	format, _ := cmd.Flags().GetString("progress")
+	switch format {
+	case "", "text":
+		return ioprogress.NewTerminal(), nil
+	case "json":
+		return ioprogress.NewJSONLines(os.Stdout), nil
+	}
+	gn.Warn(`<warn>Unknown progress output '%s'</warn>
+   <warn>Use --progress text or --progress json</warn>`, format)
+	err := fmt.Errorf("invalid progress output %q", format)
+	slog.Error("invalid progress output", "error", err)
+	return nil, err
+}
+
 // reconfigureLogging reinitializes the logger with the loaded configuration.
 // Creates log file in the proper location now that we know HomeDir.
 // Appends to existing log file to preserve bootstrap logs.
@@ -275,6 +325,15 @@ func initEnvVars(v *viper.Viper) {
 	_ = v.BindEnv("log.destination", "LOG_DESTINATION")
 	slog.Info("Log environment variables bound")
 
+	// Cache configuration
+	_ = v.BindEnv("cache.max_size_gb", "CACHE_MAX_SIZE_GB")
+
+	// S3 configuration
+	_ = v.BindEnv("s3.endpoint", "S3_ENDPOINT")
+	_ = v.BindEnv("s3.region", "S3_REGION")
+	_ = v.BindEnv("s3.access_key_id", "S3_ACCESS_KEY_ID")
+	_ = v.BindEnv("s3.secret_access_key", "S3_SECRET_ACCESS_KEY")
+
 	// General configuration
 	_ = v.BindEnv("jobs_number", "JOBS_NUMBER")
 
@@ -158,6 +158,41 @@ func TestGetRootCmd_VersionTemplate(t *testing.T) {
 		"Should use custom version template")
 }
 
+// TestGetRootCmd_ReportFlags verifies run report flags are
+// available to all subcommands.
+func TestGetRootCmd_ReportFlags(t *testing.T) {
+	cmd := getRootCmd()
+
+	flag := cmd.PersistentFlags().Lookup("report-dir")
+	require.NotNil(t, flag, "--report-dir flag should exist")
+	assert.Equal(t, "", flag.DefValue)
+
+	flag = cmd.PersistentFlags().Lookup("report-markdown")
+	require.NotNil(t, flag, "--report-markdown flag should exist")
+	assert.Equal(t, "false", flag.DefValue)
+}
+
+// TestGetRootCmd_ProgressFlag verifies the --progress flag and the
+// sinks it selects.
+func TestGetRootCmd_ProgressFlag(t *testing.T) {
+	cmd := getRootCmd()
+
+	flag := cmd.PersistentFlags().Lookup("progress")
+	require.NotNil(t, flag, "--progress flag should exist")
+	assert.Equal(t, "text", flag.DefValue)
+
+	for _, v := range []string{"text", "json"} {
+		require.NoError(t, cmd.ParseFlags([]string{"--progress", v}))
+		sink, err := progressSink(cmd)
+		require.NoError(t, err, v)
+		assert.NotNil(t, sink, v)
+	}
+
+	require.NoError(t, cmd.ParseFlags([]string{"--progress", "xml"}))
+	_, err := progressSink(cmd)
+	assert.Error(t, err)
+}
+
 // TestGetRootCmd_IndependentInstances verifies each
 // call returns independent instance.
 func TestGetRootCmd_IndependentInstances(t *testing.T) {
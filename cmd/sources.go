@@ -0,0 +1,156 @@
+/*
+Copyright © 2025 Dmitry Mozzherin <dmozzherin@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/internal/iodb"
+	"github.com/gnames/gndb/pkg/errcode"
+	"github.com/gnames/gndb/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+// getSourcesCmd returns the sources command with its subcommands.
+func getSourcesCmd() *cobra.Command {
+	sourcesCmd := &cobra.Command{
+		Use:   "sources",
+		Short: "Show information about imported data sources",
+		Long: `Show information about data sources imported to the database.
+
+Examples:
+  # Show quality profiles of all imported sources
+  gndb sources quality
+
+  # Show quality profiles of sources 1 and 11
+  gndb sources quality -s 1,11`,
+	}
+
+	sourcesCmd.AddCommand(getSourcesQualityCmd())
+
+	return sourcesCmd
+}
+
+func getSourcesQualityCmd() *cobra.Command {
+	var sourceIDs []int
+
+	qualityCmd := &cobra.Command{
+		Use:   "quality",
+		Short: "Show data quality profiles of imported sources",
+		Long: `Show the data quality profile that populate calculated for every
+imported data source. Shares are percentages of name records unless
+noted otherwise:
+
+  No parse, Clean, Minor, Major  parse quality 0, 1, 2 and 3 or worse
+  Surrogates, Hybrids            surrogate names, hybrids
+  Bare                           names that are neither taxa nor synonyms
+  Classif.                       names with a classification
+  Unresolved syn.                synonyms without accepted taxon
+  Vern. lang.                    vernacular records with a language
+
+Sources imported before quality profiles were introduced show zeros until
+they are imported again.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := runSourcesQuality(sourceIDs)
+			if err != nil {
+				gn.PrintErrorMessage(err)
+			}
+			return err
+		},
+	}
+
+	qualityCmd.Flags().IntSliceVarP(
+		&sourceIDs, "source-ids", "s", []int{},
+		"data source IDs to show (default all)",
+	)
+
+	return qualityCmd
+}
+
+func runSourcesQuality(sourceIDs []int) error {
+	ctx := context.Background()
+
+	op := iodb.NewPgxOperator()
+	if err := op.Connect(ctx, &cfg.Database); err != nil {
+		return err
+	}
+	defer op.Close()
+
+	hasTables, err := op.HasTables(ctx)
+	if err != nil {
+		return err
+	}
+	if !hasTables {
+		return &gn.Error{
+			Code: errcode.DBEmptyDatabaseError,
+			Msg: `<err>Database appears to be empty.</err>
+   Run <em>'gndb create'</em> first to initialize the schema.`,
+			Err: errors.New("cannot read sources of empty database"),
+		}
+	}
+
+	sources, err := op.GetQualityProfiles(ctx, sourceIDs)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		gn.Info("No imported data sources found")
+		return nil
+	}
+
+	printQualityProfiles(os.Stdout, sources)
+	return nil
+}
+
+// printQualityProfiles shows quality profiles of data sources as a table.
+func printQualityProfiles(out io.Writer, sources []schema.DataSource) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "ID\tTitle\tNames\tNo parse\tClean\tMinor\tMajor\t"+
+		"Surrogates\tHybrids\tBare\tClassif.\tUnresolved syn.\t"+
+		"Vernaculars\tVern. lang.\t")
+	for _, ds := range sources {
+		q := ds.Quality
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			ds.ID, ds.TitleShort, humanize.Comma(int64(ds.RecordCount)),
+			percent(q.NoParseShare), percent(q.CleanParseShare),
+			percent(q.MinorProblemsShare), percent(q.MajorProblemsShare),
+			percent(q.SurrogateShare), percent(q.HybridShare),
+			percent(q.BareNameShare), percent(q.ClassificationShare),
+			percent(q.UnresolvedSynonymShare),
+			humanize.Comma(int64(ds.VernRecordCount)),
+			percent(q.VernacularLanguageShare),
+		)
+	}
+	w.Flush()
+}
+
+// percent formats a share from 0 to 1 as a percentage.
+func percent(share float64) string {
+	return fmt.Sprintf("%.1f%%", share*100)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gnames/gndb/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetSourcesCmd_Subcommands verifies the sources command has
+// the quality subcommand with the --source-ids flag.
+func TestGetSourcesCmd_Subcommands(t *testing.T) {
+	cmd := getSourcesCmd()
+	require.NotNil(t, cmd, "Sources command should exist")
+	assert.Equal(t, "sources", cmd.Use)
+
+	sub, _, err := cmd.Find([]string{"quality"})
+	require.NoError(t, err)
+	assert.Equal(t, "quality", sub.Use)
+	assert.NotNil(t, sub.RunE)
+
+	flag := sub.Flags().Lookup("source-ids")
+	require.NotNil(t, flag, "--source-ids flag should exist")
+	assert.Equal(t, "s", flag.Shorthand)
+}
+
+// TestPrintQualityProfiles verifies the table of quality profiles.
+func TestPrintQualityProfiles(t *testing.T) {
+	sources := []schema.DataSource{
+		{
+			ID:              1,
+			TitleShort:      "Catalogue of Life",
+			RecordCount:     12000,
+			VernRecordCount: 300,
+			Quality: schema.QualityProfile{
+				CleanParseShare:         0.9,
+				BareNameShare:           0.125,
+				UnresolvedSynonymShare:  0.01,
+				VernacularLanguageShare: 1,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	printQualityProfiles(&buf, sources)
+
+	out := buf.String()
+	assert.Contains(t, out, "Catalogue of Life")
+	assert.Contains(t, out, "12,000")
+	assert.Contains(t, out, "90.0%")
+	assert.Contains(t, out, "12.5%")
+	assert.Contains(t, out, "1.0%")
+	assert.Contains(t, out, "100.0%")
+	assert.Contains(t, out, "Unresolved syn.")
+}
+
+func TestPercent(t *testing.T) {
+	assert.Equal(t, "0.0%", percent(0))
+	assert.Equal(t, "33.3%", percent(1.0/3))
+}
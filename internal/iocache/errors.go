@@ -0,0 +1,50 @@
+package iocache
+
+import (
+	"fmt"
+
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/errcode"
+)
+
+// IndexError creates an error for when the index of the SFGA archives
+// cache cannot be read or written.
+func IndexError(path string, err error) error {
+	msg := `Cannot use SFGA cache index <em>%s</em>
+
+<em>How to fix:</em>
+  Run <em>gndb cache clear</em> to start with an empty cache`
+	vars := []any{path}
+	return &gn.Error{
+		Code: errcode.CacheIndexError,
+		Msg:  msg,
+		Vars: vars,
+		Err:  fmt.Errorf("cache index %s: %w", path, err),
+	}
+}
+
+// DownloadError creates an error for when an SFGA archive cannot be
+// downloaded to the cache.
+func DownloadError(url string, err error) error {
+	msg := "Cannot download SFGA archive <em>%s</em>"
+	vars := []any{url}
+	return &gn.Error{
+		Code: errcode.CacheDownloadError,
+		Msg:  msg,
+		Vars: vars,
+		Err:  fmt.Errorf("cannot download %s: %w", url, err),
+	}
+}
+
+// RemoveError creates an error for when files of the SFGA archives
+// cache cannot be removed.
+func RemoveError(path string, err error) error {
+	msg := "Cannot remove cached files at <em>%s</em>"
+	vars := []any{path}
+	return &gn.Error{
+		Code: errcode.CacheRemoveError,
+		Msg:  msg,
+		Vars: vars,
+		Err:  fmt.Errorf("cannot remove %s: %w", path, err),
+	}
+}
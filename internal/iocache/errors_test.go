@@ -0,0 +1,57 @@
+package iocache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestErrors verifies error structure.
+func TestErrors(t *testing.T) {
+	originalErr := errors.New("permission denied")
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode gn.ErrorCode
+		wantVar  string
+	}{
+		{
+			name:     "index error",
+			err:      IndexError("/cache/index.json", originalErr),
+			wantCode: errcode.CacheIndexError,
+			wantVar:  "/cache/index.json",
+		},
+		{
+			name:     "download error",
+			err:      DownloadError("http://example.org/1.sqlite.zip", originalErr),
+			wantCode: errcode.CacheDownloadError,
+			wantVar:  "http://example.org/1.sqlite.zip",
+		},
+		{
+			name:     "remove error",
+			err:      RemoveError("/cache/abc", originalErr),
+			wantCode: errcode.CacheRemoveError,
+			wantVar:  "/cache/abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NotNil(t, tt.err)
+
+			gnErr, ok := tt.err.(*gn.Error)
+			require.True(t, ok, "Error should be of type *gn.Error")
+
+			assert.Equal(t, tt.wantCode, gnErr.Code)
+			assert.NotEmpty(t, gnErr.Msg)
+			require.Len(t, gnErr.Vars, 1)
+			assert.Equal(t, tt.wantVar, gnErr.Vars[0])
+			assert.ErrorIs(t, gnErr.Err, originalErr)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package iocache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Entry describes one archive stored in the cache.
+type Entry struct {
+	// Key identifies the archive by its file name, size and ETag.
+	Key string `json:"key"`
+
+	// File is the name of the archive, as it is on the remote server.
+	File string `json:"file"`
+
+	// URL is the address the archive was downloaded from.
+	URL string `json:"url"`
+
+	// Size is the size of the archive in bytes.
+	Size int64 `json:"size"`
+
+	// ETag is the entity tag sent by the server, it can be empty.
+	ETag string `json:"etag,omitempty"`
+
+	// SHA256 is the checksum of the downloaded archive.
+	SHA256 string `json:"sha256"`
+
+	AddedAt  time.Time `json:"added_at"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// cacheKey creates a key of an archive from its file name, size and
+// ETag. A new release of a source changes at least one of them, so it
+// never gets a stale archive from the cache.
+func cacheKey(file string, size int64, etag string) string {
+	etag = strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\x00%d\x00%s", file, size, etag))
+	return hex.EncodeToString(sum[:8])
+}
+
+// loadIndex reads cache entries from the index file. A missing index
+// means an empty cache.
+func loadIndex(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Entry
+	if err = json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// saveIndex writes cache entries sorted by key. The index is replaced
+// atomically, so an interrupted run cannot leave a broken file.
+func saveIndex(path string, entries []Entry) error {
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// evict selects least recently used entries to remove, until the total
+// size of the cache is not greater than maxSize. The entry with the keep
+// key is never removed, because it is being used.
+func evict(
+	entries []Entry,
+	maxSize int64,
+	keep string,
+) (kept []Entry, removed []Entry) {
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+
+	byUse := slices.Clone(entries)
+	slices.SortStableFunc(byUse, func(a, b Entry) int {
+		return a.LastUsed.Compare(b.LastUsed)
+	})
+
+	drop := make(map[string]bool)
+	for _, e := range byUse {
+		if total <= maxSize {
+			break
+		}
+		if e.Key == keep {
+			continue
+		}
+		drop[e.Key] = true
+		total -= e.Size
+	}
+
+	for _, e := range entries {
+		if drop[e.Key] {
+			removed = append(removed, e)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	return kept, removed
+}
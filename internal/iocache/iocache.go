@@ -0,0 +1,439 @@
+// Package iocache keeps downloaded SFGA archives between runs of gndb.
+// Archives are stored in ~/.cache/gndb/archives under a key made from
+// their file name, size and ETag, so a failed import can be restarted
+// without downloading multi-gigabyte files again. When the cache grows
+// over its size limit, least recently used archives are removed.
+// This is an impure I/O package.
+package iocache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gnames/gndb/internal/iofetch"
+	"github.com/gnames/gndb/internal/ios3"
+	"github.com/gnames/gndb/pkg/config"
+)
+
+// uncachedDir keeps archives of servers that do not answer HEAD
+// requests.
+const uncachedDir = "uncached"
+
+// Cache manages downloaded SFGA archives.
+type Cache struct {
+	dir     string
+	maxSize int64
+	client  *http.Client
+	fetcher *iofetch.Fetcher
+
+	// mu guards the index and keys when sources are imported in
+	// parallel.
+	mu sync.Mutex
+
+	// keys serialize fetches of the same archive, so parallel sources
+	// with the same URL download it only once.
+	keys map[string]*sync.Mutex
+}
+
+// New creates a Cache in the cache directory of cfg. Archives can be
+// downloaded from HTTP(S) and s3:// URLs.
+func New(cfg *config.Config) *Cache {
+	client := ios3.New(cfg.S3).HTTPClient()
+	return &Cache{
+		dir:     Dir(cfg.HomeDir),
+		maxSize: int64(cfg.Cache.MaxSizeGB) << 30,
+		client:  client,
+		keys:    make(map[string]*sync.Mutex),
+		fetcher: iofetch.New(client),
+	}
+}
+
+// Dir returns the directory of cached archives.
+// Returns ~/.cache/gndb/archives by default.
+func Dir(homeDir string) string {
+	return filepath.Join(config.CacheDir(homeDir), "archives")
+}
+
+// Fetch returns the local path of the archive at url. If the server
+// reports the same file name, size and ETag as an archive in the cache,
+// the cached file is returned and hit is true. If sha256 is not empty,
+// the archive must have this checksum. Otherwise the archive is
+// downloaded to the cache (see iofetch.Fetcher), and old archives are
+// evicted if the cache is over its size limit. If the server does not
+// answer HEAD requests, the archive is downloaded without caching.
+// Downloaded bytes are reported to progress if it is not nil.
+// Cancelling ctx stops the download.
+func (c *Cache) Fetch(
+	ctx context.Context,
+	url string,
+	sha256 string,
+	progress iofetch.Progress,
+) (filePath string, hit bool, err error) {
+	file := path.Base(url)
+	size, etag, err := c.head(ctx, url)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return "", false, DownloadError(url, ctxErr)
+	}
+	if err != nil {
+		slog.Warn("Cannot check SFGA archive, downloading it without cache",
+			"url", url,
+			"error", err,
+		)
+		filePath, err = c.downloadUncached(ctx, url, file, sha256, progress)
+		return filePath, false, err
+	}
+	key := cacheKey(file, size, etag)
+
+	unlock := c.lockKey(key)
+	defer unlock()
+
+	filePath, err = c.lookup(key, sha256)
+	if err != nil {
+		return "", false, IndexError(c.indexPath(), err)
+	}
+	if filePath != "" {
+		slog.Info("Using cached SFGA archive", "url", url, "path", filePath)
+		return filePath, true, nil
+	}
+
+	entry, err := c.download(ctx, url, key, file, size, sha256, progress)
+	if err != nil {
+		return "", false, err
+	}
+	entry.ETag = etag
+
+	if err = c.add(entry); err != nil {
+		return "", false, IndexError(c.indexPath(), err)
+	}
+	slog.Info("SFGA archive added to cache",
+		"url", url,
+		"size", entry.Size,
+		"sha256", entry.SHA256,
+	)
+	return c.entryPath(entry), false, nil
+}
+
+// List returns cached archives, most recently used first.
+func (c *Cache) List() ([]Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := loadIndex(c.indexPath())
+	if err != nil {
+		return nil, IndexError(c.indexPath(), err)
+	}
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return b.LastUsed.Compare(a.LastUsed)
+	})
+	return entries, nil
+}
+
+// Prune removes entries whose files are missing or have a wrong size,
+// then least recently used archives until the cache fits its size
+// limit. Files that do not belong to any entry, like unfinished
+// downloads, are removed as well.
+func (c *Cache) Prune() ([]Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := loadIndex(c.indexPath())
+	if err != nil {
+		return nil, IndexError(c.indexPath(), err)
+	}
+
+	var valid, removed []Entry
+	for _, e := range entries {
+		fi, err := os.Stat(c.entryPath(e))
+		if err != nil || fi.Size() != e.Size {
+			removed = append(removed, e)
+			continue
+		}
+		valid = append(valid, e)
+	}
+	valid, evicted := evict(valid, c.maxSize, "")
+	removed = append(removed, evicted...)
+
+	if err = c.removeEntries(removed); err != nil {
+		return nil, err
+	}
+	if err = c.removeOrphans(valid); err != nil {
+		return nil, err
+	}
+	if err = saveIndex(c.indexPath(), valid); err != nil {
+		return nil, IndexError(c.indexPath(), err)
+	}
+	return removed, nil
+}
+
+// Clear removes all cached archives.
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.RemoveAll(c.dir); err != nil {
+		return RemoveError(c.dir, err)
+	}
+	return nil
+}
+
+// Verify recalculates checksums of cached archives. Archives that are
+// missing or do not match their checksum are removed from the cache and
+// returned, they will be downloaded again when needed.
+func (c *Cache) Verify() (checked int, corrupted []Entry, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := loadIndex(c.indexPath())
+	if err != nil {
+		return 0, nil, IndexError(c.indexPath(), err)
+	}
+
+	var valid []Entry
+	for _, e := range entries {
+		sum, err := fileSHA256(c.entryPath(e))
+		if err != nil || sum != e.SHA256 {
+			slog.Warn("Cached SFGA archive is corrupted",
+				"file", e.File,
+				"key", e.Key,
+				"error", err,
+			)
+			corrupted = append(corrupted, e)
+			continue
+		}
+		valid = append(valid, e)
+	}
+
+	if err = c.removeEntries(corrupted); err != nil {
+		return 0, nil, err
+	}
+	if err = saveIndex(c.indexPath(), valid); err != nil {
+		return 0, nil, IndexError(c.indexPath(), err)
+	}
+	return len(entries), corrupted, nil
+}
+
+// head asks the server for the size and ETag of a file. Size is -1 if
+// the server does not report it.
+func (c *Cache) head(ctx context.Context, url string) (int64, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return resp.ContentLength, resp.Header.Get("ETag"), nil
+}
+
+// lockKey locks fetches of the archive with the given key and returns
+// the function that unlocks them.
+func (c *Cache) lockKey(key string) func() {
+	c.mu.Lock()
+	m, ok := c.keys[key]
+	if !ok {
+		m = &sync.Mutex{}
+		c.keys[key] = m
+	}
+	c.mu.Unlock()
+
+	m.Lock()
+	return m.Unlock
+}
+
+// lookup returns the path of a cached archive and marks it as used.
+// It returns an empty string if the archive is not in the cache, its
+// file is missing, or it does not have the expected sha256 checksum.
+func (c *Cache) lookup(key, sha256 string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := loadIndex(c.indexPath())
+	if err != nil {
+		return "", err
+	}
+
+	i := slices.IndexFunc(entries, func(e Entry) bool { return e.Key == key })
+	if i < 0 {
+		return "", nil
+	}
+	res := c.entryPath(entries[i])
+	if fi, err := os.Stat(res); err != nil || fi.Size() != entries[i].Size {
+		return "", nil
+	}
+	if sha256 != "" && sha256 != entries[i].SHA256 {
+		return "", nil
+	}
+
+	entries[i].LastUsed = time.Now()
+	return res, saveIndex(c.indexPath(), entries)
+}
+
+// download saves the archive at url to the cache directory of its key.
+// An unfinished download of the same archive is continued.
+func (c *Cache) download(
+	ctx context.Context,
+	url, key, file string,
+	size int64,
+	sha256 string,
+	progress iofetch.Progress,
+) (Entry, error) {
+	res := Entry{Key: key, File: file, URL: url}
+
+	dir := filepath.Join(c.dir, key)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return res, DownloadError(url, err)
+	}
+
+	fr, err := c.fetcher.Download(
+		ctx, url, filepath.Join(dir, file), sha256, progress,
+	)
+	if err != nil {
+		return res, err
+	}
+	if size >= 0 && fr.Size != size {
+		return res, DownloadError(url, fmt.Errorf(
+			"downloaded %d bytes, server reported %d", fr.Size, size,
+		))
+	}
+
+	res.Size = fr.Size
+	res.SHA256 = fr.SHA256
+	res.AddedAt = time.Now()
+	res.LastUsed = res.AddedAt
+	return res, nil
+}
+
+// downloadUncached saves the archive at url to a directory of the cache
+// that is not in the index. The archive is downloaded anew every time,
+// and the directory is removed by Prune.
+func (c *Cache) downloadUncached(
+	ctx context.Context,
+	url, file, sha256 string,
+	progress iofetch.Progress,
+) (string, error) {
+	key := uncachedDir + "/" + cacheKey(url, -1, "")
+	unlock := c.lockKey(key)
+	defer unlock()
+
+	dir := filepath.Join(c.dir, filepath.FromSlash(key))
+	if err := os.RemoveAll(dir); err != nil {
+		return "", RemoveError(dir, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", DownloadError(url, err)
+	}
+
+	res := filepath.Join(dir, file)
+	if _, err := c.fetcher.Download(ctx, url, res, sha256, progress); err != nil {
+		return "", err
+	}
+	return res, nil
+}
+
+// add stores a new entry in the index and evicts old archives if the
+// cache is over its size limit.
+func (c *Cache) add(entry Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := loadIndex(c.indexPath())
+	if err != nil {
+		return err
+	}
+	entries = slices.DeleteFunc(entries, func(e Entry) bool {
+		return e.Key == entry.Key
+	})
+	entries = append(entries, entry)
+
+	entries, removed := evict(entries, c.maxSize, entry.Key)
+	for _, e := range removed {
+		slog.Info("Evicting SFGA archive from cache",
+			"file", e.File,
+			"size", e.Size,
+			"last_used", e.LastUsed,
+		)
+	}
+	if err = c.removeEntries(removed); err != nil {
+		return err
+	}
+	return saveIndex(c.indexPath(), entries)
+}
+
+// removeEntries deletes files of the given entries.
+func (c *Cache) removeEntries(entries []Entry) error {
+	for _, e := range entries {
+		dir := filepath.Join(c.dir, e.Key)
+		if err := os.RemoveAll(dir); err != nil {
+			return RemoveError(dir, err)
+		}
+	}
+	return nil
+}
+
+// removeOrphans deletes directories of the cache that do not belong to
+// any of the entries.
+func (c *Cache) removeOrphans(entries []Entry) error {
+	dirs, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return RemoveError(c.dir, err)
+	}
+
+	keys := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		keys[e.Key] = true
+	}
+	for _, d := range dirs {
+		if !d.IsDir() || keys[d.Name()] {
+			continue
+		}
+		dir := filepath.Join(c.dir, d.Name())
+		if err = os.RemoveAll(dir); err != nil {
+			return RemoveError(dir, err)
+		}
+	}
+	return nil
+}
+
+func (c *Cache) indexPath() string {
+	return filepath.Join(c.dir, "index.json")
+}
+
+func (c *Cache) entryPath(e Entry) string {
+	return filepath.Join(c.dir, e.Key, e.File)
+}
+
+// fileSHA256 calculates the SHA-256 checksum of a file.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
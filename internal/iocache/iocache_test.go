@@ -0,0 +1,332 @@
+package iocache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gnames/gndb/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer serves files from the map, ETag of a file is its
+// content, gets counts downloads.
+func newTestServer(files map[string]string, gets *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, ok := files[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("ETag", `"`+body+`"`)
+			if r.Method == http.MethodGet {
+				*gets++
+			}
+			_, _ = w.Write([]byte(body))
+		}))
+}
+
+func newTestCache(t *testing.T) *Cache {
+	cfg := config.New()
+	cfg.Update([]config.Option{config.OptHomeDir(t.TempDir())})
+	return New(cfg)
+}
+
+func TestFetch(t *testing.T) {
+	files := map[string]string{"/0001-col-2025-01-01.sqlite.zip": "v1"}
+	var gets int
+	srv := newTestServer(files, &gets)
+	defer srv.Close()
+	url := srv.URL + "/0001-col-2025-01-01.sqlite.zip"
+
+	c := newTestCache(t)
+
+	path, hit, err := c.Fetch(context.Background(), url, "", nil)
+	require.NoError(t, err)
+	assert.False(t, hit)
+	assert.Equal(t, "0001-col-2025-01-01.sqlite.zip", filepath.Base(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+
+	// The same remote file is taken from the cache.
+	path2, hit, err := c.Fetch(context.Background(), url, "", nil)
+	require.NoError(t, err)
+	assert.True(t, hit)
+	assert.Equal(t, path, path2)
+	assert.Equal(t, 1, gets)
+
+	// A changed file gets a new key and is downloaded again.
+	files["/0001-col-2025-01-01.sqlite.zip"] = "v2"
+	path3, hit, err := c.Fetch(context.Background(), url, "", nil)
+	require.NoError(t, err)
+	assert.False(t, hit)
+	assert.NotEqual(t, path, path3)
+	assert.Equal(t, 2, gets)
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, `"v2"`, entries[0].ETag)
+	assert.Equal(t, int64(2), entries[0].Size)
+	assert.NotEmpty(t, entries[0].SHA256)
+}
+
+func TestFetchS3(t *testing.T) {
+	files := map[string]string{"/bucket/sfga/0001-col.sqlite.zip": "v1"}
+	var gets int
+	srv := newTestServer(files, &gets)
+	defer srv.Close()
+
+	cfg := config.New()
+	cfg.Update([]config.Option{
+		config.OptHomeDir(t.TempDir()),
+		config.OptS3Endpoint(srv.URL),
+	})
+	c := New(cfg)
+
+	path, hit, err := c.Fetch(context.Background(), "s3://bucket/sfga/0001-col.sqlite.zip", "", nil)
+	require.NoError(t, err)
+	assert.False(t, hit)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+
+	_, hit, err = c.Fetch(context.Background(), "s3://bucket/sfga/0001-col.sqlite.zip", "", nil)
+	require.NoError(t, err)
+	assert.True(t, hit)
+	assert.Equal(t, 1, gets)
+}
+
+func TestFetchNotFound(t *testing.T) {
+	var gets int
+	srv := newTestServer(nil, &gets)
+	defer srv.Close()
+
+	c := newTestCache(t)
+	_, _, err := c.Fetch(context.Background(), srv.URL+"/missing.sqlite.zip", "", nil)
+	assert.Error(t, err)
+}
+
+func TestFetchNoHead(t *testing.T) {
+	var gets int
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/0001-col.sqlite.zip" {
+				http.NotFound(w, r)
+				return
+			}
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			gets++
+			_, _ = w.Write([]byte("v1"))
+		}))
+	defer srv.Close()
+	url := srv.URL + "/0001-col.sqlite.zip"
+
+	c := newTestCache(t)
+
+	// Without HEAD the archive is downloaded, but not cached.
+	for range 2 {
+		path, hit, err := c.Fetch(context.Background(), url, "", nil)
+		require.NoError(t, err)
+		assert.False(t, hit)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "v1", string(data))
+	}
+	assert.Equal(t, 2, gets)
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Prune removes uncached downloads.
+	_, err = c.Prune()
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(c.dir, uncachedDir))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFetchParallel(t *testing.T) {
+	files := map[string]string{"/0001-col.sqlite.zip": "v1"}
+	var gets int
+	srv := newTestServer(files, &gets)
+	defer srv.Close()
+	url := srv.URL + "/0001-col.sqlite.zip"
+
+	c := newTestCache(t)
+
+	var wg sync.WaitGroup
+	paths := make([]string, 4)
+	for i := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path, _, err := c.Fetch(context.Background(), url, "", nil)
+			assert.NoError(t, err)
+			paths[i] = path
+		}()
+	}
+	wg.Wait()
+
+	// The archive is downloaded once, other fetches wait for it.
+	assert.Equal(t, 1, gets)
+	for _, p := range paths {
+		assert.Equal(t, paths[0], p)
+	}
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFetchCancel(t *testing.T) {
+	var gets int
+	srv := newTestServer(map[string]string{"/1.sqlite.zip": "v1"}, &gets)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := newTestCache(t)
+	_, _, err := c.Fetch(ctx, srv.URL+"/1.sqlite.zip", "", nil)
+	assert.Error(t, err)
+	assert.Equal(t, 0, gets)
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestFetchEvicts(t *testing.T) {
+	files := map[string]string{
+		"/1.sqlite.zip": "aaaa",
+		"/2.sqlite.zip": "bbbb",
+	}
+	var gets int
+	srv := newTestServer(files, &gets)
+	defer srv.Close()
+
+	c := newTestCache(t)
+	c.maxSize = 6
+
+	path1, _, err := c.Fetch(context.Background(), srv.URL+"/1.sqlite.zip", "", nil)
+	require.NoError(t, err)
+	_, _, err = c.Fetch(context.Background(), srv.URL+"/2.sqlite.zip", "", nil)
+	require.NoError(t, err)
+
+	// The first archive was least recently used.
+	_, err = os.Stat(path1)
+	assert.True(t, os.IsNotExist(err))
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "2.sqlite.zip", entries[0].File)
+}
+
+func TestVerify(t *testing.T) {
+	files := map[string]string{
+		"/1.sqlite.zip": "aaaa",
+		"/2.sqlite.zip": "bbbb",
+	}
+	var gets int
+	srv := newTestServer(files, &gets)
+	defer srv.Close()
+
+	c := newTestCache(t)
+	path1, _, err := c.Fetch(context.Background(), srv.URL+"/1.sqlite.zip", "", nil)
+	require.NoError(t, err)
+	_, _, err = c.Fetch(context.Background(), srv.URL+"/2.sqlite.zip", "", nil)
+	require.NoError(t, err)
+
+	err = os.WriteFile(path1, []byte("xxxx"), 0644)
+	require.NoError(t, err)
+
+	checked, corrupted, err := c.Verify()
+	require.NoError(t, err)
+	assert.Equal(t, 2, checked)
+	require.Len(t, corrupted, 1)
+	assert.Equal(t, "1.sqlite.zip", corrupted[0].File)
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "2.sqlite.zip", entries[0].File)
+}
+
+func TestPruneAndClear(t *testing.T) {
+	files := map[string]string{
+		"/1.sqlite.zip": "aaaa",
+		"/2.sqlite.zip": "bbbb",
+	}
+	var gets int
+	srv := newTestServer(files, &gets)
+	defer srv.Close()
+
+	c := newTestCache(t)
+	path1, _, err := c.Fetch(context.Background(), srv.URL+"/1.sqlite.zip", "", nil)
+	require.NoError(t, err)
+	_, _, err = c.Fetch(context.Background(), srv.URL+"/2.sqlite.zip", "", nil)
+	require.NoError(t, err)
+
+	// Leftover of an interrupted download.
+	orphan := filepath.Join(c.dir, "orphan")
+	require.NoError(t, os.MkdirAll(orphan, 0755))
+	require.NoError(t, os.Remove(path1))
+
+	removed, err := c.Prune()
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.Equal(t, "1.sqlite.zip", removed[0].File)
+	_, err = os.Stat(orphan)
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, c.Clear())
+	entries, err := c.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestEvict(t *testing.T) {
+	now := time.Now()
+	entries := []Entry{
+		{Key: "a", Size: 10, LastUsed: now.Add(-3 * time.Hour)},
+		{Key: "b", Size: 10, LastUsed: now.Add(-2 * time.Hour)},
+		{Key: "c", Size: 10, LastUsed: now.Add(-1 * time.Hour)},
+	}
+
+	kept, removed := evict(entries, 20, "")
+	assert.Len(t, kept, 2)
+	require.Len(t, removed, 1)
+	assert.Equal(t, "a", removed[0].Key)
+
+	// The entry in use is kept even if it is the oldest one.
+	kept, removed = evict(entries, 15, "a")
+	assert.Len(t, kept, 1)
+	assert.Equal(t, "a", kept[0].Key)
+	assert.Len(t, removed, 2)
+
+	kept, removed = evict(entries, 100, "")
+	assert.Len(t, kept, 3)
+	assert.Empty(t, removed)
+}
+
+func TestCacheKey(t *testing.T) {
+	k := cacheKey("1.sqlite.zip", 100, `"abc"`)
+	assert.Len(t, k, 16)
+	assert.Equal(t, k, cacheKey("1.sqlite.zip", 100, `W/"abc"`))
+	assert.NotEqual(t, k, cacheKey("1.sqlite.zip", 101, `"abc"`))
+	assert.NotEqual(t, k, cacheKey("1.sqlite.zip", 100, `"abd"`))
+	assert.NotEqual(t, k, cacheKey("2.sqlite.zip", 100, `"abc"`))
+}
@@ -275,10 +275,64 @@ func (p *pgxOperator) GetDataSources(
 	return sources, nil
 }
 
+// GetQualityProfiles returns DataSource records with record counts and
+// quality profiles for the given IDs. If ids is empty, all data sources
+// are returned.
+func (p *pgxOperator) GetQualityProfiles(
+	ctx context.Context,
+	ids []int,
+) ([]schema.DataSource, error) {
+	if p.pool == nil {
+		return nil, NotConnectedError()
+	}
+
+	query := `SELECT id, title_short, record_count, vern_record_count,
+	quality_no_parse_share, quality_clean_parse_share,
+	quality_minor_problems_share, quality_major_problems_share,
+	quality_surrogate_share, quality_hybrid_share,
+	quality_bare_name_share, quality_unresolved_synonym_share,
+	quality_classification_share, quality_vernacular_language_share
+FROM data_sources`
+	var args []any
+	if len(ids) > 0 {
+		query += " WHERE id = ANY($1)"
+		args = append(args, ids)
+	}
+	query += " ORDER BY id"
+
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, QueryDataSourcesError(err)
+	}
+	defer rows.Close()
+
+	var sources []schema.DataSource
+	for rows.Next() {
+		var ds schema.DataSource
+		q := &ds.Quality
+		if err := rows.Scan(
+			&ds.ID, &ds.TitleShort, &ds.RecordCount, &ds.VernRecordCount,
+			&q.NoParseShare, &q.CleanParseShare,
+			&q.MinorProblemsShare, &q.MajorProblemsShare,
+			&q.SurrogateShare, &q.HybridShare,
+			&q.BareNameShare, &q.UnresolvedSynonymShare,
+			&q.ClassificationShare, &q.VernacularLanguageShare,
+		); err != nil {
+			return nil, QueryDataSourcesError(err)
+		}
+		sources = append(sources, ds)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, QueryDataSourcesError(err)
+	}
+
+	return sources, nil
+}
+
 // DeleteDatasets removes all records for the given data source IDs.
-// It deletes from vernacular_string_indices, name_string_indices,
-// and data_sources. Orphaned name_strings/canonicals are left for
-// the optimize command to clean up.
+// It deletes from vernacular_string_indices, source_references,
+// name_string_indices, and data_sources. Orphaned
+// name_strings/canonicals are left for the optimize command to clean up.
 func (p *pgxOperator) DeleteDatasets(
 	ctx context.Context,
 	ids []int,
@@ -296,6 +350,8 @@ func (p *pgxOperator) DeleteDatasets(
 	}{
 		{"vernacular_string_indices",
 			"DELETE FROM vernacular_string_indices WHERE data_source_id = ANY($1)"},
+		{"source_references",
+			"DELETE FROM source_references WHERE data_source_id = ANY($1)"},
 		{"name_string_indices",
 			"DELETE FROM name_string_indices WHERE data_source_id = ANY($1)"},
 		{"data_sources",
@@ -313,7 +369,8 @@ func (p *pgxOperator) DeleteDatasets(
 
 // CreateMaterializedViews creates all materialized views for
 // the database. Currently creates the verification view used
-// for fast name lookups.
+// for fast name lookups. A pro parte synonym has a row for
+// every accepted name.
 func (p *pgxOperator) CreateMaterializedViews(
 	ctx context.Context,
 ) error {
@@ -329,6 +386,7 @@ WITH taxon_names AS (
 	FROM name_string_indices nsi
 	JOIN name_strings ns
 		ON nsi.name_string_id = ns.id
+	WHERE nsi.record_id = nsi.accepted_record_id
 )
 SELECT nsi.data_source_id, nsi.record_id, nsi.name_string_id,
 	ns.name, nsi.name_id, nsi.code_id, ns.year, ns.cardinality,
@@ -215,6 +215,47 @@ func TestPgxOperator_GetDataSources_All(t *testing.T) {
 	}
 }
 
+// TestPgxOperator_GetQualityProfiles verifies that quality
+// profiles of data sources are read and shares stay within 0..1.
+func TestPgxOperator_GetQualityProfiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	cfg := getTestDBConfig(t)
+	if cfg == nil {
+		t.Skip("Database not configured")
+	}
+
+	ctx := context.Background()
+	op := NewPgxOperator()
+
+	err := op.Connect(ctx, cfg)
+	require.NoError(t, err)
+	defer op.Close()
+
+	sources, err := op.GetQualityProfiles(ctx, []int{})
+	require.NoError(t, err)
+
+	for _, s := range sources {
+		assert.NotZero(t, s.ID,
+			"Each source must have a non-zero ID")
+		for _, share := range []float64{
+			s.Quality.NoParseShare,
+			s.Quality.BareNameShare,
+			s.Quality.ClassificationShare,
+		} {
+			assert.GreaterOrEqual(t, share, 0.0)
+			assert.LessOrEqual(t, share, 1.0)
+		}
+	}
+
+	sources, err = op.GetQualityProfiles(ctx, []int{999999})
+	require.NoError(t, err)
+	assert.Empty(t, sources,
+		"Non-existent IDs should return an empty slice")
+}
+
 // TestPgxOperator_DeleteDatasets_Empty verifies that
 // DeleteDatasets with an empty ID list is a no-op.
 func TestPgxOperator_DeleteDatasets_Empty(t *testing.T) {
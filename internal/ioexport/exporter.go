@@ -9,14 +9,19 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/gnames/gn"
+	"github.com/gnames/gndb/internal/ioprogress"
+	"github.com/gnames/gndb/internal/ioreport"
+	"github.com/gnames/gndb/internal/ios3"
 	"github.com/gnames/gndb/pkg/config"
 	"github.com/gnames/gndb/pkg/db"
 	"github.com/gnames/gndb/pkg/gndb"
+	"github.com/gnames/gndb/pkg/report"
 	"github.com/gnames/gndb/pkg/schema"
+	"github.com/gnames/gndb/pkg/sources"
 	"github.com/gnames/gnfmt"
 	"github.com/gnames/gnlib/ent/nomcode"
 	"github.com/gnames/gnparser"
@@ -32,11 +37,34 @@ type exporter struct {
 
 	// parsers holds one gnparser instance per nomenclatural code.
 	parsers map[nomcode.Code]gnparser.GNparser
+
+	// s3 uploads exported files when the output directory is an
+	// s3:// URL.
+	s3 *ios3.Client
+
+	// progress receives progress events of the export.
+	progress gndb.ProgressSink
 }
 
-// New creates a new Exporter.
-func New(cfg *config.Config, op db.Operator) gndb.Exporter {
-	return &exporter{cfg: cfg, operator: op}
+// exportSteps is the number of export stages of a data source.
+const exportSteps = 6
+
+// New creates a new Exporter. Progress events are sent to progress, or
+// to the terminal if progress is nil.
+func New(
+	cfg *config.Config,
+	op db.Operator,
+	progress gndb.ProgressSink,
+) gndb.Exporter {
+	if progress == nil {
+		progress = ioprogress.Default()
+	}
+	return &exporter{
+		cfg:      cfg,
+		operator: op,
+		s3:       ios3.New(cfg.S3),
+		progress: progress,
+	}
 }
 
 // Init validates preconditions and prepares state for Export:
@@ -66,9 +94,13 @@ func (e *exporter) Init(ctx context.Context) error {
 
 // Export reads data from PostgreSQL for the configured source IDs
 // and writes one SFGA .sqlite file per source into the output directory.
-func (e *exporter) Export() error {
+// The run report is saved when the export finishes or fails.
+func (e *exporter) Export() (err error) {
 	ctx := context.Background()
 
+	rep := report.New("export", e.cfg)
+	defer func() { ioreport.Save(rep, e.cfg, err) }()
+
 	if err := e.Init(ctx); err != nil {
 		return err
 	}
@@ -81,10 +113,11 @@ func (e *exporter) Export() error {
 	for i, ds := range e.sources {
 		sourceStart := time.Now()
 
-		fmt.Println()
-		fmt.Println(strings.Repeat("─", 60))
-		gn.Info("Data Source [%d]: %s", ds.ID, ds.TitleShort)
-		fmt.Println(strings.Repeat("─", 60))
+		e.progress.Emit(gndb.Event{
+			Type:     gndb.EventSourceStarted,
+			SourceID: int(ds.ID),
+			Message:  fmt.Sprintf("Data Source [%d]: %s", ds.ID, ds.TitleShort),
+		})
 
 		slog.Info("Exporting source",
 			"index", i+1,
@@ -93,40 +126,70 @@ func (e *exporter) Export() error {
 			"title", ds.TitleShort,
 		)
 
-		if err := e.exportSource(ctx, ds); err != nil {
+		src := &report.Source{ID: int(ds.ID), Title: ds.TitleShort}
+		err := e.exportSource(ctx, ds, src)
+		src.DurationSec = time.Since(sourceStart).Seconds()
+		if err != nil {
+			src.Status = report.StatusFailed
+			src.Error = report.NewError(err)
+			rep.AddSource(*src)
 			errorCount++
 			slog.Error("Failed to export source",
 				"source_id", ds.ID,
 				"title", ds.TitleShort,
 				"error", err,
 			)
-			gn.PrintErrorMessage(err)
+			e.progress.Emit(gndb.Event{
+				Type:     gndb.EventError,
+				SourceID: int(ds.ID),
+				Err:      err,
+			})
+			e.progress.Emit(gndb.Event{
+				Type:        gndb.EventSourceFinished,
+				SourceID:    int(ds.ID),
+				Status:      gndb.StatusFailed,
+				DurationSec: src.DurationSec,
+			})
 			continue
 		}
 
+		src.Status = report.StatusSucceeded
+		rep.AddSource(*src)
 		successCount++
 		exported = append(exported, ds)
 
-		dur := gnfmt.TimeString(time.Since(sourceStart).Seconds())
 		slog.Info("Source exported successfully",
 			"source_id", ds.ID,
 			"title", ds.TitleShort,
-			"duration", dur,
+			"duration_sec", src.DurationSec,
 		)
-		gn.Info("Completed in %s", dur)
+		e.progress.Emit(gndb.Event{
+			Type:        gndb.EventSourceFinished,
+			SourceID:    int(ds.ID),
+			Status:      gndb.StatusSucceeded,
+			DurationSec: src.DurationSec,
+			Message:     "Completed in",
+		})
 	}
 
 	// Write consolidated sources-export.yaml for all successful exports.
 	if err := writeConsolidatedYAML(exported, e.cfg); err != nil {
 		slog.Warn("Failed to write consolidated YAML", "error", err)
+	} else if err := e.upload("sources-export.yaml"); err != nil {
+		slog.Warn("Failed to upload consolidated YAML", "error", err)
 	}
 
-	totalDur := gnfmt.TimeString(time.Since(startTime).Seconds())
-	fmt.Println(strings.Repeat("─", 60))
-	gn.Info(`Export complete
+	totalSec := time.Since(startTime).Seconds()
+	e.progress.Emit(gndb.Event{
+		Type:        gndb.EventSummary,
+		Count:       successCount,
+		Total:       len(e.sources),
+		DurationSec: totalSec,
+		Message: fmt.Sprintf(`Export complete
 Sources succeeded: %d, failed: %d, total: %d.
 Elapsed time: <em>%s</em>
-`, successCount, errorCount, len(e.sources), totalDur)
+`, successCount, errorCount, len(e.sources), gnfmt.TimeString(totalSec)),
+	})
 
 	if errorCount > 0 && successCount == 0 {
 		return AllSourcesFailedError(errorCount)
@@ -134,8 +197,13 @@ Elapsed time: <em>%s</em>
 	return nil
 }
 
-// exportSource exports a single data source through all 5 stages.
-func (e *exporter) exportSource(ctx context.Context, ds schema.DataSource) error {
+// exportSource exports a single data source through all 6 stages.
+// Stages and their record counts are added to src.
+func (e *exporter) exportSource(
+	ctx context.Context,
+	ds schema.DataSource,
+	src *report.Source,
+) error {
 	pool := e.operator.Pool()
 	batchSize := e.cfg.Database.BatchSize
 
@@ -158,43 +226,60 @@ func (e *exporter) exportSource(ctx context.Context, ds schema.DataSource) error
 	}
 	defer arc.Close()
 
-	// (1/5) Metadata
+	// (1/6) Metadata
 	t := time.Now()
-	gn.Info("(1/5) writing metadata...")
+	startStage(e.progress, int(ds.ID), 1, "metadata", "writing metadata...")
 	if err := arc.InsertMeta(dataSourceToMeta(ds)); err != nil {
 		return SFGAWriteError(int(ds.ID), "metadata", err)
 	}
-	gn.Message("<em>Metadata written</em> %s",
-		gnfmt.TimeString(time.Since(t).Seconds()))
+	finishStage(e.progress, int(ds.ID), 1, "metadata", t, 1,
+		"<em>Metadata written</em>")
+	addStage(src, "metadata", t, 1)
 
-	// (2/5) Names
-	if _, err := exportNames(ctx, pool, arc, e.parsers, int(ds.ID), batchSize); err != nil {
+	// (2/6) Names
+	t = time.Now()
+	count, err := exportNames(ctx, pool, e.progress, arc, e.parsers, int(ds.ID), batchSize)
+	if err != nil {
 		return err
 	}
+	addStage(src, "names", t, count)
 
-	// (3/5) Taxa
-	if _, err := exportTaxa(ctx, pool, arc, int(ds.ID), batchSize); err != nil {
+	// (3/6) Taxa
+	t = time.Now()
+	if count, err = exportTaxa(ctx, pool, e.progress, arc, int(ds.ID), batchSize); err != nil {
 		return err
 	}
+	addStage(src, "taxa", t, count)
 
-	// (4/5) Synonyms
-	if _, err := exportSynonyms(ctx, pool, arc, int(ds.ID), batchSize); err != nil {
+	// (4/6) Synonyms
+	t = time.Now()
+	if count, err = exportSynonyms(ctx, pool, e.progress, arc, int(ds.ID), batchSize); err != nil {
 		return err
 	}
+	addStage(src, "synonyms", t, count)
 
-	// (5/5) Vernaculars
-	if _, err := exportVernaculars(ctx, pool, arc, int(ds.ID), batchSize); err != nil {
+	// (5/6) Vernaculars
+	t = time.Now()
+	if count, err = exportVernaculars(ctx, pool, e.progress, arc, int(ds.ID), batchSize); err != nil {
 		return err
 	}
+	addStage(src, "vernaculars", t, count)
 
-	// Export SFGA to output files (.sqlite + .sql, optional .zip).
-	outputDir := e.cfg.Export.OutputDir
-	if outputDir == "" {
-		outputDir = "."
+	// (6/6) References
+	t = time.Now()
+	if count, err = exportReferences(ctx, pool, e.progress, arc, int(ds.ID), batchSize); err != nil {
+		return err
 	}
-	basePath := buildOutputBase(ds, outputDir)
+	addStage(src, "references", t, count)
 
-	gn.Info("Writing SFGA files to %s...", basePath)
+	// Export SFGA to output files (.sqlite + .sql, optional .zip).
+	basePath := buildOutputBase(ds, outputDir(e.cfg))
+
+	e.progress.Emit(gndb.Event{
+		Type:     gndb.EventInfo,
+		SourceID: int(ds.ID),
+		Message:  fmt.Sprintf("Writing SFGA files to %s...", basePath),
+	})
 	if err := arc.Export(basePath, e.cfg.Export.WithZip); err != nil {
 		return SFGAWriteError(int(ds.ID), "export", err)
 	}
@@ -205,15 +290,73 @@ func (e *exporter) exportSource(ctx context.Context, ds schema.DataSource) error
 			"source_id", ds.ID, "error", err)
 	}
 
-	return nil
+	return e.upload(filepath.Base(basePath) + ".")
+}
+
+// startStage sends the start of export stage step of a data source.
+func startStage(
+	progress gndb.ProgressSink,
+	sourceID, step int,
+	name, msg string,
+) {
+	progress.Emit(gndb.Event{
+		Type:     gndb.EventStageStarted,
+		SourceID: sourceID,
+		Stage:    name,
+		Step:     step,
+		Steps:    exportSteps,
+		Message:  fmt.Sprintf("(%d/%d) %s", step, exportSteps, msg),
+	})
+}
+
+// finishStage sends the result of export stage step of a data source
+// that started at start and wrote count records.
+func finishStage(
+	progress gndb.ProgressSink,
+	sourceID, step int,
+	name string,
+	start time.Time,
+	count int,
+	msg string,
+) {
+	progress.Emit(gndb.Event{
+		Type:        gndb.EventStageFinished,
+		SourceID:    sourceID,
+		Stage:       name,
+		Step:        step,
+		Steps:       exportSteps,
+		Status:      gndb.StatusSucceeded,
+		Count:       count,
+		DurationSec: time.Since(start).Seconds(),
+		Message:     msg,
+	})
+}
+
+// addStage records an export stage and the number of written records.
+func addStage(src *report.Source, name string, start time.Time, count int) {
+	src.Stages = append(src.Stages, report.Stage{
+		Name:        name,
+		DurationSec: time.Since(start).Seconds(),
+		Inserted:    count,
+	})
+}
+
+// outputDir returns the local directory for exported files. Exports to
+// an s3:// URL are written to a staging directory in the cache first and
+// uploaded from there.
+func outputDir(cfg *config.Config) string {
+	switch {
+	case sources.IsS3URL(cfg.Export.OutputDir):
+		return filepath.Join(config.CacheDir(cfg.HomeDir), "export", "upload")
+	case cfg.Export.OutputDir == "":
+		return "."
+	}
+	return cfg.Export.OutputDir
 }
 
 // ensureOutputDir creates the output directory when it does not exist.
 func (e *exporter) ensureOutputDir() error {
-	dir := e.cfg.Export.OutputDir
-	if dir == "" {
-		dir = "."
-	}
+	dir := outputDir(e.cfg)
 
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return OutputDirError(dir, err)
@@ -223,6 +366,41 @@ func (e *exporter) ensureOutputDir() error {
 	return nil
 }
 
+// upload copies files of the staging directory whose names start with
+// prefix to the s3:// output directory and removes them locally. It does
+// nothing when the output directory is local.
+func (e *exporter) upload(prefix string) error {
+	if !sources.IsS3URL(e.cfg.Export.OutputDir) {
+		return nil
+	}
+
+	dir := outputDir(e.cfg)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return OutputDirError(dir, err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		url := strings.TrimSuffix(e.cfg.Export.OutputDir, "/") + "/" + name
+
+		e.progress.Emit(gndb.Event{
+			Type:    gndb.EventInfo,
+			Message: fmt.Sprintf("Uploading <em>%s</em>...", url),
+		})
+		if err = e.s3.Upload(path, url); err != nil {
+			return err
+		}
+		if err = os.Remove(path); err != nil {
+			slog.Warn("Failed to remove uploaded file", "path", path, "error", err)
+		}
+	}
+	return nil
+}
+
 // loadSources reads data_sources from PostgreSQL and filters to the
 // requested IDs (or returns all rows when SourceIDs is empty).
 func (e *exporter) loadSources(ctx context.Context) ([]schema.DataSource, error) {
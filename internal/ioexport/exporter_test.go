@@ -3,7 +3,10 @@ package ioexport
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/gnames/gndb/internal/iodb"
@@ -19,7 +22,7 @@ func TestNew_ImplementsInterface(t *testing.T) {
 	cfg := config.New()
 	op := iodb.NewPgxOperator()
 
-	var _ gndb.Exporter = New(cfg, op)
+	var _ gndb.Exporter = New(cfg, op, nil)
 }
 
 // TestNew_StoresConfig verifies cfg and operator are stored.
@@ -27,7 +30,7 @@ func TestNew_StoresConfig(t *testing.T) {
 	cfg := config.New()
 	op := iodb.NewPgxOperator()
 
-	e := New(cfg, op).(*exporter)
+	e := New(cfg, op, nil).(*exporter)
 
 	assert.Same(t, cfg, e.cfg)
 	assert.Equal(t, op, e.operator)
@@ -40,7 +43,7 @@ func TestInit_NilPool_ReturnsNotConnectedError(t *testing.T) {
 	cfg := config.New()
 	op := iodb.NewPgxOperator() // pool is nil before Connect
 
-	e := New(cfg, op)
+	e := New(cfg, op, nil)
 	err := e.(*exporter).Init(context.Background())
 
 	require.Error(t, err)
@@ -90,6 +93,72 @@ func TestEnsureOutputDir_EmptyOutputDir_UsesDot(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestEnsureOutputDir_S3_UsesStagingDir verifies that exports to an
+// s3:// URL are staged in the cache directory.
+func TestEnsureOutputDir_S3_UsesStagingDir(t *testing.T) {
+	home := t.TempDir()
+
+	cfg := config.New()
+	cfg.Update([]config.Option{
+		config.OptHomeDir(home),
+		config.OptExportOutputDir("s3://sfga/exports/"),
+	})
+
+	e := &exporter{cfg: cfg}
+	err := e.ensureOutputDir()
+
+	require.NoError(t, err)
+	dir := outputDir(cfg)
+	assert.Equal(t,
+		filepath.Join(config.CacheDir(home), "export", "upload"), dir)
+	_, statErr := os.Stat(dir)
+	assert.NoError(t, statErr)
+}
+
+// TestUpload_S3 verifies that staged files of a source are uploaded and
+// removed locally, other files stay.
+func TestUpload_S3(t *testing.T) {
+	var uploaded []string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut {
+				uploaded = append(uploaded, r.URL.Path)
+			}
+		}))
+	defer srv.Close()
+
+	cfg := config.New()
+	cfg.Update([]config.Option{
+		config.OptHomeDir(t.TempDir()),
+		config.OptExportOutputDir("s3://sfga/exports/"),
+		config.OptS3Endpoint(srv.URL),
+	})
+	e := New(cfg, iodb.NewPgxOperator(), nil).(*exporter)
+	require.NoError(t, e.ensureOutputDir())
+
+	dir := outputDir(cfg)
+	for _, name := range []string{
+		"0001-col-2025-01-01.sqlite",
+		"0001-col-2025-01-01.yaml",
+		"0002-gbif-2025-01-01.sqlite",
+	} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+		require.NoError(t, err)
+	}
+
+	err := e.upload("0001-col-2025-01-01.")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"/sfga/exports/0001-col-2025-01-01.sqlite",
+		"/sfga/exports/0001-col-2025-01-01.yaml",
+	}, uploaded)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "0002-gbif-2025-01-01.sqlite", files[0].Name())
+}
+
 // Integration tests (require PostgreSQL)
 
 // TestInit_Integration_LoadsAllSources verifies Init loads all sources
@@ -102,7 +171,7 @@ func TestInit_Integration_LoadsAllSources(t *testing.T) {
 	cfg, op := mustConnectTestDB(t)
 	defer op.Close()
 
-	e := New(cfg, op).(*exporter)
+	e := New(cfg, op, nil).(*exporter)
 	err := e.Init(context.Background())
 
 	require.NoError(t, err)
@@ -120,7 +189,7 @@ func TestInit_Integration_FiltersBySourceID(t *testing.T) {
 	defer op.Close()
 
 	// Load all first to find a valid ID.
-	e := New(cfg, op).(*exporter)
+	e := New(cfg, op, nil).(*exporter)
 	err := e.Init(context.Background())
 	require.NoError(t, err)
 	require.NotEmpty(t, e.sources)
@@ -131,7 +200,7 @@ func TestInit_Integration_FiltersBySourceID(t *testing.T) {
 	cfg.Update([]config.Option{
 		config.OptExportSourceIDs([]int{firstID}),
 	})
-	e2 := New(cfg, op).(*exporter)
+	e2 := New(cfg, op, nil).(*exporter)
 	err = e2.Init(context.Background())
 
 	require.NoError(t, err)
@@ -153,7 +222,7 @@ func TestInit_Integration_NoMatchingIDs_ReturnsError(t *testing.T) {
 		config.OptExportSourceIDs([]int{999999}),
 	})
 
-	e := New(cfg, op).(*exporter)
+	e := New(cfg, op, nil).(*exporter)
 	err := e.Init(context.Background())
 
 	require.Error(t, err)
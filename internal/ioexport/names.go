@@ -6,10 +6,9 @@ import (
 	"log/slog"
 	"time"
 
-	"github.com/cheggaaa/pb/v3"
 	"github.com/dustin/go-humanize"
-	"github.com/gnames/gn"
-	"github.com/gnames/gnfmt"
+	"github.com/gnames/gndb/internal/ioprogress"
+	"github.com/gnames/gndb/pkg/gndb"
 	"github.com/gnames/gnlib/ent/nomcode"
 	"github.com/gnames/gnparser"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -19,29 +18,33 @@ import (
 
 // exportNames reads name_strings for a data source in batches, re-parses
 // each name with gnparser using the appropriate nomenclatural code, and
-// writes them to the SFGA archive. Returns the count of names written.
+// writes them to the SFGA archive with links to their publication
+// references. Returns the count of names written.
 //
 // Uses keyset pagination (WHERE ns.id > $cursor) instead of OFFSET for
 // stable performance regardless of dataset size.
 func exportNames(
 	ctx context.Context,
 	pool *pgxpool.Pool,
+	progress gndb.ProgressSink,
 	arc sfga.Archive,
 	parsers map[nomcode.Code]gnparser.GNparser,
 	sourceID int,
 	batchSize int,
 ) (int, error) {
 	t := time.Now()
-	gn.Info("(2/5) exporting names...")
+	startStage(progress, sourceID, 2, "names", "exporting names...")
 
 	totalCount, err := countNames(ctx, pool, sourceID)
 	if err != nil {
 		return 0, err
 	}
 
-	bar := pb.Full.Start(totalCount)
-	bar.Set("prefix", "Exporting names: ")
-	bar.Set(pb.CleanOnFinish, true)
+	bar := ioprogress.NewCounter(progress, gndb.Event{
+		SourceID: sourceID,
+		Stage:    "names",
+		Message:  "Exporting names: ",
+	}, totalCount)
 	defer bar.Finish()
 
 	// Use nil UUID as initial cursor — it sorts before all real UUID5 values,
@@ -61,7 +64,7 @@ func exportNames(
 		}
 		total += len(batch)
 		cursor = batch[len(batch)-1].ID
-		bar.SetCurrent(int64(total))
+		bar.SetCurrent(total)
 
 		slog.Debug("names batch written",
 			"source_id", sourceID,
@@ -71,9 +74,8 @@ func exportNames(
 		)
 	}
 
-	gn.Message("<em>Exported %s names</em> %s",
-		humanize.Comma(int64(total)),
-		gnfmt.TimeString(time.Since(t).Seconds()),
+	finishStage(progress, sourceID, 2, "names", t, total,
+		fmt.Sprintf("<em>Exported %s names</em>", humanize.Comma(int64(total))),
 	)
 	return total, nil
 }
@@ -95,10 +97,10 @@ func countNames(ctx context.Context, pool *pgxpool.Pool, sourceID int) (int, err
 // limit early, avoiding a full sort of the remaining rows.
 const namesQuery = `
 SELECT ns.id, ns.name,
-       sub.code_id, sub.classification, sub.outlink_id
+       sub.code_id, sub.classification, sub.outlink_id, sub.reference_id
 FROM (
     SELECT DISTINCT ON (name_string_id)
-        name_string_id, code_id, classification, outlink_id
+        name_string_id, code_id, classification, outlink_id, reference_id
     FROM name_string_indices
     WHERE data_source_id = $1
       AND name_string_id > $2
@@ -131,8 +133,11 @@ func queryNamesBatch(
 			codeID         int
 			classification *string
 			outlinkID      *string
+			referenceID    *string
 		)
-		if err := rows.Scan(&id, &name, &codeID, &classification, &outlinkID); err != nil {
+		if err := rows.Scan(
+			&id, &name, &codeID, &classification, &outlinkID, &referenceID,
+		); err != nil {
 			return nil, err
 		}
 
@@ -157,6 +162,11 @@ func queryNamesBatch(
 			n.AlternativeID = "gnoutlink:" + *outlinkID
 		}
 
+		// Publication of the name, exported by exportReferences.
+		if referenceID != nil {
+			n.ReferenceID = *referenceID
+		}
+
 		batch = append(batch, n)
 	}
 	return batch, rows.Err()
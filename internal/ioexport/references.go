@@ -0,0 +1,137 @@
+package ioexport
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gnames/gndb/internal/ioprogress"
+	"github.com/gnames/gndb/pkg/gndb"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sfborg/sflib/pkg/coldp"
+	"github.com/sfborg/sflib/pkg/sfga"
+)
+
+// exportReferences reads source_references for a data source in batches
+// and writes them to the SFGA archive. Names link to them by their
+// reference ID. Returns the count written.
+func exportReferences(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	progress gndb.ProgressSink,
+	arc sfga.Archive,
+	sourceID int,
+	batchSize int,
+) (int, error) {
+	t := time.Now()
+	startStage(progress, sourceID, 6, "references", "exporting references...")
+
+	totalCount, err := countReferences(ctx, pool, sourceID)
+	if err != nil {
+		return 0, err
+	}
+
+	bar := ioprogress.NewCounter(progress, gndb.Event{
+		SourceID: sourceID,
+		Stage:    "references",
+		Message:  "Exporting references: ",
+	}, totalCount)
+	defer bar.Finish()
+
+	total := 0
+	cursor := ""
+	for {
+		batch, err := queryReferencesBatch(ctx, pool, sourceID, batchSize, cursor)
+		if err != nil {
+			return total, fmt.Errorf("references batch after cursor %q: %w", cursor, err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if err = arc.InsertReferences(batch); err != nil {
+			return total, SFGAWriteError(sourceID, "references", err)
+		}
+		total += len(batch)
+		cursor = batch[len(batch)-1].ID
+		bar.SetCurrent(total)
+
+		slog.Debug("references batch written",
+			"source_id", sourceID,
+			"cursor", cursor,
+			"batch", len(batch),
+			"total", total,
+		)
+	}
+
+	finishStage(progress, sourceID, 6, "references", t, total,
+		fmt.Sprintf("<em>Exported %s references</em>", humanize.Comma(int64(total))),
+	)
+	return total, nil
+}
+
+func countReferences(ctx context.Context, pool *pgxpool.Pool, sourceID int) (int, error) {
+	var count int
+	err := pool.QueryRow(ctx,
+		`SELECT COUNT(*)
+		   FROM source_references
+		  WHERE data_source_id = $1`, sourceID).Scan(&count)
+	return count, err
+}
+
+const referencesQuery = `
+SELECT record_id, citation, doi, year, link
+FROM source_references
+WHERE data_source_id = $1
+  AND record_id > $2
+ORDER BY record_id
+LIMIT $3
+`
+
+func queryReferencesBatch(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	sourceID int,
+	limit int,
+	cursor string,
+) ([]coldp.Reference, error) {
+	rows, err := pool.Query(ctx, referencesQuery, sourceID, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []coldp.Reference
+	for rows.Next() {
+		var (
+			recordID string
+			citation *string
+			doi      *string
+			year     sql.NullInt16
+			link     *string
+		)
+		if err := rows.Scan(&recordID, &citation, &doi, &year, &link); err != nil {
+			return nil, err
+		}
+
+		r := coldp.Reference{ID: recordID}
+		if citation != nil {
+			r.Citation = *citation
+		}
+		if doi != nil {
+			r.DOI = *doi
+		}
+		if year.Valid {
+			r.Issued = strconv.Itoa(int(year.Int16))
+		}
+		if link != nil {
+			r.Link = *link
+		}
+
+		batch = append(batch, r)
+	}
+	return batch, rows.Err()
+}
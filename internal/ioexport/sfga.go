@@ -203,7 +203,7 @@ func writeConsolidatedYAML(
 		return CompanionYAMLError("sources-export.yaml", err)
 	}
 
-	yamlPath := filepath.Join(cfg.Export.OutputDir, "sources-export.yaml")
+	yamlPath := filepath.Join(outputDir(cfg), "sources-export.yaml")
 	content := append([]byte(header), data...)
 
 	if err := os.WriteFile(yamlPath, content, 0644); err != nil {
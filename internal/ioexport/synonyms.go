@@ -6,43 +6,49 @@ import (
 	"log/slog"
 	"time"
 
-	"github.com/cheggaaa/pb/v3"
 	"github.com/dustin/go-humanize"
-	"github.com/gnames/gn"
-	"github.com/gnames/gnfmt"
+	"github.com/gnames/gndb/internal/ioprogress"
+	"github.com/gnames/gndb/pkg/gndb"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sfborg/sflib/pkg/coldp"
 	"github.com/sfborg/sflib/pkg/sfga"
 )
 
 // exportSynonyms reads synonym name_string_indices for a data source in
-// batches and writes them to the SFGA archive. Returns the count written.
+// batches and writes them to the SFGA archive. A pro parte synonym is
+// written once for every accepted taxon with the same ID. Returns the
+// count written.
 func exportSynonyms(
 	ctx context.Context,
 	pool *pgxpool.Pool,
+	progress gndb.ProgressSink,
 	arc sfga.Archive,
 	sourceID int,
 	batchSize int,
 ) (int, error) {
 	t := time.Now()
-	gn.Info("(4/5) exporting synonyms...")
+	startStage(progress, sourceID, 4, "synonyms", "exporting synonyms...")
 
 	totalCount, err := countSynonyms(ctx, pool, sourceID)
 	if err != nil {
 		return 0, err
 	}
 
-	bar := pb.Full.Start(totalCount)
-	bar.Set("prefix", "Exporting synonyms: ")
-	bar.Set(pb.CleanOnFinish, true)
+	bar := ioprogress.NewCounter(progress, gndb.Event{
+		SourceID: sourceID,
+		Stage:    "synonyms",
+		Message:  "Exporting synonyms: ",
+	}, totalCount)
 	defer bar.Finish()
 
 	total := 0
-	cursor := ""
+	var cursor synonymCursor
 	for {
 		batch, err := querySynonymsBatch(ctx, pool, sourceID, batchSize, cursor)
 		if err != nil {
-			return total, fmt.Errorf("synonyms batch after cursor %q: %w", cursor, err)
+			return total, fmt.Errorf(
+				"synonyms batch after cursor %q: %w", cursor.recordID, err,
+			)
 		}
 		if len(batch) == 0 {
 			break
@@ -51,20 +57,20 @@ func exportSynonyms(
 			return total, SFGAWriteError(sourceID, "synonyms", err)
 		}
 		total += len(batch)
-		cursor = batch[len(batch)-1].ID
-		bar.SetCurrent(int64(total))
+		last := batch[len(batch)-1]
+		cursor = synonymCursor{recordID: last.ID, acceptedRecordID: last.TaxonID}
+		bar.SetCurrent(total)
 
 		slog.Debug("synonyms batch written",
 			"source_id", sourceID,
-			"cursor", cursor,
+			"cursor", cursor.recordID,
 			"batch", len(batch),
 			"total", total,
 		)
 	}
 
-	gn.Message("<em>Exported %s synonyms</em> %s",
-		humanize.Comma(int64(total)),
-		gnfmt.TimeString(time.Since(t).Seconds()),
+	finishStage(progress, sourceID, 4, "synonyms", t, total,
+		fmt.Sprintf("<em>Exported %s synonyms</em>", humanize.Comma(int64(total))),
 	)
 	return total, nil
 }
@@ -80,15 +86,23 @@ func countSynonyms(ctx context.Context, pool *pgxpool.Pool, sourceID int) (int,
 	return count, err
 }
 
+// synonymCursor is the last synonym of a batch. Rows of a pro parte
+// synonym share the record ID, so the accepted record ID is needed to
+// continue after them.
+type synonymCursor struct {
+	recordID         string
+	acceptedRecordID string
+}
+
 const synonymsQuery = `
 SELECT
     record_id, accepted_record_id, name_string_id, taxonomic_status
 FROM name_string_indices
 WHERE data_source_id = $1
-  AND record_id > $2
+  AND (record_id, accepted_record_id) > ($2, $3)
   AND taxonomic_status IN ('synonym', 'ambiguous synonym', 'misapplied')
-ORDER BY record_id
-LIMIT $3
+ORDER BY record_id, accepted_record_id
+LIMIT $4
 `
 
 func querySynonymsBatch(
@@ -96,9 +110,10 @@ func querySynonymsBatch(
 	pool *pgxpool.Pool,
 	sourceID int,
 	limit int,
-	cursor string,
+	cursor synonymCursor,
 ) ([]coldp.Synonym, error) {
-	rows, err := pool.Query(ctx, synonymsQuery, sourceID, cursor, limit)
+	rows, err := pool.Query(ctx, synonymsQuery,
+		sourceID, cursor.recordID, cursor.acceptedRecordID, limit)
 	if err != nil {
 		return nil, err
 	}
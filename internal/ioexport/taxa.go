@@ -7,10 +7,9 @@ import (
 	"strings"
 	"time"
 
-	"github.com/cheggaaa/pb/v3"
 	"github.com/dustin/go-humanize"
-	"github.com/gnames/gn"
-	"github.com/gnames/gnfmt"
+	"github.com/gnames/gndb/internal/ioprogress"
+	"github.com/gnames/gndb/pkg/gndb"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sfborg/sflib/pkg/coldp"
 	"github.com/sfborg/sflib/pkg/sfga"
@@ -21,21 +20,24 @@ import (
 func exportTaxa(
 	ctx context.Context,
 	pool *pgxpool.Pool,
+	progress gndb.ProgressSink,
 	arc sfga.Archive,
 	sourceID int,
 	batchSize int,
 ) (int, error) {
 	t := time.Now()
-	gn.Info("(3/5) exporting taxa...")
+	startStage(progress, sourceID, 3, "taxa", "exporting taxa...")
 
 	totalCount, err := countTaxa(ctx, pool, sourceID)
 	if err != nil {
 		return 0, err
 	}
 
-	bar := pb.Full.Start(totalCount)
-	bar.Set("prefix", "Exporting taxa: ")
-	bar.Set(pb.CleanOnFinish, true)
+	bar := ioprogress.NewCounter(progress, gndb.Event{
+		SourceID: sourceID,
+		Stage:    "taxa",
+		Message:  "Exporting taxa: ",
+	}, totalCount)
 	defer bar.Finish()
 
 	total := 0
@@ -53,7 +55,7 @@ func exportTaxa(
 		}
 		total += len(batch)
 		cursor = batch[len(batch)-1].ID
-		bar.SetCurrent(int64(total))
+		bar.SetCurrent(total)
 
 		slog.Debug("taxa batch written",
 			"source_id", sourceID,
@@ -63,9 +65,8 @@ func exportTaxa(
 		)
 	}
 
-	gn.Message("<em>Exported %s taxa</em> %s",
-		humanize.Comma(int64(total)),
-		gnfmt.TimeString(time.Since(t).Seconds()),
+	finishStage(progress, sourceID, 3, "taxa", t, total,
+		fmt.Sprintf("<em>Exported %s taxa</em>", humanize.Comma(int64(total))),
 	)
 	return total, nil
 }
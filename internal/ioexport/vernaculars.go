@@ -6,10 +6,9 @@ import (
 	"log/slog"
 	"time"
 
-	"github.com/cheggaaa/pb/v3"
 	"github.com/dustin/go-humanize"
-	"github.com/gnames/gn"
-	"github.com/gnames/gnfmt"
+	"github.com/gnames/gndb/internal/ioprogress"
+	"github.com/gnames/gndb/pkg/gndb"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sfborg/sflib/pkg/coldp"
 	"github.com/sfborg/sflib/pkg/sfga"
@@ -20,21 +19,24 @@ import (
 func exportVernaculars(
 	ctx context.Context,
 	pool *pgxpool.Pool,
+	progress gndb.ProgressSink,
 	arc sfga.Archive,
 	sourceID int,
 	batchSize int,
 ) (int, error) {
 	t := time.Now()
-	gn.Info("(5/5) exporting vernaculars...")
+	startStage(progress, sourceID, 5, "vernaculars", "exporting vernaculars...")
 
 	totalCount, err := countVernaculars(ctx, pool, sourceID)
 	if err != nil {
 		return 0, err
 	}
 
-	bar := pb.Full.Start(totalCount)
-	bar.Set("prefix", "Exporting vernaculars: ")
-	bar.Set(pb.CleanOnFinish, true)
+	bar := ioprogress.NewCounter(progress, gndb.Event{
+		SourceID: sourceID,
+		Stage:    "vernaculars",
+		Message:  "Exporting vernaculars: ",
+	}, totalCount)
 	defer bar.Finish()
 
 	total := 0
@@ -55,7 +57,7 @@ func exportVernaculars(
 		}
 		total += len(batch)
 		cursor = lastCursor
-		bar.SetCurrent(int64(total))
+		bar.SetCurrent(total)
 
 		slog.Debug("vernaculars batch written",
 			"source_id", sourceID,
@@ -65,9 +67,8 @@ func exportVernaculars(
 		)
 	}
 
-	gn.Message("<em>Exported %s vernaculars</em> %s",
-		humanize.Comma(int64(total)),
-		gnfmt.TimeString(time.Since(t).Seconds()),
+	finishStage(progress, sourceID, 5, "vernaculars", t, total,
+		fmt.Sprintf("<em>Exported %s vernaculars</em>", humanize.Comma(int64(total))),
 	)
 	return total, nil
 }
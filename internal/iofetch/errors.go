@@ -0,0 +1,43 @@
+package iofetch
+
+import (
+	"fmt"
+
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/errcode"
+)
+
+// FetchError creates an error for when a file cannot be downloaded
+// after all retries.
+func FetchError(url string, err error) error {
+	msg := `Cannot download <em>%s</em>
+
+<em>How to fix:</em>
+  Check the network connection and run the command again,
+  the download continues where it stopped`
+	vars := []any{url}
+	return &gn.Error{
+		Code: errcode.FetchError,
+		Msg:  msg,
+		Vars: vars,
+		Err:  fmt.Errorf("cannot download %s: %w", url, err),
+	}
+}
+
+// ChecksumError creates an error for when a downloaded file does not
+// match the checksum of its .sha256 sidecar.
+func ChecksumError(url, want, got string) error {
+	msg := `Downloaded file <em>%s</em> is corrupted
+
+<em>Expected SHA-256:</em> %s
+<em>Actual SHA-256:</em>   %s
+
+The file is removed, run the command again to download it anew`
+	vars := []any{url, want, got}
+	return &gn.Error{
+		Code: errcode.FetchChecksumError,
+		Msg:  msg,
+		Vars: vars,
+		Err:  fmt.Errorf("checksum mismatch for %s", url),
+	}
+}
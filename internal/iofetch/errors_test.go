@@ -0,0 +1,45 @@
+package iofetch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFetchError verifies error structure.
+func TestFetchError(t *testing.T) {
+	url := "http://example.org/1.sqlite.zip"
+	originalErr := errors.New("connection reset")
+
+	err := FetchError(url, originalErr)
+
+	require.NotNil(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok, "Error should be of type *gn.Error")
+
+	assert.Equal(t, errcode.FetchError, gnErr.Code)
+	assert.NotEmpty(t, gnErr.Msg)
+	require.Len(t, gnErr.Vars, 1)
+	assert.Equal(t, url, gnErr.Vars[0])
+	assert.ErrorIs(t, gnErr.Err, originalErr)
+}
+
+// TestChecksumError verifies error structure.
+func TestChecksumError(t *testing.T) {
+	err := ChecksumError("http://example.org/1.sqlite.zip", "aa", "bb")
+
+	require.NotNil(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok, "Error should be of type *gn.Error")
+
+	assert.Equal(t, errcode.FetchChecksumError, gnErr.Code)
+	require.Len(t, gnErr.Vars, 3)
+	assert.Equal(t, "aa", gnErr.Vars[1])
+	assert.Equal(t, "bb", gnErr.Vars[2])
+}
@@ -0,0 +1,330 @@
+// Package iofetch downloads remote files for gndb. Interrupted downloads
+// continue from the partial file with HTTP Range requests, failed
+// attempts are retried with exponential backoff, and a downloaded file
+// is checked against a known checksum, or an optional .sha256 sidecar
+// published next to it.
+// This is an impure I/O package.
+package iofetch
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultRetries is the number of attempts after the first one.
+	defaultRetries = 5
+
+	// defaultBackoff is the wait before the first retry, it doubles
+	// with every next retry.
+	defaultBackoff = 2 * time.Second
+
+	// maxBackoff limits the wait between retries.
+	maxBackoff = time.Minute
+)
+
+// Fetcher downloads files over HTTP.
+type Fetcher struct {
+	client  *http.Client
+	retries int
+	backoff time.Duration
+}
+
+// Progress receives the number of downloaded bytes of a file and its
+// size, the size is 0 if the server does not report it.
+type Progress func(done, total int64)
+
+// Result describes a downloaded file.
+type Result struct {
+	// Size is the size of the file in bytes.
+	Size int64
+
+	// SHA256 is the checksum of the file.
+	SHA256 string
+
+	// Verified is true if the file matched the expected checksum.
+	Verified bool
+}
+
+// permanentError is a download error that does not go away on retry,
+// for example a missing file.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// New creates a Fetcher that sends requests with client.
+func New(client *http.Client) *Fetcher {
+	return &Fetcher{
+		client:  client,
+		retries: defaultRetries,
+		backoff: defaultBackoff,
+	}
+}
+
+// Download saves the file at url to dst. The file is written to
+// dst.part first, which is kept on failure, so the next download of the
+// same url continues where the previous one stopped. The checksum of the
+// file must match want, if it is empty, the url.sha256 sidecar is used
+// when the server has it. If progress is not nil, it is called as the
+// file is downloaded. The download and waits between retries stop when
+// ctx is cancelled.
+func (f *Fetcher) Download(
+	ctx context.Context,
+	url, dst, want string,
+	progress Progress,
+) (Result, error) {
+	var res Result
+
+	var err error
+	if want == "" {
+		err = f.retry(ctx, url+".sha256", func() error {
+			want, err = f.sidecar(ctx, url+".sha256")
+			return err
+		})
+		if err != nil {
+			return res, FetchError(url+".sha256", err)
+		}
+	}
+
+	part := dst + ".part"
+	err = f.retry(ctx, url, func() error {
+		return f.attempt(ctx, url, part, progress)
+	})
+	if err != nil {
+		return res, FetchError(url, err)
+	}
+
+	res.SHA256, res.Size, err = hashFile(part)
+	if err != nil {
+		return res, FetchError(url, err)
+	}
+	if want != "" {
+		if res.SHA256 != strings.ToLower(want) {
+			_ = os.Remove(part)
+			return res, ChecksumError(url, want, res.SHA256)
+		}
+		res.Verified = true
+	}
+
+	if err = os.Rename(part, dst); err != nil {
+		return res, FetchError(url, err)
+	}
+	slog.Info("Downloaded file",
+		"url", url,
+		"size", res.Size,
+		"sha256", res.SHA256,
+		"verified", res.Verified,
+	)
+	return res, nil
+}
+
+// retry runs fn until it succeeds, returns a permanent error, or the
+// retries are used up. The wait between attempts grows exponentially.
+// Cancelling ctx stops waiting for the next attempt.
+func (f *Fetcher) retry(
+	ctx context.Context,
+	url string,
+	fn func() error,
+) error {
+	wait := f.backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) || attempt > f.retries {
+			return err
+		}
+
+		slog.Warn("Download failed, retrying",
+			"url", url,
+			"attempt", attempt,
+			"wait", wait,
+			"error", err,
+		)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(2*wait, maxBackoff)
+	}
+}
+
+// attempt downloads url to the part file. If the part file exists, only
+// the rest of the file is requested. Servers that ignore the Range
+// header send the whole file, and the part file is rewritten.
+func (f *Fetcher) attempt(
+	ctx context.Context,
+	url, part string,
+	progress Progress,
+) error {
+	var offset int64
+	if fi, err := os.Stat(part); err == nil {
+		offset = fi.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return &permanentError{err}
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flag := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+		flag |= os.O_TRUNC
+	case resp.StatusCode == http.StatusPartialContent &&
+		strings.HasPrefix(
+			resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset),
+		):
+		flag |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable &&
+		resp.Header.Get("Content-Range") == fmt.Sprintf("bytes */%d", offset):
+		// The part file is already complete.
+		return nil
+	case resp.StatusCode == http.StatusPartialContent,
+		resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The part file does not fit the remote file, start again.
+		if err = os.Remove(part); err != nil {
+			return &permanentError{err}
+		}
+		return fmt.Errorf("partial download does not match %s", url)
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("status %d", resp.StatusCode)
+	default:
+		return &permanentError{fmt.Errorf("status %d", resp.StatusCode)}
+	}
+
+	var total int64 = -1
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	file, err := os.OpenFile(part, flag, 0644)
+	if err != nil {
+		return &permanentError{err}
+	}
+
+	var body io.Reader = resp.Body
+	if progress != nil {
+		body = &progressReader{
+			r: body, done: offset, total: max(total, 0), fn: progress,
+		}
+	}
+
+	n, err := io.Copy(file, body)
+	if cerr := file.Close(); err == nil && cerr != nil {
+		return &permanentError{cerr}
+	}
+	if err != nil {
+		return err
+	}
+	if total >= 0 && offset+n != total {
+		return fmt.Errorf(
+			"incomplete download: %d of %d bytes", offset+n, total,
+		)
+	}
+	return nil
+}
+
+// sidecar returns the checksum from a .sha256 file, or an empty string
+// if the server does not have one. The file has the format of sha256sum:
+// a hex checksum, optionally followed by the file name.
+func (f *Fetcher) sidecar(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", &permanentError{err}
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode >= http.StatusInternalServerError:
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	default:
+		// There is no sidecar, the file is not verified.
+		return "", nil
+	}
+
+	line, err := bufio.NewReader(io.LimitReader(resp.Body, 1024)).
+		ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", &permanentError{errors.New("empty .sha256 file")}
+	}
+	sum := strings.ToLower(fields[0])
+	if _, err = hex.DecodeString(sum); err != nil || len(sum) != 64 {
+		return "", &permanentError{
+			fmt.Errorf("invalid checksum in .sha256 file: %q", fields[0]),
+		}
+	}
+	return sum, nil
+}
+
+// progressReader reports bytes read from r to fn.
+type progressReader struct {
+	r     io.Reader
+	done  int64
+	total int64
+	fn    Progress
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	p.fn(p.done, p.total)
+	return n, err
+}
+
+// hashFile returns the SHA-256 checksum and the size of a file.
+func hashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(hash, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), n, nil
+}
@@ -0,0 +1,289 @@
+package iofetch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var content = bytes.Repeat([]byte("0123456789"), 1000)
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func newTestFetcher() *Fetcher {
+	f := New(http.DefaultClient)
+	f.retries = 3
+	f.backoff = time.Millisecond
+	return f
+}
+
+// serveFile serves content with Range support at /file.zip.
+func serveFile(w http.ResponseWriter, r *http.Request) {
+	http.ServeContent(w, r, "file.zip", time.Time{}, bytes.NewReader(content))
+}
+
+func TestDownload(t *testing.T) {
+	tests := []struct {
+		name         string
+		sidecar      string
+		wantVerified bool
+	}{
+		{
+			name:         "with sidecar",
+			sidecar:      checksum(content) + "  file.zip\n",
+			wantVerified: true,
+		},
+		{
+			name:         "upper case sidecar without file name",
+			sidecar:      string(bytes.ToUpper([]byte(checksum(content)))),
+			wantVerified: true,
+		},
+		{
+			name: "without sidecar",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path == "/file.zip.sha256" && tt.sidecar != "" {
+						_, _ = w.Write([]byte(tt.sidecar))
+						return
+					}
+					if r.URL.Path == "/file.zip" {
+						serveFile(w, r)
+						return
+					}
+					http.NotFound(w, r)
+				}))
+			defer srv.Close()
+
+			dst := filepath.Join(t.TempDir(), "file.zip")
+			res, err := newTestFetcher().Download(context.Background(), srv.URL+"/file.zip", dst, "", nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, int64(len(content)), res.Size)
+			assert.Equal(t, checksum(content), res.SHA256)
+			assert.Equal(t, tt.wantVerified, res.Verified)
+
+			data, err := os.ReadFile(dst)
+			require.NoError(t, err)
+			assert.Equal(t, content, data)
+			_, err = os.Stat(dst + ".part")
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+func TestDownloadChecksumMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/file.zip.sha256" {
+				_, _ = w.Write([]byte(checksum([]byte("other"))))
+				return
+			}
+			serveFile(w, r)
+		}))
+	defer srv.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.zip")
+	_, err := newTestFetcher().Download(context.Background(), srv.URL+"/file.zip", dst, "", nil)
+	require.Error(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok)
+	assert.Equal(t, errcode.FetchChecksumError, gnErr.Code)
+
+	// Corrupted files are not kept.
+	_, err = os.Stat(dst)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(dst + ".part")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDownloadResume(t *testing.T) {
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/file.zip" {
+				http.NotFound(w, r)
+				return
+			}
+			ranges = append(ranges, r.Header.Get("Range"))
+			serveFile(w, r)
+		}))
+	defer srv.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.zip")
+	err := os.WriteFile(dst+".part", content[:4000], 0644)
+	require.NoError(t, err)
+
+	res, err := newTestFetcher().Download(context.Background(), srv.URL+"/file.zip", dst, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, checksum(content), res.SHA256)
+	assert.Equal(t, []string{"bytes=4000-"}, ranges)
+}
+
+func TestDownloadProgress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/file.zip" {
+				http.NotFound(w, r)
+				return
+			}
+			serveFile(w, r)
+		}))
+	defer srv.Close()
+
+	// The resumed download reports bytes of the part file too.
+	dst := filepath.Join(t.TempDir(), "file.zip")
+	err := os.WriteFile(dst+".part", content[:4000], 0644)
+	require.NoError(t, err)
+
+	var done, total int64
+	progress := func(d, t int64) {
+		done, total = d, t
+	}
+	_, err = newTestFetcher().Download(context.Background(), srv.URL+"/file.zip", dst, "", progress)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), done)
+	assert.Equal(t, int64(len(content)), total)
+}
+
+func TestDownloadRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/file.zip" {
+				http.NotFound(w, r)
+				return
+			}
+			switch calls.Add(1) {
+			case 1:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			case 2:
+				// Connection breaks in the middle of the file.
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				_, _ = w.Write(content[:3000])
+			default:
+				serveFile(w, r)
+			}
+		}))
+	defer srv.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.zip")
+	res, err := newTestFetcher().Download(context.Background(), srv.URL+"/file.zip", dst, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, checksum(content), res.SHA256)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestDownloadPermanentError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/file.zip" {
+				calls.Add(1)
+			}
+			http.NotFound(w, r)
+		}))
+	defer srv.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.zip")
+	_, err := newTestFetcher().Download(context.Background(), srv.URL+"/file.zip", dst, "", nil)
+	require.Error(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok)
+	assert.Equal(t, errcode.FetchError, gnErr.Code)
+	assert.Equal(t, int32(1), calls.Load(), "404 should not be retried")
+}
+
+func TestDownloadRetriesUsedUp(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/file.zip" {
+				calls.Add(1)
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			http.NotFound(w, r)
+		}))
+	defer srv.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.zip")
+	_, err := newTestFetcher().Download(context.Background(), srv.URL+"/file.zip", dst, "", nil)
+	require.Error(t, err)
+	assert.Equal(t, int32(4), calls.Load(), "first attempt and 3 retries")
+}
+
+func TestDownloadCancel(t *testing.T) {
+	// The server sends a part of the file and hangs.
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/file.zip" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			_, _ = w.Write(content[:3000])
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	dst := filepath.Join(t.TempDir(), "file.zip")
+	start := time.Now()
+	_, err := newTestFetcher().Download(ctx, srv.URL+"/file.zip", dst, "", nil)
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok)
+	assert.ErrorIs(t, gnErr.Err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestDownloadCancelRetryWait(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/file.zip" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			http.NotFound(w, r)
+		}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	f := newTestFetcher()
+	f.backoff = time.Minute
+	dst := filepath.Join(t.TempDir(), "file.zip")
+	start := time.Now()
+	_, err := f.Download(ctx, srv.URL+"/file.zip", dst, "", nil)
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok)
+	assert.ErrorIs(t, gnErr.Err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -5,26 +5,38 @@ package iooptimize
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/gnames/gn"
+	"github.com/gnames/gndb/internal/ioprogress"
+	"github.com/gnames/gndb/internal/ioreport"
 	"github.com/gnames/gndb/pkg/config"
 	"github.com/gnames/gndb/pkg/db"
 	"github.com/gnames/gndb/pkg/errcode"
 	"github.com/gnames/gndb/pkg/gndb"
+	"github.com/gnames/gndb/pkg/report"
 	"github.com/gnames/gnfmt"
 )
 
 // optimizer implements the Optimizer interface.
 type optimizer struct {
 	operator db.Operator
+
+	// progress receives progress events of the optimization.
+	progress gndb.ProgressSink
 }
 
-// NewOptimizer creates a new Optimizer.
-func NewOptimizer(op db.Operator) gndb.Optimizer {
+// NewOptimizer creates a new Optimizer. Progress of the optimization is
+// sent to progress, or to the terminal if it is nil.
+func NewOptimizer(op db.Operator, progress gndb.ProgressSink) gndb.Optimizer {
+	if progress == nil {
+		progress = ioprogress.Default()
+	}
 	return &optimizer{
 		operator: op,
+		progress: progress,
 	}
 }
 
@@ -37,17 +49,23 @@ func NewOptimizer(op db.Operator) gndb.Optimizer {
 //  5. Create verification materialized view with indexes
 //  6. Run VACUUM ANALYZE to update statistics
 //
+// Every step is added to the run report, which is saved when
+// optimization finishes or fails.
+//
 // Errors are returned to the CLI layer for user-friendly display
-// via gn.PrintErrorMessage(). Progress messages are logged via
-// slog.Info() for developer visibility.
+// via gn.PrintErrorMessage(). Progress is sent to the progress sink
+// and logged via slog.Info() for developer visibility.
 //
 // Reference: gnidump Build() workflow in buildio.go
 func (o *optimizer) Optimize(
 	ctx context.Context,
 	cfg *config.Config,
-) error {
+) (err error) {
 	var msg string
-	var err error
+
+	rep := report.New("optimize", cfg)
+	defer func() { ioreport.Save(rep, cfg, err) }()
+
 	pool := o.operator.Pool()
 	if pool == nil {
 		return &gn.Error{
@@ -58,10 +76,11 @@ func (o *optimizer) Optimize(
 	}
 
 	slog.Info("Starting database optimization")
-	gn.Info(
-		"Optimization in progress, " +
+	o.progress.Emit(gndb.Event{
+		Type: gndb.EventInfo,
+		Message: "Optimization in progress, " +
 			"<em>it might take a while</em>...",
-	)
+	})
 
 	startTime := time.Now()
 	var stepStart time.Time
@@ -69,82 +88,140 @@ func (o *optimizer) Optimize(
 	// Step 1: Reparse all name_strings with latest gnparser
 	// algorithms
 	msg = "Step 1/6: Reparsing name strings"
-	gn.Info(msg)
+	o.startStep(1, "reparse", msg)
 	slog.Info(msg)
 	stepStart = time.Now()
 	if msg, err = reparseNames(ctx, o); err != nil {
 		return err
 	}
-	gn.Message("%s %s", msg, gnfmt.TimeString(time.Since(stepStart).Seconds()))
+	o.finishStep(1, "reparse", stepStart, msg)
 	slog.Info("Step 1/6: Complete - Name strings reparsed")
+	rep.AddStep(report.Stage{
+		Name:        "reparse",
+		DurationSec: time.Since(stepStart).Seconds(),
+	})
 
 	// Step 2: Normalize vernacular language codes
 	msg = "Step 2/6: Normalizing vernacular languages"
-	gn.Info(msg)
+	o.startStep(2, "vernaculars", msg)
 	slog.Info(msg)
 	stepStart = time.Now()
 	if msg, err = normalizeVernaculars(ctx, o, cfg); err != nil {
 		return err
 	}
-	gn.Message(
-		"%s %s", msg, gnfmt.TimeString(time.Since(stepStart).Seconds()),
-	)
+	o.finishStep(2, "vernaculars", stepStart, msg)
 	slog.Info(
 		"Step 2/6: Complete - " +
 			"Vernacular languages normalized",
 	)
+	rep.AddStep(report.Stage{
+		Name:        "vernaculars",
+		DurationSec: time.Since(stepStart).Seconds(),
+	})
 
 	// Step 3: Remove orphaned records
 	msg = "Step 3/6: Removing orphaned records"
-	gn.Info(msg)
+	o.startStep(3, "orphans", msg)
 	slog.Info(msg)
 	stepStart = time.Now()
 	if msg, err = removeOrphans(ctx, o, cfg); err != nil {
 		return err
 	}
-	gn.Message("%s %s", msg, gnfmt.TimeString(time.Since(stepStart).Seconds()))
+	o.finishStep(3, "orphans", stepStart, msg)
 	slog.Info("Step 3/6: Complete - Orphaned records removed")
+	rep.AddStep(report.Stage{
+		Name:        "orphans",
+		DurationSec: time.Since(stepStart).Seconds(),
+	})
 
 	// Step 4: Extract and link words for advanced matching
 	msg = "Step 4/6: Extracting words for advanced matching"
-	gn.Info(msg)
+	o.startStep(4, "words", msg)
 	slog.Info(msg)
 	stepStart = time.Now()
 	if msg, err = extractWords(ctx, o, cfg); err != nil {
 		return err
 	}
-	gn.Message("%s %s", msg, gnfmt.TimeString(time.Since(stepStart).Seconds()))
+	o.finishStep(4, "words", stepStart, msg)
 	slog.Info("Step 4/6: Complete - Words extracted and linked")
+	rep.AddStep(report.Stage{
+		Name:        "words",
+		DurationSec: time.Since(stepStart).Seconds(),
+	})
 
 	// Step 5: Create verification materialized view
 	msg = "Step 5/6: Creating verification view"
-	gn.Info(msg)
+	o.startStep(5, "views", msg)
 	slog.Info(msg)
 	stepStart = time.Now()
 	if msg, err = createVerificationView(ctx, o, cfg); err != nil {
 		return err
 	}
-	gn.Message("%s %s", msg, gnfmt.TimeString(time.Since(stepStart).Seconds()))
+	o.finishStep(5, "views", stepStart, msg)
 	slog.Info("Step 5/6: Complete - Verification view created")
+	rep.AddStep(report.Stage{
+		Name:        "views",
+		DurationSec: time.Since(stepStart).Seconds(),
+	})
 
 	// Step 6: Run VACUUM ANALYZE
 	msg = "Step 6/6: Running VACUUM ANALYZE"
-	gn.Info(msg)
+	o.startStep(6, "vacuum", msg)
 	slog.Info(msg)
 	stepStart = time.Now()
 	if err := vacuumAnalyze(ctx, o, cfg); err != nil {
 		return err
 	}
-	gn.Message(
-		"<em>VACUUM ANALYZE completed</em> %s",
-		gnfmt.TimeString(time.Since(stepStart).Seconds()),
-	)
+	o.finishStep(6, "vacuum", stepStart, "<em>VACUUM ANALYZE completed</em>")
 	slog.Info("Step 6/6: Complete - VACUUM ANALYZE finished")
+	rep.AddStep(report.Stage{
+		Name:        "vacuum",
+		DurationSec: time.Since(stepStart).Seconds(),
+	})
 
 	totalDuration := time.Since(startTime)
 	slog.Info("Optimization complete",
 		"duration", gnfmt.TimeString(totalDuration.Seconds()))
-	gn.Info("Optimization complete. Elapsed time: <em>%s</em>",
-		gnfmt.TimeString(totalDuration.Seconds()))
+	o.progress.Emit(gndb.Event{
+		Type:        gndb.EventSummary,
+		DurationSec: totalDuration.Seconds(),
+		Message: fmt.Sprintf(
+			"Optimization complete. Elapsed time: <em>%s</em>",
+			gnfmt.TimeString(totalDuration.Seconds()),
+		),
+	})
 	return nil
 }
+
+// optimizeSteps is the number of optimization steps.
+const optimizeSteps = 6
+
+// startStep sends the start of an optimization step.
+func (o *optimizer) startStep(step int, name, msg string) {
+	o.progress.Emit(gndb.Event{
+		Type:    gndb.EventStageStarted,
+		Stage:   name,
+		Step:    step,
+		Steps:   optimizeSteps,
+		Message: msg,
+	})
+}
+
+// finishStep sends the result of an optimization step that started at
+// start.
+func (o *optimizer) finishStep(
+	step int,
+	name string,
+	start time.Time,
+	msg string,
+) {
+	o.progress.Emit(gndb.Event{
+		Type:        gndb.EventStageFinished,
+		Stage:       name,
+		Step:        step,
+		Steps:       optimizeSteps,
+		Status:      gndb.StatusSucceeded,
+		DurationSec: time.Since(start).Seconds(),
+		Message:     msg,
+	})
+}
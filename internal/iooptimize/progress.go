@@ -1,17 +1,16 @@
 package iooptimize
 
 import (
-	"github.com/cheggaaa/pb/v3"
+	"github.com/gnames/gndb/internal/ioprogress"
+	"github.com/gnames/gndb/pkg/gndb"
 )
 
 // newProgressBar creates a new progress bar with consistent
-// settings.
+// settings. Its progress is sent to the progress sink.
 func newProgressBar(
+	progress gndb.ProgressSink,
 	total int,
 	prefix string,
-) *pb.ProgressBar {
-	bar := pb.Full.Start(total)
-	bar.Set("prefix", prefix)
-	bar.Set(pb.CleanOnFinish, true)
-	return bar
+) *ioprogress.Counter {
+	return ioprogress.NewCounter(progress, gndb.Event{Message: prefix}, total)
 }
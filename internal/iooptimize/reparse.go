@@ -80,7 +80,7 @@ FROM name_strings`
 	}
 	defer rows.Close()
 
-	bar := newProgressBar(totalCount, "Loading names: ")
+	bar := newProgressBar(opt.progress, totalCount, "Loading names: ")
 	defer bar.Finish()
 
 	count := 0
@@ -11,6 +11,7 @@ import (
 	"github.com/gnames/gn"
 	"github.com/gnames/gndb/pkg/config"
 	"github.com/gnames/gndb/pkg/errcode"
+	"github.com/gnames/gndb/pkg/gndb"
 	"github.com/gnames/gnfmt/gnlang"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -77,7 +78,7 @@ func normalizeVernaculars(
 	}()
 
 	// Load and normalize all records
-	records, err := loadAndNormalizeVernaculars(ctx, pool)
+	records, err := loadAndNormalizeVernaculars(ctx, pool, opt.progress)
 	if err != nil {
 		return "", err
 	}
@@ -86,6 +87,7 @@ func normalizeVernaculars(
 	err = batchInsertVernacularUpdates(
 		ctx,
 		pool,
+		opt.progress,
 		records,
 		cfg,
 	)
@@ -170,6 +172,7 @@ CREATE UNLOGGED TABLE temp_vernacular_updates (
 func loadAndNormalizeVernaculars(
 	ctx context.Context,
 	pool *pgxpool.Pool,
+	progress gndb.ProgressSink,
 ) ([]vernacular, error) {
 	// Count total vernacular records for progress
 	var totalCount int
@@ -199,6 +202,7 @@ FROM vernacular_string_indices`
 
 	// Create progress bar with known total
 	bar := newProgressBar(
+		progress,
 		totalCount,
 		"Loading and normalizing: ",
 	)
@@ -340,6 +344,7 @@ func normalizeVernacularRecord(v *vernacular) {
 func batchInsertVernacularUpdates(
 	ctx context.Context,
 	pool *pgxpool.Pool,
+	progress gndb.ProgressSink,
 	records []vernacular,
 	cfg *config.Config,
 ) error {
@@ -358,7 +363,7 @@ func batchInsertVernacularUpdates(
 		batchSize = maxBatchSize
 	}
 
-	bar := newProgressBar(len(records), "Saving updates: ")
+	bar := newProgressBar(progress, len(records), "Saving updates: ")
 	defer bar.Finish()
 
 	for i := 0; i < len(records); i += batchSize {
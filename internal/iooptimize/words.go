@@ -11,6 +11,7 @@ import (
 	"github.com/gnames/gn"
 	"github.com/gnames/gndb/pkg/config"
 	"github.com/gnames/gndb/pkg/errcode"
+	"github.com/gnames/gndb/pkg/gndb"
 	"github.com/gnames/gndb/pkg/schema"
 	"github.com/gnames/gnparser"
 	"github.com/gnames/gnparser/ent/parsed"
@@ -73,7 +74,7 @@ func extractWords(
 	// word-name linkages in batches during streaming.
 	slog.Info("Streaming names and extracting words")
 	wordsMap, totalLinks, err := parseNamesForWords(
-		ctx, pool, cfg,
+		ctx, pool, opt.progress, cfg,
 	)
 	if err != nil {
 		return "", err
@@ -96,7 +97,7 @@ func extractWords(
 	// Step 5: Bulk insert words.
 	slog.Info("Saving words to database")
 	if err := saveWords(
-		ctx, pool, uniqueWords, cfg,
+		ctx, pool, opt.progress, uniqueWords, cfg,
 	); err != nil {
 		return "", err
 	}
@@ -151,6 +152,7 @@ func truncateWordsTables(
 func loadNamesForWords(
 	ctx context.Context,
 	pool *pgxpool.Pool,
+	progress gndb.ProgressSink,
 	chIn chan<- nameForWords,
 ) error {
 	// Count total names for progress bar.
@@ -184,7 +186,7 @@ WHERE canonical_id IS NOT NULL`
 	}
 	defer rows.Close()
 
-	bar := newProgressBar(totalCount, "Extracting names' words: ")
+	bar := newProgressBar(progress, totalCount, "Extracting names' words: ")
 	defer bar.Finish()
 
 	count := 0
@@ -244,6 +246,7 @@ WHERE canonical_id IS NOT NULL`
 func parseNamesForWords(
 	ctx context.Context,
 	pool *pgxpool.Pool,
+	progress gndb.ProgressSink,
 	cfg *config.Config,
 ) (map[string]schema.Word, int, error) {
 	jobsNum := cfg.JobsNumber
@@ -266,7 +269,7 @@ func parseNamesForWords(
 	// Stage 1: Stream names from database.
 	g.Go(func() error {
 		defer close(chIn)
-		return loadNamesForWords(gCtx, pool, chIn)
+		return loadNamesForWords(gCtx, pool, progress, chIn)
 	})
 
 	// Stage 2: Parse with workers.
@@ -448,6 +451,7 @@ func workerExtractWords(
 func saveWords(
 	ctx context.Context,
 	pool *pgxpool.Pool,
+	progress gndb.ProgressSink,
 	words []schema.Word,
 	cfg *config.Config,
 ) error {
@@ -466,7 +470,7 @@ func saveWords(
 	}
 	totalSaved := 0
 
-	bar := newProgressBar(len(words), "Saving words: ")
+	bar := newProgressBar(progress, len(words), "Saving words: ")
 	defer bar.Finish()
 
 	for i := 0; i < len(words); i += batchSize {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/gnames/gndb/pkg/config"
 )
@@ -47,3 +48,23 @@ func prepareCacheDir(homeDir string) (string, error) {
 
 	return sfgaCache, nil
 }
+
+// prepareSourceCacheDir returns an empty SFGA cache subdirectory for one
+// data source. It is used when several sources are imported in parallel.
+// Cache location: ~/.cache/gndb/sfga/<source ID>/
+func prepareSourceCacheDir(homeDir string, sourceID int) (string, error) {
+	sfgaCache := sourceCacheDir(homeDir, sourceID)
+
+	if err := clearCache(sfgaCache); err != nil {
+		return "", fmt.Errorf("failed to clear cache: %w", err)
+	}
+
+	return sfgaCache, nil
+}
+
+// sourceCacheDir returns the SFGA cache subdirectory of a data source.
+func sourceCacheDir(homeDir string, sourceID int) string {
+	return filepath.Join(
+		config.CacheDir(homeDir), "sfga", strconv.Itoa(sourceID),
+	)
+}
@@ -139,3 +139,32 @@ func TestPrepareCacheDirPath(t *testing.T) {
 	// Verify it ends with "sfga".
 	assert.Equal(t, "sfga", filepath.Base(cacheDir))
 }
+
+func TestPrepareSourceCacheDir(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses file system in short mode")
+	}
+
+	tmpHome := t.TempDir()
+
+	dir1, err := prepareSourceCacheDir(tmpHome, 1)
+	require.NoError(t, err)
+	dir2, err := prepareSourceCacheDir(tmpHome, 2)
+	require.NoError(t, err)
+
+	// Every source gets its own subdirectory of the SFGA cache.
+	assert.Equal(t, "1", filepath.Base(dir1))
+	assert.Equal(t, "sfga", filepath.Base(filepath.Dir(dir1)))
+	assert.NotEqual(t, dir1, dir2)
+
+	// Preparing one source does not touch files of another.
+	testFile := filepath.Join(dir2, "test.sqlite")
+	err = os.WriteFile(testFile, []byte("test"), 0644)
+	require.NoError(t, err)
+
+	_, err = prepareSourceCacheDir(tmpHome, 1)
+	require.NoError(t, err)
+
+	_, err = os.Stat(testFile)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,255 @@
+package iopopulate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gnames/gndb/pkg/config"
+)
+
+// stage identifies one of the six phases of a source import.
+// The numeric values match the "(N/6)" labels shown to the user.
+type stage int
+
+const (
+	stageNone stage = iota
+	stageFetch
+	stageNames
+	stageHierarchy
+	stageIndices
+	stageVernaculars
+	stageMetadata
+)
+
+// stageFinal is the last stage of a source import. A source whose
+// checkpoint reached this stage was imported completely.
+const stageFinal = stageMetadata
+
+// String returns a human-readable name of the stage.
+func (s stage) String() string {
+	switch s {
+	case stageFetch:
+		return "sfga"
+	case stageNames:
+		return "names"
+	case stageHierarchy:
+		return "hierarchy"
+	case stageIndices:
+		return "indices"
+	case stageVernaculars:
+		return "vernaculars"
+	case stageMetadata:
+		return "metadata"
+	default:
+		return "none"
+	}
+}
+
+// checkpoint records the progress of one data source in a populate run.
+type checkpoint struct {
+	// SourceID is the ID of the data source.
+	SourceID int `json:"source_id"`
+
+	// Stage is the last stage that finished successfully.
+	Stage stage `json:"stage"`
+
+	// SFGAFile is the name of the SFGA file the stages were run on.
+	// A different file means a new release, so the checkpoint is void.
+	SFGAFile string `json:"sfga_file"`
+
+	// UpdatedAt is the time the checkpoint was last written.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// checkpoints is the persistent state of a populate run. It is stored as
+// a JSON file in the cache directory and rewritten after every stage, so
+// an interrupted run can be continued with `gndb populate --resume`.
+type checkpoints struct {
+	// Database identifies the PostgreSQL database the state belongs to.
+	// State saved for another database is ignored on resume.
+	Database string `json:"database"`
+
+	// StartedAt is the time the run (not the resumed run) started.
+	StartedAt time.Time `json:"started_at"`
+
+	// Sources maps data source IDs to their checkpoints.
+	Sources map[int]*checkpoint `json:"sources"`
+
+	// Releases maps data source IDs to the last release imported into
+	// the database. Unlike checkpoints they are kept between runs.
+	Releases map[int]*release `json:"releases,omitempty"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// release describes the SFGA file of a completely imported data source.
+type release struct {
+	// SFGAFile is the name of the imported file.
+	SFGAFile string `json:"sfga_file"`
+
+	// Version and RevisionDate are stored in data_sources as well.
+	Version      string `json:"version,omitempty"`
+	RevisionDate string `json:"revision_date,omitempty"`
+
+	// SHA256 is the checksum of the file from a remote manifest, if it
+	// was known.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Settings is the hash of the source settings used by the import,
+	// like filters and policies (see settingsHash).
+	Settings string `json:"settings,omitempty"`
+}
+
+// checkpointsPath returns the location of the populate state file.
+// Cache location: ~/.cache/gndb/populate-state.json
+func checkpointsPath(homeDir string) string {
+	return filepath.Join(config.CacheDir(homeDir), "populate-state.json")
+}
+
+// databaseKey returns a string that identifies the target database.
+func databaseKey(cfg config.DatabaseConfig) string {
+	return fmt.Sprintf("%s@%s:%d/%s",
+		cfg.User, cfg.Host, cfg.Port, cfg.Database)
+}
+
+// newCheckpoints creates an empty state for a fresh populate run.
+func newCheckpoints(path, database string) *checkpoints {
+	return &checkpoints{
+		Database:  database,
+		StartedAt: time.Now(),
+		Sources:   make(map[int]*checkpoint),
+		Releases:  make(map[int]*release),
+		path:      path,
+	}
+}
+
+// loadCheckpoints reads populate state from path. If the file does not
+// exist, or it belongs to a different database, an empty state is
+// returned.
+func loadCheckpoints(path, database string) (*checkpoints, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		slog.Info("No previous populate state found", "path", path)
+		return newCheckpoints(path, database), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read populate state: %w", err)
+	}
+
+	var res checkpoints
+	if err = json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("failed to parse populate state: %w", err)
+	}
+
+	if res.Database != database {
+		slog.Warn("Populate state belongs to another database, ignoring",
+			"state_database", res.Database,
+			"database", database,
+		)
+		return newCheckpoints(path, database), nil
+	}
+
+	if res.Sources == nil {
+		res.Sources = make(map[int]*checkpoint)
+	}
+	if res.Releases == nil {
+		res.Releases = make(map[int]*release)
+	}
+	res.path = path
+	return &res, nil
+}
+
+// get returns a copy of the checkpoint of a source. The zero value
+// (stageNone) means the source did not reach any stage yet.
+func (c *checkpoints) get(sourceID int) checkpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cp, ok := c.Sources[sourceID]; ok {
+		return *cp
+	}
+	return checkpoint{SourceID: sourceID}
+}
+
+// isDone returns true if the source was imported completely.
+func (c *checkpoints) isDone(sourceID int) bool {
+	return c.get(sourceID).Stage >= stageFinal
+}
+
+// mark records that the source finished the given stage on sfgaFile and
+// saves the state to disk.
+func (c *checkpoints) mark(sourceID int, s stage, sfgaFile string) error {
+	c.mu.Lock()
+	c.Sources[sourceID] = &checkpoint{
+		SourceID:  sourceID,
+		Stage:     s,
+		SFGAFile:  sfgaFile,
+		UpdatedAt: time.Now(),
+	}
+	c.mu.Unlock()
+
+	return c.save()
+}
+
+// getRelease returns the last imported release of a source, and false if
+// it is unknown.
+func (c *checkpoints) getRelease(sourceID int) (release, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if r, ok := c.Releases[sourceID]; ok {
+		return *r, true
+	}
+	return release{}, false
+}
+
+// setRelease records the imported release of a source and saves the
+// state to disk.
+func (c *checkpoints) setRelease(sourceID int, r release) error {
+	c.mu.Lock()
+	c.Releases[sourceID] = &r
+	c.mu.Unlock()
+
+	return c.save()
+}
+
+// reset removes the checkpoint of a source, so it starts from stage 1.
+func (c *checkpoints) reset(sourceID int) error {
+	c.mu.Lock()
+	delete(c.Sources, sourceID)
+	c.mu.Unlock()
+
+	return c.save()
+}
+
+// save writes the state to disk. It writes to a temporary file first and
+// renames it, so an interruption never leaves a truncated state file.
+func (c *checkpoints) save() error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode populate state: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create populate state directory: %w", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write populate state: %w", err)
+	}
+
+	if err = os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to save populate state: %w", err)
+	}
+	return nil
+}
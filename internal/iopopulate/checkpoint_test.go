@@ -0,0 +1,98 @@
+package iopopulate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointsRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses file system in short mode")
+	}
+
+	path := filepath.Join(t.TempDir(), "state", "populate-state.json")
+	db := "postgres@localhost:5432/gnames"
+
+	cps, err := loadCheckpoints(path, db)
+	require.NoError(t, err)
+	assert.Equal(t, stageNone, cps.get(1).Stage)
+
+	require.NoError(t, cps.mark(1, stageIndices, "0001-col-2025-10-03.sqlite.zip"))
+	require.NoError(t, cps.mark(3, stageMetadata, "0003-itis-2025-10-03.sqlite.zip"))
+
+	loaded, err := loadCheckpoints(path, db)
+	require.NoError(t, err)
+
+	cp := loaded.get(1)
+	assert.Equal(t, stageIndices, cp.Stage)
+	assert.Equal(t, "0001-col-2025-10-03.sqlite.zip", cp.SFGAFile)
+	assert.False(t, loaded.isDone(1))
+	assert.True(t, loaded.isDone(3))
+
+	require.NoError(t, loaded.reset(3))
+	loaded, err = loadCheckpoints(path, db)
+	require.NoError(t, err)
+	assert.False(t, loaded.isDone(3))
+
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err), "temporary file should be renamed")
+}
+
+func TestCheckpointsReleases(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses file system in short mode")
+	}
+
+	path := filepath.Join(t.TempDir(), "populate-state.json")
+	db := "postgres@localhost:5432/gnames"
+
+	cps := newCheckpoints(path, db)
+	_, ok := cps.getRelease(1)
+	assert.False(t, ok)
+
+	r := release{
+		SFGAFile:     "0001-col-2025-10-03.sqlite.zip",
+		RevisionDate: "2025-10-03",
+		SHA256:       "abc",
+	}
+	require.NoError(t, cps.setRelease(1, r))
+	require.NoError(t, cps.reset(1))
+
+	loaded, err := loadCheckpoints(path, db)
+	require.NoError(t, err)
+	got, ok := loaded.getRelease(1)
+	assert.True(t, ok, "releases should survive reset of checkpoints")
+	assert.Equal(t, r, got)
+}
+
+func TestCheckpointsOtherDatabase(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses file system in short mode")
+	}
+
+	path := filepath.Join(t.TempDir(), "populate-state.json")
+
+	cps := newCheckpoints(path, "postgres@localhost:5432/gnames")
+	require.NoError(t, cps.mark(1, stageMetadata, "0001.sqlite"))
+
+	loaded, err := loadCheckpoints(path, "postgres@localhost:5432/other")
+	require.NoError(t, err)
+	assert.Empty(t, loaded.Sources,
+		"state of another database should be ignored")
+}
+
+func TestCheckpointsCorruptFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses file system in short mode")
+	}
+
+	path := filepath.Join(t.TempDir(), "populate-state.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0644))
+
+	_, err := loadCheckpoints(path, "postgres@localhost:5432/gnames")
+	assert.Error(t, err)
+}
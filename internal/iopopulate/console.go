@@ -0,0 +1,146 @@
+package iopopulate
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/gnames/gndb/internal/iofetch"
+	"github.com/gnames/gndb/internal/ioprogress"
+	"github.com/gnames/gndb/pkg/gndb"
+)
+
+// emit sends an event to the progress sink. Events get the ID of the
+// data source being imported, if any.
+func (p *populator) emit(e gndb.Event) {
+	e.SourceID = p.sourceID
+	e.Parallel = p.sourceID > 0 && p.isParallel()
+	p.sink().Emit(e)
+}
+
+// sink returns the progress sink of the populator, or the terminal if
+// none was given.
+func (p *populator) sink() gndb.ProgressSink {
+	if p.progress == nil {
+		return ioprogress.Default()
+	}
+	return p.progress
+}
+
+// info sends an informational message.
+func (p *populator) info(msg string, vars ...any) {
+	p.emit(gndb.Event{Type: gndb.EventInfo, Message: format(msg, vars)})
+}
+
+// message sends a detail of the current stage.
+func (p *populator) message(msg string, vars ...any) {
+	p.emit(gndb.Event{Type: gndb.EventMessage, Message: format(msg, vars)})
+}
+
+// warn sends a warning.
+func (p *populator) warn(msg string, vars ...any) {
+	p.emit(gndb.Event{Type: gndb.EventWarning, Message: format(msg, vars)})
+}
+
+// startStage sends the start of a stage of the source.
+func (p *populator) startStage(s stage, msg string, vars ...any) {
+	p.emit(gndb.Event{
+		Type:    gndb.EventStageStarted,
+		Stage:   s.String(),
+		Step:    int(s),
+		Steps:   int(stageFinal),
+		Message: fmt.Sprintf("(%d/%d) ", s, stageFinal) + format(msg, vars),
+	})
+}
+
+// finishStage sends the result of a stage that started at start and
+// wrote count records.
+func (p *populator) finishStage(
+	s stage,
+	start time.Time,
+	count int,
+	msg string,
+) {
+	p.emit(gndb.Event{
+		Type:        gndb.EventStageFinished,
+		Stage:       s.String(),
+		Step:        int(s),
+		Steps:       int(stageFinal),
+		Status:      gndb.StatusSucceeded,
+		Count:       count,
+		DurationSec: time.Since(start).Seconds(),
+		Message:     msg,
+	})
+}
+
+// finishSource sends the result of the source being imported.
+func (p *populator) finishSource(
+	res sourceResult,
+	err error,
+	start time.Time,
+) {
+	e := gndb.Event{
+		Type:        gndb.EventSourceFinished,
+		Status:      res.status(),
+		DurationSec: time.Since(start).Seconds(),
+		Err:         err,
+	}
+	if res == sourceSucceeded {
+		e.Message = "Completed in"
+	}
+	p.emit(e)
+}
+
+// format applies vars to msg, the message is used as is without vars.
+func format(msg string, vars []any) string {
+	if len(vars) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, vars...)
+}
+
+// logger returns the logger of the populator. Sources imported in
+// parallel get a logger that adds the "source" attribute to every record.
+func (p *populator) logger() *slog.Logger {
+	if p.log == nil {
+		return slog.Default()
+	}
+	return p.log
+}
+
+// newCounter starts a progress bar of a stage with the given total and
+// label. The terminal hides bars of parallel imports, because they would
+// overwrite each other.
+func (p *populator) newCounter(total int, label string) *ioprogress.Counter {
+	return ioprogress.NewCounter(p.sink(), gndb.Event{
+		SourceID: p.sourceID,
+		Parallel: p.sourceID > 0 && p.isParallel(),
+		Message:  label,
+	}, total)
+}
+
+// downloadProgress returns a callback that shows downloaded bytes of the
+// file at url as a progress bar, and a function that closes the bar.
+// The bar starts with the first downloaded bytes, so cached archives do
+// not show it.
+func (p *populator) downloadProgress(url string) (iofetch.Progress, func()) {
+	var bar *ioprogress.Counter
+	progress := func(done, total int64) {
+		if bar == nil {
+			bar = p.newCounter(int(total), "Downloading "+path.Base(url)+" ")
+		}
+		bar.SetCurrent(int(done))
+	}
+	finish := func() {
+		if bar != nil {
+			bar.Finish()
+		}
+	}
+	return progress, finish
+}
+
+// isParallel returns true if several sources are imported at once.
+func (p *populator) isParallel() bool {
+	return p.cfg != nil && p.cfg.Populate.ParallelSources > 1
+}
@@ -0,0 +1,101 @@
+package iopopulate
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gnames/gndb/pkg/config"
+	"github.com/gnames/gndb/pkg/sources"
+	"github.com/sfborg/sflib"
+)
+
+// convertBatchSize is the number of records written to the SFGA file at
+// once during conversion.
+const convertBatchSize = 10_000
+
+// needsConversion tells if a source has to be converted to SFGA before
+// import.
+func needsConversion(source sources.DataSourceConfig) bool {
+	return source.Format == sources.FormatDwCA ||
+		source.Format == sources.FormatCoLDP
+}
+
+// convertArchive converts a DwC-A or CoLDP archive to SFGA with the
+// sfborg libraries and returns the path of the resulting SFGA file.
+// The conversion is stopped when ctx is cancelled.
+// Converted files are kept in a subdirectory of the cache per data source.
+// Cache location: ~/.cache/gndb/convert/<source ID>/
+func convertArchive(
+	ctx context.Context,
+	homeDir string,
+	sourceID int,
+	format, archive string,
+) (string, error) {
+	dir := filepath.Join(
+		config.CacheDir(homeDir), "convert", strconv.Itoa(sourceID),
+	)
+	if err := clearCache(dir); err != nil {
+		return "", fmt.Errorf("failed to clear convert directory: %w", err)
+	}
+
+	// sflib.Create requires an empty directory.
+	arc := sflib.NewSfga()
+	if err := arc.Create(filepath.Join(dir, "work")); err != nil {
+		return "", fmt.Errorf("failed to create SFGA database: %w", err)
+	}
+	if _, err := arc.Connect(); err != nil {
+		return "", fmt.Errorf("failed to connect to SFGA database: %w", err)
+	}
+	defer arc.Close()
+
+	var err error
+	extractDir := filepath.Join(dir, "archive")
+	switch format {
+	case sources.FormatCoLDP:
+		err = importColdp(ctx, arc, archive, extractDir)
+	case sources.FormatDwCA:
+		err = importDwca(ctx, arc, archive)
+	default:
+		err = fmt.Errorf("unknown archive format %q", format)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	base := filepath.Join(dir, "sfga")
+	if err = arc.Export(base, false); err != nil {
+		return "", fmt.Errorf("failed to write SFGA file: %w", err)
+	}
+	return base + ".sqlite", nil
+}
+
+// insertBatches writes records received from ch to the SFGA file in
+// batches of convertBatchSize. It drains ch if writing fails, so the
+// sender is never blocked.
+func insertBatches[T any](
+	ctx context.Context,
+	ch <-chan T,
+	insert func([]T) error,
+) error {
+	var err error
+	batch := make([]T, 0, convertBatchSize)
+	for rec := range ch {
+		if err != nil {
+			continue
+		}
+		if err = ctx.Err(); err != nil {
+			continue
+		}
+		batch = append(batch, rec)
+		if len(batch) == convertBatchSize {
+			err = insert(batch)
+			batch = make([]T, 0, convertBatchSize)
+		}
+	}
+	if err != nil || len(batch) == 0 {
+		return err
+	}
+	return insert(batch)
+}
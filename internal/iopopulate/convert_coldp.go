@@ -0,0 +1,96 @@
+package iopopulate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sfborg/sflib"
+	"github.com/sfborg/sflib/pkg/coldp"
+	"github.com/sfborg/sflib/pkg/sfga"
+	"golang.org/x/sync/errgroup"
+)
+
+// importColdp extracts a CoLDP archive to dir and copies its metadata and
+// data files to the SFGA database.
+func importColdp(
+	ctx context.Context,
+	arc sfga.Archive,
+	archive, dir string,
+) error {
+	c := sflib.NewColdp()
+	if err := c.Fetch(archive, dir); err != nil {
+		return fmt.Errorf("failed to extract CoLDP archive: %w", err)
+	}
+
+	meta, err := c.Meta()
+	if err != nil {
+		return fmt.Errorf("failed to read CoLDP metadata: %w", err)
+	}
+	if meta != nil {
+		if err = arc.InsertMeta(meta); err != nil {
+			return fmt.Errorf("failed to write metadata: %w", err)
+		}
+	}
+
+	// References go first, names and taxa refer to them.
+	steps := []struct {
+		dt   coldp.DataType
+		copy func(path string) error
+	}{
+		{coldp.ReferenceDT, func(path string) error {
+			return copyColdp(ctx, c, path, arc.InsertReferences)
+		}},
+		{coldp.NameDT, func(path string) error {
+			return copyColdp(ctx, c, path, arc.InsertNames)
+		}},
+		{coldp.TaxonDT, func(path string) error {
+			return copyColdp(ctx, c, path, arc.InsertTaxa)
+		}},
+		{coldp.SynonymDT, func(path string) error {
+			return copyColdp(ctx, c, path, arc.InsertSynonyms)
+		}},
+		{coldp.NameUsageDT, func(path string) error {
+			return copyColdp(ctx, c, path, arc.InsertNameUsages)
+		}},
+		{coldp.VernacularNameDT, func(path string) error {
+			return copyColdp(ctx, c, path, arc.InsertVernaculars)
+		}},
+	}
+
+	paths := c.DataPaths()
+	for _, step := range steps {
+		path, ok := paths[step.dt]
+		if !ok {
+			continue
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = step.copy(path); err != nil {
+			return fmt.Errorf("failed to convert %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// copyColdp reads records of one CoLDP data file and writes them to the
+// SFGA database with insert.
+func copyColdp[T coldp.DataLoader](
+	ctx context.Context,
+	c coldp.Archive,
+	path string,
+	insert func([]T) error,
+) error {
+	ch := make(chan T)
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return insertBatches(ctx, ch, insert)
+	})
+
+	err := coldp.Read(c.Config(), path, ch)
+	close(ch)
+	if gErr := g.Wait(); err == nil {
+		err = gErr
+	}
+	return err
+}
@@ -0,0 +1,418 @@
+package iopopulate
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sfborg/sflib/pkg/coldp"
+	"github.com/sfborg/sflib/pkg/sfga"
+)
+
+// dwcaMeta is the part of meta.xml of a DwC-A used in conversion.
+type dwcaMeta struct {
+	Core       dwcaFile   `xml:"core"`
+	Extensions []dwcaFile `xml:"extension"`
+}
+
+// dwcaFile describes a core or extension data file of a DwC-A.
+type dwcaFile struct {
+	RowType            string      `xml:"rowType,attr"`
+	FieldsTerminatedBy *string     `xml:"fieldsTerminatedBy,attr"`
+	FieldsEnclosedBy   *string     `xml:"fieldsEnclosedBy,attr"`
+	IgnoreHeaderLines  int         `xml:"ignoreHeaderLines,attr"`
+	Location           string      `xml:"files>location"`
+	ID                 *dwcaField  `xml:"id"`
+	CoreID             *dwcaField  `xml:"coreid"`
+	Fields             []dwcaField `xml:"field"`
+}
+
+// dwcaField is a column of a DwC-A data file.
+type dwcaField struct {
+	Index string `xml:"index,attr"`
+	Term  string `xml:"term,attr"`
+}
+
+// dwcaEML is the part of eml.xml of a DwC-A used in conversion.
+type dwcaEML struct {
+	Title    string `xml:"dataset>title"`
+	Abstract string `xml:"dataset>abstract>para"`
+}
+
+// importDwca reads a zipped DwC-A with a Taxon core and copies its names,
+// taxa, synonyms and vernacular names to the SFGA database.
+func importDwca(ctx context.Context, arc sfga.Archive, archive string) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("failed to open DwC-A archive: %w", err)
+	}
+	defer zr.Close()
+
+	// meta.xml is either at the root of the archive or in its only
+	// directory, data files are next to it.
+	var meta dwcaMeta
+	metaPath, err := findDwcaFile(&zr.Reader, "meta.xml")
+	if err != nil {
+		return err
+	}
+	if err = readDwcaXML(&zr.Reader, metaPath, &meta); err != nil {
+		return err
+	}
+	if !strings.HasSuffix(meta.Core.RowType, "Taxon") {
+		return fmt.Errorf("DwC-A core %q is not Taxon", meta.Core.RowType)
+	}
+	dir := path.Dir(metaPath)
+
+	if err = importDwcaMeta(&zr.Reader, arc); err != nil {
+		return err
+	}
+
+	err = importDwcaCore(ctx, &zr.Reader, dir, meta.Core, arc)
+	if err != nil {
+		return err
+	}
+
+	for _, ext := range meta.Extensions {
+		if !strings.HasSuffix(ext.RowType, "VernacularName") {
+			continue
+		}
+		err = importDwcaVernaculars(ctx, &zr.Reader, dir, ext, arc)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importDwcaMeta writes the title and description of eml.xml to the
+// SFGA metadata. Archives without eml.xml get empty metadata.
+func importDwcaMeta(zr *zip.Reader, arc sfga.Archive) error {
+	var eml dwcaEML
+	emlPath, err := findDwcaFile(zr, "eml.xml")
+	if err == nil {
+		if err = readDwcaXML(zr, emlPath, &eml); err != nil {
+			return err
+		}
+	}
+	meta := &coldp.Meta{
+		Title:       strings.TrimSpace(eml.Title),
+		Description: strings.TrimSpace(eml.Abstract),
+	}
+	if err = arc.InsertMeta(meta); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	return nil
+}
+
+// importDwcaCore converts rows of the Taxon core to names, and to taxa
+// or synonyms depending on their acceptedNameUsageID.
+func importDwcaCore(
+	ctx context.Context,
+	zr *zip.Reader,
+	dir string,
+	core dwcaFile,
+	arc sfga.Archive,
+) error {
+	var (
+		names    []coldp.Name
+		taxa     []coldp.Taxon
+		synonyms []coldp.Synonym
+	)
+	// Names are written before taxa and synonyms that refer to them.
+	flushNames := func() error {
+		err := flushBatch(names, arc.InsertNames)
+		names = nil
+		return err
+	}
+	insertTaxa := func(batch []coldp.Taxon) error {
+		if err := flushNames(); err != nil {
+			return err
+		}
+		return arc.InsertTaxa(batch)
+	}
+	insertSynonyms := func(batch []coldp.Synonym) error {
+		if err := flushNames(); err != nil {
+			return err
+		}
+		return arc.InsertSynonyms(batch)
+	}
+
+	err := readDwcaRows(ctx, zr, dir, core, func(row map[string]string) error {
+		id := row["taxonID"]
+		if id == "" {
+			id = row["id"]
+		}
+		name := row["scientificName"]
+		if id == "" || name == "" {
+			return nil
+		}
+
+		auth := row["scientificNameAuthorship"]
+		nameString := name
+		if auth != "" && !strings.HasSuffix(name, auth) {
+			nameString += " " + auth
+		}
+		n := coldp.Name{
+			ID:                   id,
+			ScientificName:       name,
+			Authorship:           auth,
+			ScientificNameString: nameString,
+			Rank:                 coldp.NewRank(row["taxonRank"]),
+		}
+
+		var err error
+		names, err = appendBatch(names, n, arc.InsertNames)
+		if err != nil {
+			return err
+		}
+
+		status := strings.ToLower(row["taxonomicStatus"])
+		accepted := row["acceptedNameUsageID"]
+		if accepted == "" || accepted == id {
+			t := coldp.Taxon{
+				ID:       id,
+				NameID:   id,
+				ParentID: row["parentNameUsageID"],
+			}
+			if status != "" {
+				t.Status = coldp.NewTaxonomicStatus(status)
+			}
+			taxa, err = appendBatch(taxa, t, insertTaxa)
+		} else {
+			if status == "" {
+				status = synonymStatus
+			}
+			s := coldp.Synonym{
+				ID:      id,
+				NameID:  id,
+				TaxonID: accepted,
+				Status:  coldp.NewTaxonomicStatus(status),
+			}
+			synonyms, err = appendBatch(synonyms, s, insertSynonyms)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = flushNames(); err != nil {
+		return err
+	}
+	if err = flushBatch(taxa, arc.InsertTaxa); err != nil {
+		return err
+	}
+	return flushBatch(synonyms, arc.InsertSynonyms)
+}
+
+// importDwcaVernaculars converts rows of the VernacularName extension.
+func importDwcaVernaculars(
+	ctx context.Context,
+	zr *zip.Reader,
+	dir string,
+	ext dwcaFile,
+	arc sfga.Archive,
+) error {
+	var vernaculars []coldp.Vernacular
+	err := readDwcaRows(ctx, zr, dir, ext, func(row map[string]string) error {
+		if row["coreid"] == "" || row["vernacularName"] == "" {
+			return nil
+		}
+		v := coldp.Vernacular{
+			TaxonID:  row["coreid"],
+			Name:     row["vernacularName"],
+			Language: row["language"],
+			Country:  row["countryCode"],
+			Area:     row["locality"],
+		}
+		var err error
+		vernaculars, err = appendBatch(vernaculars, v, arc.InsertVernaculars)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return flushBatch(vernaculars, arc.InsertVernaculars)
+}
+
+// appendBatch adds rec to batch and writes the batch when it reaches
+// convertBatchSize records.
+func appendBatch[T any](batch []T, rec T, insert func([]T) error) ([]T, error) {
+	batch = append(batch, rec)
+	if len(batch) < convertBatchSize {
+		return batch, nil
+	}
+	if err := insert(batch); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// flushBatch writes the remaining records of a batch.
+func flushBatch[T any](batch []T, insert func([]T) error) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	return insert(batch)
+}
+
+// findDwcaFile returns the path of a file with the given name at the root
+// of the archive, or in any of its directories.
+func findDwcaFile(zr *zip.Reader, name string) (string, error) {
+	var res string
+	for _, f := range zr.File {
+		if path.Base(f.Name) != name {
+			continue
+		}
+		if res == "" || len(f.Name) < len(res) {
+			res = f.Name
+		}
+	}
+	if res == "" {
+		return "", fmt.Errorf("%s not found in DwC-A archive", name)
+	}
+	return res, nil
+}
+
+// readDwcaXML decodes an XML file of the archive into v.
+func readDwcaXML(zr *zip.Reader, name string, v any) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err = xml.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return nil
+}
+
+// readDwcaRows calls fn for every row of a data file. Rows are given as
+// maps of local names of their terms to values, the core ID is under
+// "id" and the core ID of an extension under "coreid".
+func readDwcaRows(
+	ctx context.Context,
+	zr *zip.Reader,
+	dir string,
+	file dwcaFile,
+	fn func(row map[string]string) error,
+) error {
+	columns := dwcaColumns(file)
+
+	f, err := zr.Open(path.Join(dir, file.Location))
+	if err != nil {
+		return fmt.Errorf("failed to open DwC-A data file: %w", err)
+	}
+	defer f.Close()
+
+	next := dwcaRowReader(f, file)
+	for i := 0; ; i++ {
+		fields, err := next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file.Location, err)
+		}
+		if i < file.IgnoreHeaderLines {
+			continue
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		row := make(map[string]string, len(columns))
+		for idx, term := range columns {
+			if idx < len(fields) {
+				row[term] = strings.TrimSpace(fields[idx])
+			}
+		}
+		if err = fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+// dwcaColumns maps column indices of a data file to local names of their
+// terms.
+func dwcaColumns(file dwcaFile) map[int]string {
+	res := make(map[int]string)
+	add := func(f *dwcaField, term string) {
+		if f == nil {
+			return
+		}
+		idx, err := strconv.Atoi(f.Index)
+		if err != nil {
+			return
+		}
+		if term == "" {
+			term = f.Term[strings.LastIndexAny(f.Term, "/:")+1:]
+		}
+		if _, ok := res[idx]; !ok {
+			res[idx] = term
+		}
+	}
+	add(file.ID, "id")
+	add(file.CoreID, "coreid")
+	for i := range file.Fields {
+		add(&file.Fields[i], "")
+	}
+	return res
+}
+
+// dwcaRowReader returns a function that reads the next row of a data
+// file. By default DwC-A files are comma separated and quoted with '"'.
+func dwcaRowReader(r io.Reader, file dwcaFile) func() ([]string, error) {
+	sep, quote := ",", `"`
+	if file.FieldsTerminatedBy != nil {
+		sep = unescapeDwca(*file.FieldsTerminatedBy)
+	}
+	if file.FieldsEnclosedBy != nil {
+		quote = unescapeDwca(*file.FieldsEnclosedBy)
+	}
+
+	sepRune, size := utf8.DecodeRuneInString(sep)
+	if quote == `"` && size == len(sep) {
+		cr := csv.NewReader(r)
+		cr.Comma = sepRune
+		cr.LazyQuotes = true
+		cr.FieldsPerRecord = -1
+		return cr.Read
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return func() ([]string, error) {
+		if !sc.Scan() {
+			if err := sc.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		fields := strings.Split(strings.TrimSuffix(sc.Text(), "\r"), sep)
+		if quote != "" {
+			for i := range fields {
+				fields[i] = strings.TrimSuffix(
+					strings.TrimPrefix(fields[i], quote), quote,
+				)
+			}
+		}
+		return fields, nil
+	}
+}
+
+// unescapeDwca converts escaped characters of meta.xml attributes.
+func unescapeDwca(s string) string {
+	return strings.NewReplacer(`\t`, "\t", `\n`, "\n", `\r`, "\r").
+		Replace(s)
+}
@@ -0,0 +1,142 @@
+package iopopulate
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDwcaMeta = `<?xml version="1.0" encoding="UTF-8"?>
+<archive xmlns="http://rs.tdwg.org/dwc/text/">
+  <core rowType="http://rs.tdwg.org/dwc/terms/Taxon"
+        fieldsTerminatedBy="\t" fieldsEnclosedBy="" ignoreHeaderLines="1">
+    <files><location>taxon.txt</location></files>
+    <id index="0"/>
+    <field index="1" term="http://rs.tdwg.org/dwc/terms/scientificName"/>
+    <field index="2" term="http://rs.tdwg.org/dwc/terms/acceptedNameUsageID"/>
+    <field default="ICZN" term="http://rs.tdwg.org/dwc/terms/nomenclaturalCode"/>
+  </core>
+  <extension rowType="http://rs.gbif.org/terms/1.0/VernacularName">
+    <files><location>vernacular.csv</location></files>
+    <coreid index="0"/>
+    <field index="1" term="http://rs.tdwg.org/dwc/terms/vernacularName"/>
+  </extension>
+</archive>`
+
+// testDwca creates a zipped DwC-A in memory, files are in a subdirectory.
+func testDwca(t *testing.T) *zip.Reader {
+	files := map[string]string{
+		"dwca/meta.xml": testDwcaMeta,
+		"dwca/taxon.txt": "id\tscientificName\tacceptedNameUsageID\n" +
+			"t1\tPasser domesticus\t\n" +
+			"t2\tFringilla domestica\tt1\r\n",
+		"dwca/vernacular.csv": "t1,\"Sparrow, house\"\n",
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return zr
+}
+
+func TestReadDwcaRows(t *testing.T) {
+	zr := testDwca(t)
+	ctx := context.Background()
+
+	metaPath, err := findDwcaFile(zr, "meta.xml")
+	require.NoError(t, err)
+	assert.Equal(t, "dwca/meta.xml", metaPath)
+	_, err = findDwcaFile(zr, "eml.xml")
+	assert.Error(t, err)
+
+	var meta dwcaMeta
+	require.NoError(t, readDwcaXML(zr, metaPath, &meta))
+	assert.Equal(t, map[int]string{
+		0: "id", 1: "scientificName", 2: "acceptedNameUsageID",
+	}, dwcaColumns(meta.Core))
+
+	var rows []map[string]string
+	collect := func(row map[string]string) error {
+		rows = append(rows, row)
+		return nil
+	}
+
+	err = readDwcaRows(ctx, zr, "dwca", meta.Core, collect)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{
+		{"id": "t1", "scientificName": "Passer domesticus",
+			"acceptedNameUsageID": ""},
+		{"id": "t2", "scientificName": "Fringilla domestica",
+			"acceptedNameUsageID": "t1"},
+	}, rows)
+
+	// The extension uses the default comma separator and quotes.
+	rows = nil
+	require.Len(t, meta.Extensions, 1)
+	err = readDwcaRows(ctx, zr, "dwca", meta.Extensions[0], collect)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{
+		{"coreid": "t1", "vernacularName": "Sparrow, house"},
+	}, rows)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = readDwcaRows(cancelled, zr, "dwca", meta.Core, collect)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDwcaRowReader(t *testing.T) {
+	sep, quote := "|", "'"
+	next := dwcaRowReader(
+		strings.NewReader("'a'|b\n"),
+		dwcaFile{FieldsTerminatedBy: &sep, FieldsEnclosedBy: &quote},
+	)
+	fields, err := next()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, fields)
+	_, err = next()
+	assert.Error(t, err)
+}
+
+func TestBatches(t *testing.T) {
+	var inserted [][]int
+	insert := func(batch []int) error {
+		inserted = append(inserted, batch)
+		return nil
+	}
+
+	var batch []int
+	var err error
+	for i := range convertBatchSize + 1 {
+		batch, err = appendBatch(batch, i, insert)
+		require.NoError(t, err)
+	}
+	require.NoError(t, flushBatch(batch, insert))
+	require.Len(t, inserted, 2)
+	assert.Len(t, inserted[0], convertBatchSize)
+	assert.Equal(t, []int{convertBatchSize}, inserted[1])
+
+	inserted = nil
+	ch := make(chan int)
+	go func() {
+		for i := range 3 {
+			ch <- i
+		}
+		close(ch)
+	}()
+	require.NoError(t, insertBatches(context.Background(), ch, insert))
+	assert.Equal(t, [][]int{{0, 1, 2}}, inserted)
+}
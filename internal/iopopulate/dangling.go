@@ -0,0 +1,184 @@
+package iopopulate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gnames/gndb/pkg/report"
+	"github.com/gnames/gndb/pkg/sources"
+)
+
+// danglingSynonymsQuery finds synonyms whose accepted taxon does not exist
+// in the taxon table. Record IDs are built the same way as in
+// processSynonyms.
+const danglingSynonymsQuery = `
+	SELECT ` + synonymRecordID + `, COALESCE(s.col__taxon_id,'')
+	FROM synonym s
+	JOIN name n ON n.col__id = s.col__name_id
+	LEFT JOIN taxon t ON t.col__id = s.col__taxon_id
+	WHERE t.col__id IS NULL
+	ORDER BY 1, 2
+`
+
+// findDanglingSynonyms returns synonyms of the SFGA file whose accepted
+// taxon is missing, sorted by record ID and taxon ID. A pro parte synonym
+// occurs once for every missing taxon.
+func (p *populator) findDanglingSynonyms(
+	ctx context.Context,
+) ([]recordKey, error) {
+	rows, err := p.sfgaDB.QueryContext(ctx, danglingSynonymsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dangling synonyms: %w", err)
+	}
+	defer rows.Close()
+
+	var res []recordKey
+	for rows.Next() {
+		var key recordKey
+		if err = rows.Scan(&key.id, &key.acceptedID); err != nil {
+			return nil, fmt.Errorf("failed to scan dangling synonym: %w", err)
+		}
+		res = append(res, key)
+	}
+	return res, rows.Err()
+}
+
+// handleDanglingSynonyms looks for synonyms whose accepted taxon is
+// missing before name indices are written and decides what to do with
+// them according to the on_dangling_synonym policy (see ask). All
+// dangling synonyms go to the run report. Returns nil if the source has
+// none.
+func (p *populator) handleDanglingSynonyms(
+	ctx context.Context,
+	source *sources.DataSourceConfig,
+) (*danglingSynonyms, error) {
+	keys, err := p.findDanglingSynonyms(ctx)
+	if err != nil {
+		return nil, NamesError(source.ID, err)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	q := danglingSynonymQuestion(humanize.Comma(int64(len(keys))))
+	response, err := p.ask(
+		source.ID, q,
+		p.cfg.Populate.OnDanglingSynonym, source.OnDanglingSynonym,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger().Warn("Dangling synonyms",
+		"data_source_id", source.ID,
+		"count", len(keys),
+		"policy", response,
+	)
+	p.addWarning(fmt.Sprintf(
+		"%s synonyms have no accepted taxon, on_dangling_synonym: %s",
+		humanize.Comma(int64(len(keys))), response,
+	))
+	if p.srcReport != nil {
+		p.srcReport.DanglingSynonyms = &report.DanglingSynonyms{
+			Policy:    response,
+			Count:     len(keys),
+			RecordIDs: distinctIDs(keys),
+		}
+	}
+
+	return newDanglingSynonyms(response, keys), nil
+}
+
+// danglingSynonyms applies the on_dangling_synonym policy while synonyms
+// of a source are written. A nil *danglingSynonyms means the source has
+// no dangling synonyms.
+type danglingSynonyms struct {
+	policy string
+
+	// keys are record IDs and missing taxon IDs of dangling synonyms.
+	keys map[recordKey]struct{}
+
+	// bareNames are record IDs of synonyms written as bare names.
+	bareNames map[string]struct{}
+
+	// imported is the number of dangling synonyms written as synonyms
+	// or bare names.
+	imported int
+}
+
+// newDanglingSynonyms creates danglingSynonyms for dangling synonyms of
+// a source.
+func newDanglingSynonyms(policy string, keys []recordKey) *danglingSynonyms {
+	res := &danglingSynonyms{
+		policy:    policy,
+		keys:      make(map[recordKey]struct{}, len(keys)),
+		bareNames: make(map[string]struct{}),
+	}
+	for _, key := range keys {
+		res.keys[key] = struct{}{}
+	}
+	return res
+}
+
+// importsAny returns true if dangling synonyms are written, so the
+// synonyms query must not drop synonyms without an accepted taxon.
+func (d *danglingSynonyms) importsAny() bool {
+	return d != nil && d.policy != sources.OnDanglingDrop
+}
+
+// toBareName returns true if the synonym with the record ID has no
+// accepted taxon with taxonID and must be written as a bare name. It
+// returns false for ok if the synonym must not be written, because it is
+// a pro parte synonym already written as a bare name for another missing
+// taxon. Dangling synonyms are counted here, so it is called once for
+// every written synonym.
+func (d *danglingSynonyms) toBareName(recordID, taxonID string) (bool, bool) {
+	if d == nil {
+		return false, true
+	}
+	key := recordKey{id: recordID, acceptedID: taxonID}
+	if _, ok := d.keys[key]; !ok {
+		return false, true
+	}
+	if d.policy != sources.OnDanglingBareName {
+		d.imported++
+		return false, true
+	}
+	if _, ok := d.bareNames[recordID]; ok {
+		return false, false
+	}
+	d.bareNames[recordID] = struct{}{}
+	d.imported++
+	return true, true
+}
+
+// summary describes what happened to dangling synonyms, empty if there
+// were none.
+func (d *danglingSynonyms) summary() string {
+	if d == nil {
+		return ""
+	}
+	switch d.policy {
+	case sources.OnDanglingBareName:
+		return fmt.Sprintf(
+			"imported %d dangling synonyms as bare names", d.imported,
+		)
+	case sources.OnDanglingKeep:
+		return fmt.Sprintf("kept %d dangling synonyms", d.imported)
+	}
+	return fmt.Sprintf("dropped %d dangling synonyms", len(d.keys))
+}
+
+// distinctIDs returns record IDs of sorted keys without repeats of pro
+// parte synonyms.
+func distinctIDs(keys []recordKey) []string {
+	res := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if n := len(res); n > 0 && res[n-1] == key.id {
+			continue
+		}
+		res = append(res, key.id)
+	}
+	return res
+}
@@ -0,0 +1,108 @@
+package iopopulate
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/gnames/gndb/pkg/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDanglingSynonyms(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses SQLite in short mode")
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE name (col__id TEXT);
+		CREATE TABLE taxon (col__id TEXT, col__name_id TEXT);
+		CREATE TABLE synonym (
+			col__id TEXT, col__taxon_id TEXT, col__name_id TEXT
+		);
+		INSERT INTO name VALUES ('n1'), ('n2'), ('n3'), ('n4'), ('n5');
+		INSERT INTO taxon VALUES ('t1', 'n1');
+		INSERT INTO synonym VALUES
+			('s1', 't1', 'n2'), ('s2', 't9', 'n3'), (NULL, NULL, 'n4'),
+			('s3', 't1', 'n5'), ('s3', 't8', 'n5');
+	`)
+	require.NoError(t, err)
+
+	p := &populator{sfgaDB: db}
+	keys, err := p.findDanglingSynonyms(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []recordKey{
+		{id: "s2|t9|n3", acceptedID: "t9"},
+		{id: "s3", acceptedID: "t8"},
+		{id: "||n4", acceptedID: ""},
+	}, keys)
+}
+
+func TestDanglingSynonyms(t *testing.T) {
+	keys := []recordKey{
+		{id: "s2", acceptedID: "t9"},
+		{id: "s3", acceptedID: "t8"},
+		{id: "s3", acceptedID: "t9"},
+	}
+
+	t.Run("no dangling synonyms", func(t *testing.T) {
+		var d *danglingSynonyms
+		assert.False(t, d.importsAny())
+		bare, ok := d.toBareName("s2", "t9")
+		assert.False(t, bare)
+		assert.True(t, ok)
+		assert.Empty(t, d.summary())
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		d := newDanglingSynonyms(sources.OnDanglingDrop, keys)
+		assert.False(t, d.importsAny())
+		assert.Equal(t, "dropped 3 dangling synonyms", d.summary())
+	})
+
+	t.Run("bare-name", func(t *testing.T) {
+		d := newDanglingSynonyms(sources.OnDanglingBareName, keys)
+		assert.True(t, d.importsAny())
+
+		bare, ok := d.toBareName("s1", "t1")
+		assert.False(t, bare)
+		assert.True(t, ok)
+
+		bare, ok = d.toBareName("s2", "t9")
+		assert.True(t, bare)
+		assert.True(t, ok)
+
+		// Pro parte synonym s3 becomes one bare name.
+		bare, ok = d.toBareName("s3", "t8")
+		assert.True(t, bare)
+		assert.True(t, ok)
+		_, ok = d.toBareName("s3", "t9")
+		assert.False(t, ok)
+
+		assert.Equal(t,
+			"imported 2 dangling synonyms as bare names", d.summary())
+	})
+
+	t.Run("keep", func(t *testing.T) {
+		d := newDanglingSynonyms(sources.OnDanglingKeep, keys[:1])
+		assert.True(t, d.importsAny())
+		bare, ok := d.toBareName("s2", "t9")
+		assert.False(t, bare)
+		assert.True(t, ok)
+		assert.Equal(t, "kept 1 dangling synonyms", d.summary())
+	})
+}
+
+func TestDistinctIDs(t *testing.T) {
+	keys := []recordKey{
+		{id: "s2", acceptedID: "t9"},
+		{id: "s3", acceptedID: "t8"},
+		{id: "s3", acceptedID: "t9"},
+	}
+	assert.Equal(t, []string{"s2", "s3"}, distinctIDs(keys))
+}
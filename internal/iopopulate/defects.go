@@ -0,0 +1,134 @@
+package iopopulate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gnames/gndb/internal/ioreport"
+)
+
+// defectReport is the JSON form of the hierarchy defects of a source.
+// Reports are sent to data providers, so they can fix their data.
+type defectReport struct {
+	DataSourceID int               `json:"data_source_id"`
+	Nodes        int               `json:"nodes"`
+	Affected     int               `json:"affected"`
+	DefectRatio  float64           `json:"defect_ratio"`
+	Defects      []hierarchyDefect `json:"defects"`
+}
+
+// reportHierarchyDefects saves defects of the hierarchy of a source to
+// TSV and JSON files and tells the user about them. With StrictHierarchy
+// it returns HierarchyDefectsError if too many nodes are affected.
+func (p *populator) reportHierarchyDefects(
+	sourceID int,
+	check hierarchyCheck,
+) error {
+	if len(check.defects) == 0 {
+		return nil
+	}
+
+	path, err := p.writeDefectReport(sourceID, check)
+	if err != nil {
+		p.logger().Warn("Cannot save hierarchy defect report",
+			"data_source_id", sourceID,
+			"error", err,
+		)
+	}
+
+	missing := check.count(missingParent)
+	circular := check.count(circularParent)
+	p.logger().Warn("Hierarchy has defects",
+		"data_source_id", sourceID,
+		"missing_parents", missing,
+		"circular_parents", circular,
+		"affected_nodes", check.affected,
+		"report", path,
+	)
+	msg := fmt.Sprintf(
+		"hierarchy defects: %d missing and %d circular parents, "+
+			"%.2f%% of nodes affected",
+		missing, circular, check.ratio()*100,
+	)
+	p.addWarning(msg)
+	p.message("<em>Found %s</em>", msg)
+	if path != "" {
+		p.message("<em>Defect report:</em> %s", path)
+	}
+
+	maxRatio := p.cfg.Populate.MaxHierarchyDefectRatio
+	if p.cfg.Populate.StrictHierarchy && !p.useFlatClassification() &&
+		check.ratio() > maxRatio {
+		return HierarchyDefectsError(sourceID, check.ratio(), maxRatio, path)
+	}
+	return nil
+}
+
+// writeDefectReport writes hierarchy defects of a source to the report
+// directory as hierarchy-<id>-<time>.tsv and .json. Returns the path
+// of the TSV file.
+func (p *populator) writeDefectReport(
+	sourceID int,
+	check hierarchyCheck,
+) (string, error) {
+	dir := ioreport.Dir(p.cfg)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf(
+		"hierarchy-%d-%s", sourceID, time.Now().Format("20060102-150405"),
+	))
+
+	tsv, err := os.Create(base + ".tsv")
+	if err != nil {
+		return "", err
+	}
+	defer tsv.Close()
+	if err = writeDefectsTSV(tsv, check.defects); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(defectReport{
+		DataSourceID: sourceID,
+		Nodes:        check.nodes,
+		Affected:     check.affected,
+		DefectRatio:  check.ratio(),
+		Defects:      check.defects,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err = os.WriteFile(base+".json", data, 0644); err != nil {
+		return "", err
+	}
+
+	return base + ".tsv", nil
+}
+
+// writeDefectsTSV writes defects as tab-separated values with a header.
+func writeDefectsTSV(w io.Writer, defects []hierarchyDefect) error {
+	_, err := fmt.Fprintln(w, "node_id\tparent_id\tdefect\tdescendants")
+	if err != nil {
+		return err
+	}
+	for _, d := range defects {
+		_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%d\n",
+			d.NodeID, d.ParentID, d.Type, d.Descendants)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// useFlatClassification returns true if classification breadcrumbs
+// come from flat classification instead of the hierarchy.
+func (p *populator) useFlatClassification() bool {
+	flat := p.cfg.Populate.WithFlatClassification
+	return flat != nil && *flat
+}
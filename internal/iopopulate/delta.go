@@ -0,0 +1,266 @@
+package iopopulate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/jackc/pgx/v5"
+)
+
+// deltaStats counts name index records changed in the live table by
+// an import. Without --delta all records of a source are removed and
+// added again.
+type deltaStats struct {
+	added   int
+	changed int
+	removed int
+}
+
+// String returns a summary of the changes for the user.
+func (d deltaStats) String() string {
+	return fmt.Sprintf(
+		"added %s, changed %s, removed %s records",
+		humanize.Comma(int64(d.added)),
+		humanize.Comma(int64(d.changed)),
+		humanize.Comma(int64(d.removed)),
+	)
+}
+
+// Columns that identify a name index record of a data source. They
+// are the primary key of name_string_indices without data_source_id.
+var nameIndexKeyColumns = []string{"record_id", "accepted_record_id"}
+
+// Columns of name_string_indices that can change between releases.
+var nameIndexValueColumns = []string{
+	"name_string_id", "outlink_id", "global_id", "name_id", "local_id",
+	"code_id", "rank", "taxonomic_status",
+	"classification", "classification_ids", "classification_ranks",
+	"reference_id",
+}
+
+// Columns that identify a reference of a data source. They are the
+// primary key of source_references without data_source_id.
+var refKeyColumns = []string{"record_id"}
+
+// Columns of source_references that can change between releases.
+var refValueColumns = []string{"citation", "doi", "year", "link"}
+
+// Columns of vernacular_string_indices. The table has no key, so a
+// vernacular record is identified by all of its values.
+var vernIndexColumns = []string{
+	"record_id", "vernacular_string_id", "language_orig", "language",
+	"lang_code", "locality", "country_code", "preferred",
+}
+
+// Columns of vernacular_string_indices that narrow down the search of a
+// vernacular record. The staging table is indexed on them before a
+// merge.
+var vernIndexKeyColumns = []string{"record_id", "vernacular_string_id"}
+
+// mergeNameIndicesDelta compares staged name indices of a source with
+// the live ones by record_id and accepted_record_id. Only records that
+// were removed, changed or added are written to name_string_indices.
+func mergeNameIndicesDelta(
+	ctx context.Context,
+	tx pgx.Tx,
+	sourceID int,
+) (deltaStats, error) {
+	return mergeKeyedDelta(
+		ctx, tx, nameIndicesTable, sourceID,
+		nameIndexKeyColumns, nameIndexValueColumns,
+	)
+}
+
+// mergeReferencesDelta compares staged references of a source with the
+// live ones by record_id. Only references that were removed, changed or
+// added are written to source_references.
+func mergeReferencesDelta(
+	ctx context.Context,
+	tx pgx.Tx,
+	sourceID int,
+) (deltaStats, error) {
+	return mergeKeyedDelta(
+		ctx, tx, refsTable, sourceID, refKeyColumns, refValueColumns,
+	)
+}
+
+// mergeKeyedDelta merges the staging table of a source into the live
+// table. Records are matched by the key columns, live records that are
+// not staged are removed, records with different values are updated and
+// new records are added.
+func mergeKeyedDelta(
+	ctx context.Context,
+	tx pgx.Tx,
+	table string,
+	sourceID int,
+	keyColumns, valueColumns []string,
+) (deltaStats, error) {
+	var res deltaStats
+	err := indexStagingTable(ctx, tx, table, sourceID, keyColumns)
+	if err != nil {
+		return res, err
+	}
+
+	live := pgx.Identifier{table}.Sanitize()
+	staged := stagingName(table, sourceID)
+	keyMatch := columnsMatch("l", "s", keyColumns, "=")
+
+	q := fmt.Sprintf(
+		`DELETE FROM %s l
+		 WHERE l.data_source_id = $1
+		   AND NOT EXISTS (SELECT 1 FROM %s s WHERE %s)`,
+		live, staged, keyMatch,
+	)
+	tag, err := tx.Exec(ctx, q, sourceID)
+	if err != nil {
+		return res, fmt.Errorf("failed to remove deleted records: %w", err)
+	}
+	res.removed = int(tag.RowsAffected())
+
+	sets := make([]string, len(valueColumns))
+	for i, c := range valueColumns {
+		sets[i] = fmt.Sprintf("%s = s.%s", c, c)
+	}
+	q = fmt.Sprintf(
+		`UPDATE %s l SET %s
+		 FROM %s s
+		 WHERE l.data_source_id = $1
+		   AND %s
+		   AND (%s) IS DISTINCT FROM (%s)`,
+		live, strings.Join(sets, ", "),
+		staged,
+		keyMatch,
+		prefixColumns("l", valueColumns),
+		prefixColumns("s", valueColumns),
+	)
+	tag, err = tx.Exec(ctx, q, sourceID)
+	if err != nil {
+		return res, fmt.Errorf("failed to update changed records: %w", err)
+	}
+	res.changed = int(tag.RowsAffected())
+
+	q = fmt.Sprintf(
+		`INSERT INTO %s
+		 SELECT s.* FROM %s s
+		 WHERE NOT EXISTS (
+		   SELECT 1 FROM %s l WHERE l.data_source_id = $1 AND %s
+		 )`,
+		live, staged, live, keyMatch,
+	)
+	tag, err = tx.Exec(ctx, q, sourceID)
+	if err != nil {
+		return res, fmt.Errorf("failed to add new records: %w", err)
+	}
+	res.added = int(tag.RowsAffected())
+
+	return res, nil
+}
+
+// mergeVernacularIndicesDelta removes live vernacular indices of a
+// source that are not staged anymore and adds staged ones that are new.
+// A changed vernacular record counts as removed and added. Records are
+// matched by key columns and compared by a hash of all their columns
+// (see rowHash).
+func mergeVernacularIndicesDelta(
+	ctx context.Context,
+	tx pgx.Tx,
+	sourceID int,
+) (deltaStats, error) {
+	var res deltaStats
+	err := indexStagingTable(
+		ctx, tx, vernIndicesTable, sourceID, vernIndexKeyColumns,
+	)
+	if err != nil {
+		return res, err
+	}
+
+	live := pgx.Identifier{vernIndicesTable}.Sanitize()
+	staged := stagingName(vernIndicesTable, sourceID)
+	rowMatch := fmt.Sprintf("%s AND %s = %s",
+		columnsMatch("l", "s", vernIndexKeyColumns, "="),
+		rowHash("l", vernIndexColumns), rowHash("s", vernIndexColumns))
+
+	q := fmt.Sprintf(
+		`DELETE FROM %s l
+		 WHERE l.data_source_id = $1
+		   AND NOT EXISTS (SELECT 1 FROM %s s WHERE %s)`,
+		live, staged, rowMatch,
+	)
+	tag, err := tx.Exec(ctx, q, sourceID)
+	if err != nil {
+		return res, fmt.Errorf("failed to remove deleted vernaculars: %w", err)
+	}
+	res.removed = int(tag.RowsAffected())
+
+	q = fmt.Sprintf(
+		`INSERT INTO %s
+		 SELECT s.* FROM %s s
+		 WHERE NOT EXISTS (
+		   SELECT 1 FROM %s l WHERE l.data_source_id = $1 AND %s
+		 )`,
+		live, staged, live, rowMatch,
+	)
+	tag, err = tx.Exec(ctx, q, sourceID)
+	if err != nil {
+		return res, fmt.Errorf("failed to add new vernaculars: %w", err)
+	}
+	res.added = int(tag.RowsAffected())
+
+	return res, nil
+}
+
+// indexStagingTable indexes a staging table of a source on the given
+// columns and updates its statistics. Staging tables are loaded without
+// indices, and without them every live record of a merge would scan the
+// whole staging table.
+func indexStagingTable(
+	ctx context.Context,
+	tx pgx.Tx,
+	table string,
+	sourceID int,
+	columns []string,
+) error {
+	staged := stagingName(table, sourceID)
+	stmts := []string{
+		fmt.Sprintf("CREATE INDEX ON %s (%s)",
+			staged, strings.Join(columns, ", ")),
+		"ANALYZE " + staged,
+	}
+	for _, q := range stmts {
+		if _, err := tx.Exec(ctx, q); err != nil {
+			return fmt.Errorf("failed to index staging table of %s: %w",
+				table, err)
+		}
+	}
+	return nil
+}
+
+// rowHash builds an md5 hash of the given columns of a table alias. The
+// text form of a row keeps NULL and empty values apart, so equal hashes
+// mean equal records. Unlike "IS NOT DISTINCT FROM" on every column,
+// comparing hashes with "=" lets PostgreSQL use a hash anti-join.
+func rowHash(alias string, columns []string) string {
+	return fmt.Sprintf("md5(ROW(%s)::text)", prefixColumns(alias, columns))
+}
+
+// columnsMatch builds a condition that compares the given columns of two
+// table aliases with the operator op, e.g. "l.a = s.a AND l.b = s.b".
+func columnsMatch(left, right string, columns []string, op string) string {
+	res := make([]string, len(columns))
+	for i, c := range columns {
+		res[i] = fmt.Sprintf("%s.%s %s %s.%s", left, c, op, right, c)
+	}
+	return strings.Join(res, " AND ")
+}
+
+// prefixColumns returns a comma-separated list of columns qualified
+// with a table alias, e.g. "l.a, l.b".
+func prefixColumns(alias string, columns []string) string {
+	res := make([]string, len(columns))
+	for i, c := range columns {
+		res[i] = alias + "." + c
+	}
+	return strings.Join(res, ", ")
+}
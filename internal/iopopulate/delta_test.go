@@ -0,0 +1,32 @@
+package iopopulate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColumnsMatch(t *testing.T) {
+	assert.Equal(t,
+		"l.a = s.a AND l.b = s.b",
+		columnsMatch("l", "s", []string{"a", "b"}, "="),
+	)
+	assert.Equal(t,
+		"l.a IS NOT DISTINCT FROM s.a",
+		columnsMatch("l", "s", []string{"a"}, "IS NOT DISTINCT FROM"),
+	)
+}
+
+func TestPrefixColumns(t *testing.T) {
+	assert.Equal(t, "s.a, s.b", prefixColumns("s", []string{"a", "b"}))
+	assert.Equal(t, "", prefixColumns("s", nil))
+}
+
+func TestRowHash(t *testing.T) {
+	assert.Equal(t, "md5(ROW(l.a, l.b)::text)", rowHash("l", []string{"a", "b"}))
+}
+
+func TestDeltaStatsString(t *testing.T) {
+	d := deltaStats{added: 1200, changed: 3, removed: 0}
+	assert.Equal(t, "added 1,200, changed 3, removed 0 records", d.String())
+}
@@ -0,0 +1,320 @@
+package iopopulate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gnames/gndb/pkg/gndb"
+	"github.com/gnames/gndb/pkg/sources"
+	"github.com/gnames/gnfmt"
+)
+
+// preflight is the result of a dry-run check of one data source.
+type preflight struct {
+	source sources.DataSourceConfig
+
+	// file is the name of the resolved SFGA file.
+	file string
+
+	// Expected number of records.
+	names       int
+	taxa        int
+	synonyms    int
+	bare        int
+	vernaculars int
+	references  int
+
+	// hierarchy describes problems of the classification hierarchy.
+	hierarchy hierarchyCheck
+
+	// warnings are problems that do not stop an import.
+	warnings []string
+
+	// err is set if the source cannot be imported.
+	err error
+}
+
+// dryRun checks selected sources without writing to PostgreSQL. Every
+// source goes through SFGA resolution, fetching and version check, then
+// its records are counted and the hierarchy is checked. The result is
+// printed as a table with expected record counts and warnings.
+func (p *populator) dryRun(
+	ctx context.Context,
+	sourcesToProcess []sources.DataSourceConfig,
+) error {
+	startTime := time.Now()
+	p.info("Dry run: checking sources, nothing is written to the database")
+
+	res := make([]preflight, len(sourcesToProcess))
+	var failed int
+	for i, source := range sourcesToProcess {
+		if err := ctx.Err(); err != nil {
+			return CancelledError(err)
+		}
+		p.info("Checking Data Source [%d]: %s", source.ID, source.TitleShort)
+		res[i] = p.preflightSource(ctx, source)
+		if res[i].err != nil {
+			failed++
+			p.logger().Error("Source failed preflight check",
+				"data_source_id", source.ID,
+				"error", res[i].err,
+			)
+			continue
+		}
+		p.logger().Info("Source passed preflight check",
+			"data_source_id", source.ID,
+			"file", res[i].file,
+			"names", res[i].names,
+			"warnings", len(res[i].warnings),
+		)
+	}
+
+	var table strings.Builder
+	printPreflight(&table, res)
+	p.message("\n%s", table.String())
+
+	p.emit(gndb.Event{
+		Type:        gndb.EventSummary,
+		Count:       len(res) - failed,
+		Total:       len(res),
+		DurationSec: time.Since(startTime).Seconds(),
+		Message: fmt.Sprintf(
+			"Dry run complete, sources checked: %d, failed: %d. "+
+				"Elapsed time: <em>%s</em>",
+			len(res), failed,
+			gnfmt.TimeString(time.Since(startTime).Seconds()),
+		),
+	})
+
+	if failed > 0 {
+		return PreflightError(failed)
+	}
+	return nil
+}
+
+// preflightSource fetches the SFGA file of a source and collects its
+// expected record counts and warnings.
+func (p *populator) preflightSource(
+	ctx context.Context,
+	source sources.DataSourceConfig,
+) preflight {
+	res := preflight{source: source}
+
+	sfgaPath, metadata, warning, err := p.resolveSFGAPath(ctx, source)
+	if err != nil {
+		res.err = sfgaPathError(source, err)
+		return res
+	}
+	res.file = filepath.Base(sfgaPath)
+	if warning != "" {
+		res.warnings = append(res.warnings, warning)
+	}
+
+	cacheDir, err := prepareCacheDir(p.cfg.HomeDir)
+	if err != nil {
+		res.err = CacheError("prepare cache directory", err)
+		return res
+	}
+
+	sqlitePath, err := p.fetchSFGA(ctx, source, sfgaPath, metadata.SHA256, cacheDir)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	p.sfgaDB, err = openSFGA(sqlitePath)
+	if err != nil {
+		res.err = SFGAReadError(sqlitePath, err)
+		return res
+	}
+	defer p.sfgaDB.Close()
+
+	if res.err = p.checkSfgaVersion(ctx, source.ID); res.err != nil {
+		return res
+	}
+
+	if res.err = p.countRecords(ctx, &res); res.err != nil {
+		return res
+	}
+
+	hierarchy, err := p.buildHierarchy(ctx)
+	if err != nil {
+		res.warnings = append(res.warnings,
+			fmt.Sprintf("cannot build hierarchy: %s", err))
+		return res
+	}
+	res.hierarchy = checkHierarchy(hierarchy)
+	if len(res.hierarchy.defects) == 0 {
+		return res
+	}
+
+	res.warnings = append(res.warnings, fmt.Sprintf(
+		"hierarchy has %s missing and %s circular parents, "+
+			"%.2f%% of nodes affected",
+		humanize.Comma(int64(res.hierarchy.count(missingParent))),
+		humanize.Comma(int64(res.hierarchy.count(circularParent))),
+		res.hierarchy.ratio()*100,
+	))
+	path, err := p.writeDefectReport(source.ID, res.hierarchy)
+	if err != nil {
+		res.warnings = append(res.warnings,
+			fmt.Sprintf("cannot save hierarchy defect report: %s", err))
+	} else {
+		res.warnings = append(res.warnings,
+			fmt.Sprintf("hierarchy defect report: %s", path))
+	}
+
+	maxRatio := p.cfg.Populate.MaxHierarchyDefectRatio
+	if p.cfg.Populate.StrictHierarchy && !source.PreferFlatClassification &&
+		!p.useFlatClassification() && res.hierarchy.ratio() > maxRatio {
+		res.err = HierarchyDefectsError(
+			source.ID, res.hierarchy.ratio(), maxRatio, path,
+		)
+	}
+
+	return res
+}
+
+// countRecords fills expected record counts of a source and adds
+// warnings about names and taxa.
+func (p *populator) countRecords(ctx context.Context, res *preflight) error {
+	var empty int
+	var err error
+
+	res.names, empty, err = p.countNames(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count names: %w", err)
+	}
+	if empty > 0 {
+		policy := p.cfg.Populate.OnEmptyNameString
+		if policy == "" {
+			policy = res.source.OnEmptyNameString
+		}
+		if policy == "" {
+			policy = "ask"
+		}
+		res.warnings = append(res.warnings, fmt.Sprintf(
+			"%s names have empty gn__scientific_name_string "+
+				"(on_empty_name_string: %s)",
+			humanize.Comma(int64(empty)), policy,
+		))
+	}
+
+	dups, err := p.findDuplicateRecordIDs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(dups) > 0 {
+		policy := p.cfg.Populate.OnDuplicateRecordID
+		if policy == "" {
+			policy = res.source.OnDuplicateRecordID
+		}
+		if policy == "" {
+			policy = "ask"
+		}
+		res.warnings = append(res.warnings, fmt.Sprintf(
+			"%s record IDs are not unique (on_duplicate_record_id: %s)",
+			humanize.Comma(int64(len(dups))), policy,
+		))
+	}
+
+	dangling, err := p.findDanglingSynonyms(ctx)
+	if err != nil {
+		return err
+	}
+	if len(dangling) > 0 {
+		policy := p.cfg.Populate.OnDanglingSynonym
+		if policy == "" {
+			policy = res.source.OnDanglingSynonym
+		}
+		if policy == "" {
+			policy = "ask"
+		}
+		res.warnings = append(res.warnings, fmt.Sprintf(
+			"%s synonyms have no accepted taxon (on_dangling_synonym: %s)",
+			humanize.Comma(int64(len(dangling))), policy,
+		))
+	}
+
+	if res.taxa, err = p.getTotalCount(ctx); err != nil {
+		return err
+	}
+
+	if res.synonyms, err = p.getTotalSynonymCount(ctx); err != nil {
+		return err
+	}
+
+	if res.bare, err = p.getTotalBareCount(ctx); err != nil {
+		return fmt.Errorf("failed to count bare names: %w", err)
+	}
+
+	// Vernaculars are optional, as in a real import.
+	if res.vernaculars, err = p.getTotalVernacularCount(ctx); err != nil {
+		res.warnings = append(res.warnings, err.Error())
+	}
+
+	// References are optional too.
+	if res.references, err = p.getTotalReferenceCount(ctx); err != nil {
+		res.warnings = append(res.warnings, err.Error())
+	}
+
+	if res.taxa == 0 && res.synonyms == 0 {
+		res.warnings = append(res.warnings,
+			"no taxa or synonyms, all names are imported as bare names")
+	}
+
+	if !res.source.Filters.IsEmpty() {
+		res.warnings = append(res.warnings,
+			"source has filters, counts include records they exclude")
+	}
+	return nil
+}
+
+// printPreflight shows dry-run results as a table, followed by warnings
+// and errors of every source.
+func printPreflight(out io.Writer, res []preflight) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w,
+		"ID\tNames\tTaxa\tSynonyms\tBare\tVernaculars\tReferences\tHierarchy\tStatus\t")
+	for _, r := range res {
+		status := "ok"
+		switch {
+		case r.err != nil:
+			status = "FAILED"
+		case len(r.warnings) == 1:
+			status = "1 warning"
+		case len(r.warnings) > 1:
+			status = fmt.Sprintf("%d warnings", len(r.warnings))
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			r.source.ID,
+			humanize.Comma(int64(r.names)),
+			humanize.Comma(int64(r.taxa)),
+			humanize.Comma(int64(r.synonyms)),
+			humanize.Comma(int64(r.bare)),
+			humanize.Comma(int64(r.vernaculars)),
+			humanize.Comma(int64(r.references)),
+			humanize.Comma(int64(r.hierarchy.nodes)),
+			status,
+		)
+	}
+	w.Flush()
+	fmt.Fprintln(out)
+
+	for _, r := range res {
+		if r.err != nil {
+			fmt.Fprintf(out, "[%d] %s: error: %s\n",
+				r.source.ID, r.source.TitleShort, r.err)
+		}
+		for _, warning := range r.warnings {
+			fmt.Fprintf(out, "[%d] %s: %s\n",
+				r.source.ID, r.source.TitleShort, warning)
+		}
+	}
+}
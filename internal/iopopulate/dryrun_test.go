@@ -0,0 +1,51 @@
+package iopopulate
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gnames/gndb/pkg/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintPreflight(t *testing.T) {
+	res := []preflight{
+		{
+			source:      sources.DataSourceConfig{ID: 1, TitleShort: "CoL"},
+			names:       1500,
+			taxa:        1000,
+			synonyms:    400,
+			bare:        100,
+			vernaculars: 20,
+			references:  7,
+			hierarchy:   hierarchyCheck{nodes: 1000},
+		},
+		{
+			source:   sources.DataSourceConfig{ID: 2, TitleShort: "Bad"},
+			warnings: []string{"3 parent IDs of the hierarchy do not exist"},
+		},
+		{
+			source: sources.DataSourceConfig{ID: 3, TitleShort: "Gone"},
+			err:    errors.New("file not found"),
+		},
+	}
+
+	var buf bytes.Buffer
+	printPreflight(&buf, res)
+	out := buf.String()
+	lines := strings.Split(out, "\n")
+	require.Greater(t, len(lines), 4)
+
+	assert.Contains(t, lines[0], "Vernaculars")
+	assert.Contains(t, lines[0], "References")
+	assert.Contains(t, lines[1], "1,500")
+	assert.Contains(t, lines[1], "ok")
+	assert.Contains(t, lines[2], "1 warning")
+	assert.Contains(t, lines[3], "FAILED")
+	assert.Contains(t, out,
+		"[2] Bad: 3 parent IDs of the hierarchy do not exist")
+	assert.Contains(t, out, "[3] Gone: error: file not found")
+}
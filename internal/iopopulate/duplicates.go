@@ -0,0 +1,225 @@
+package iopopulate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gnames/gndb/pkg/report"
+	"github.com/gnames/gndb/pkg/sources"
+)
+
+// proParteIDsQuery finds IDs of pro parte synonyms. Such a synonym has
+// several rows in the synonym table with the same ID and different
+// accepted taxa.
+const proParteIDsQuery = `
+	SELECT col__id
+	FROM synonym
+	WHERE COALESCE(col__id,'') <> ''
+	GROUP BY col__id
+	HAVING COUNT(DISTINCT COALESCE(col__taxon_id,'')) > 1`
+
+// synonymRecordID is the SQL expression for record IDs of synonyms. Name
+// indices of a pro parte synonym share its ID as the record ID and differ
+// by accepted_record_id. Other synonyms keep record IDs of earlier
+// versions, `id|taxon_id|name_id`, so --delta imports do not rewrite
+// them.
+const synonymRecordID = `CASE
+	WHEN s.col__id IN (` + proParteIDsQuery + `) THEN s.col__id
+	ELSE COALESCE(s.col__id,'') || '|' || COALESCE(s.col__taxon_id,'') ||
+		'|' || n.col__id
+	END`
+
+// duplicateIDsQuery finds record IDs that occur more than once with the
+// same accepted record ID among name indices of a source. Record IDs are
+// built the same way as in processTaxa, processSynonyms and
+// processBareNames, because all of them go to the same table.
+const duplicateIDsQuery = `
+	SELECT DISTINCT record_id
+	FROM (
+		SELECT record_id
+		FROM (
+			SELECT t.col__id AS record_id, t.col__id AS accepted_record_id
+			FROM taxon t
+			JOIN name n ON n.col__id = t.col__name_id
+
+			UNION ALL
+
+			SELECT ` + synonymRecordID + `, s.col__taxon_id
+			FROM synonym s
+			JOIN name n ON n.col__id = s.col__name_id
+			JOIN taxon t ON t.col__id = s.col__taxon_id
+
+			UNION ALL
+
+			SELECT 'bare-name-' || name.col__id, 'bare-name-' || name.col__id
+			FROM name
+			WHERE name.col__id NOT IN (
+				SELECT col__name_id FROM taxon
+				UNION
+				SELECT col__name_id FROM synonym
+			)
+		)
+		GROUP BY record_id, accepted_record_id
+		HAVING COUNT(*) > 1
+	)
+	ORDER BY record_id
+`
+
+// findDuplicateRecordIDs returns sorted record IDs that occur more than
+// once in name indices of the SFGA file.
+func (p *populator) findDuplicateRecordIDs(
+	ctx context.Context,
+) ([]string, error) {
+	rows, err := p.sfgaDB.QueryContext(ctx, duplicateIDsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duplicate record IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan duplicate record ID: %w", err)
+		}
+		res = append(res, id)
+	}
+	return res, rows.Err()
+}
+
+// handleDuplicateRecordIDs looks for duplicate record IDs before name
+// indices are written and decides what to do with them according to
+// the on_duplicate_record_id policy (see ask). All duplicate IDs go to
+// the run report. Returns nil if the source has no duplicates, and
+// DuplicateRecordIDsError if the policy is "fail".
+func (p *populator) handleDuplicateRecordIDs(
+	ctx context.Context,
+	source *sources.DataSourceConfig,
+) (*recordIDs, error) {
+	dups, err := p.findDuplicateRecordIDs(ctx)
+	if err != nil {
+		return nil, NamesError(source.ID, err)
+	}
+	if len(dups) == 0 {
+		return nil, nil
+	}
+
+	q := duplicateIDQuestion(humanize.Comma(int64(len(dups))))
+	response, err := p.ask(
+		source.ID, q,
+		p.cfg.Populate.OnDuplicateRecordID, source.OnDuplicateRecordID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger().Warn("Duplicate record IDs",
+		"data_source_id", source.ID,
+		"count", len(dups),
+		"policy", response,
+	)
+	p.addWarning(fmt.Sprintf(
+		"%s record IDs are not unique, on_duplicate_record_id: %s",
+		humanize.Comma(int64(len(dups))), response,
+	))
+	if p.srcReport != nil {
+		p.srcReport.Duplicates = &report.Duplicates{
+			Policy:    response,
+			RecordIDs: dups,
+		}
+	}
+
+	if response == sources.OnDuplicateFail {
+		return nil, DuplicateRecordIDsError(source.ID, dups)
+	}
+	return newRecordIDs(response, dups), nil
+}
+
+// recordIDs resolves duplicate record IDs while name indices of a source
+// are written. The same instance is used for taxa, synonyms and bare
+// names. A nil *recordIDs keeps all IDs as they are.
+type recordIDs struct {
+	policy string
+
+	// dups are record IDs that have duplicates.
+	dups map[string]struct{}
+
+	// seen counts records written so far for every duplicate ID and
+	// accepted ID.
+	seen map[recordKey]int
+
+	// dropped is the number of records removed by "keep-first".
+	dropped int
+
+	// renamed is the number of records changed by "suffix".
+	renamed int
+}
+
+// recordKey identifies a name index of a source. Pro parte synonyms have
+// several name indices with the same record ID.
+type recordKey struct {
+	id, acceptedID string
+}
+
+// newRecordIDs creates recordIDs for duplicate IDs of a source.
+func newRecordIDs(policy string, dups []string) *recordIDs {
+	res := &recordIDs{
+		policy: policy,
+		dups:   make(map[string]struct{}, len(dups)),
+		seen:   make(map[recordKey]int, len(dups)),
+	}
+	for _, id := range dups {
+		res.dups[id] = struct{}{}
+	}
+	return res
+}
+
+// resolve returns the record ID to write for a record with the given
+// accepted ID, and false if the record must not be written. Records
+// with the same ID and different accepted IDs are pro parte synonyms and
+// are not duplicates. The first record of a duplicate always keeps its
+// ID. Following ones are dropped ("keep-first") or get a "-dupN" suffix,
+// where N is the number of the record with this ID ("suffix").
+func (r *recordIDs) resolve(id, acceptedID string) (string, bool) {
+	if r == nil {
+		return id, true
+	}
+	if _, ok := r.dups[id]; !ok {
+		return id, true
+	}
+	key := recordKey{id: id, acceptedID: acceptedID}
+	n := r.seen[key] + 1
+	r.seen[key] = n
+	if n == 1 {
+		return id, true
+	}
+
+	if r.policy == sources.OnDuplicateKeepFirst {
+		r.dropped++
+		return "", false
+	}
+	r.renamed++
+	return fmt.Sprintf("%s-dup%d", id, n), true
+}
+
+// summary describes what happened to duplicate records, it is empty if
+// nothing was changed.
+func (r *recordIDs) summary() string {
+	switch {
+	case r == nil:
+		return ""
+	case r.dropped > 0:
+		return fmt.Sprintf(
+			"dropped %s records with duplicate IDs",
+			humanize.Comma(int64(r.dropped)),
+		)
+	case r.renamed > 0:
+		return fmt.Sprintf(
+			"renamed %s records with duplicate IDs",
+			humanize.Comma(int64(r.renamed)),
+		)
+	default:
+		return ""
+	}
+}
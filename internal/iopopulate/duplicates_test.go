@@ -0,0 +1,92 @@
+package iopopulate
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/gnames/gndb/pkg/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicateRecordIDs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses SQLite in short mode")
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE name (col__id TEXT);
+		CREATE TABLE taxon (col__id TEXT, col__name_id TEXT);
+		CREATE TABLE synonym (
+			col__id TEXT, col__taxon_id TEXT, col__name_id TEXT
+		);
+		INSERT INTO name VALUES
+			('n1'), ('n2'), ('n3'), ('n4'), ('n5'), ('n5'), ('n6'), ('n7');
+		INSERT INTO taxon VALUES
+			('t1', 'n1'), ('t1', 'n2'), ('t2', 'n3'), ('t3', 'n7');
+		INSERT INTO synonym VALUES
+			('s1', 't2', 'n4'), ('s1', 't2', 'n4'),
+			('s2', 't2', 'n6'), ('s2', 't3', 'n6');
+	`)
+	require.NoError(t, err)
+
+	p := &populator{sfgaDB: db}
+	dups, err := p.findDuplicateRecordIDs(context.Background())
+	require.NoError(t, err)
+	// Pro parte synonym s2 has two accepted taxa and is not a duplicate.
+	assert.Equal(t, []string{"bare-name-n5", "s1|t2|n4", "t1"}, dups)
+}
+
+func TestRecordIDsResolve(t *testing.T) {
+	dups := []string{"t1", "t2"}
+
+	t.Run("no duplicates", func(t *testing.T) {
+		var ids *recordIDs
+		id, ok := ids.resolve("t1", "t1")
+		assert.True(t, ok)
+		assert.Equal(t, "t1", id)
+		assert.Empty(t, ids.summary())
+	})
+
+	t.Run("keep-first", func(t *testing.T) {
+		ids := newRecordIDs(sources.OnDuplicateKeepFirst, dups)
+		var kept []string
+		for _, in := range []string{"t1", "t3", "t1", "t2", "t1"} {
+			if id, ok := ids.resolve(in, in); ok {
+				kept = append(kept, id)
+			}
+		}
+		assert.Equal(t, []string{"t1", "t3", "t2"}, kept)
+		assert.Equal(t, "dropped 2 records with duplicate IDs", ids.summary())
+	})
+
+	t.Run("suffix", func(t *testing.T) {
+		ids := newRecordIDs(sources.OnDuplicateSuffix, dups)
+		var res []string
+		for _, in := range []string{"t1", "t2", "t1", "t2", "t1"} {
+			id, ok := ids.resolve(in, in)
+			require.True(t, ok)
+			res = append(res, id)
+		}
+		assert.Equal(t,
+			[]string{"t1", "t2", "t1-dup2", "t2-dup2", "t1-dup3"}, res)
+		assert.Equal(t, "renamed 3 records with duplicate IDs", ids.summary())
+	})
+
+	t.Run("pro parte", func(t *testing.T) {
+		ids := newRecordIDs(sources.OnDuplicateSuffix, []string{"s1"})
+		var res []string
+		for _, acc := range []string{"t1", "t2", "t1"} {
+			id, ok := ids.resolve("s1", acc)
+			require.True(t, ok)
+			res = append(res, id)
+		}
+		assert.Equal(t, []string{"s1", "s1", "s1-dup2"}, res)
+		assert.Equal(t, "renamed 1 records with duplicate IDs", ids.summary())
+	})
+}
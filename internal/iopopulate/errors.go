@@ -2,6 +2,7 @@ package iopopulate
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/gnames/gn"
 	"github.com/gnames/gndb/pkg/config"
@@ -76,6 +77,51 @@ func SFGAFileNotFoundError(
 	}
 }
 
+// ReleaseNotFoundError creates an error for when the pinned release of
+// a data source has no SFGA file.
+func ReleaseNotFoundError(sourceID int, release string, err error) error {
+	msg := `Pinned release of data source <em>%d</em> not found
+
+<em>Release:</em> %s
+
+<em>How to fix:</em>
+  1. Check <em>release_date</em> and <em>version</em> of the source in sources.yaml,
+     or <em>--release-date</em> and <em>--release-version</em> flags
+  2. Make sure the file of this release exists at the parent location
+  3. Remove the pin to import the latest release`
+
+	vars := []any{sourceID, release}
+
+	return &gn.Error{
+		Code: errcode.PopulateReleaseNotFoundError,
+		Msg:  msg,
+		Vars: vars,
+		Err:  fmt.Errorf("release %s of source %d: %w", release, sourceID, err),
+	}
+}
+
+// FileSourceError creates an error for when a file given with
+// --file cannot be imported as a data source.
+func FileSourceError(sourceID int, path string, err error) error {
+	msg := `Cannot import file as data source <em>%d</em>
+
+<em>File:</em> %s
+
+<em>How to fix:</em>
+  1. Check that the file exists and is an SFGA file
+  2. Check <em>--id</em>, <em>--title</em> and <em>--outlink-*</em> flags
+  3. Or add the source to custom_sources.yaml`
+
+	vars := []any{sourceID, path}
+
+	return &gn.Error{
+		Code: errcode.PopulateFileSourceError,
+		Msg:  msg,
+		Vars: vars,
+		Err:  fmt.Errorf("file %s of source %d: %w", path, sourceID, err),
+	}
+}
+
 // SFGAReadError creates an error for when SFGA file
 // cannot be read.
 func SFGAReadError(path string, err error) error {
@@ -202,10 +248,164 @@ func CacheError(operation string, err error) error {
 	}
 }
 
+// CheckpointError creates an error for failures to read or write
+// the populate state file used by --resume.
+func CheckpointError(operation string, err error) error {
+	msg := `Cannot %s
+
+<em>How to fix:</em>
+  1. Check permissions of the cache directory
+  2. Run populate without <em>--resume</em> to start over`
+	vars := []any{operation}
+
+	return &gn.Error{
+		Code: errcode.PopulateCheckpointError,
+		Msg:  msg,
+		Vars: vars,
+		Err:  fmt.Errorf("checkpoint operation failed: %w", err),
+	}
+}
+
+// AbortedError creates an error for when a populate policy
+// stopped the run.
+func AbortedError() error {
+	msg := `Population was aborted by policy
+
+<em>How to fix:</em>
+  1. Check "Policies applied" above for the source that stopped the run
+  2. Change <em>--on-empty-name-string</em> or the source settings
+     in sources.yaml`
+
+	return &gn.Error{
+		Code: errcode.PopulateAbortedError,
+		Msg:  msg,
+		Vars: nil,
+		Err:  fmt.Errorf("populate run aborted by policy"),
+	}
+}
+
+// PreflightError creates an error for when some sources did not
+// pass the checks of a dry run.
+func PreflightError(count int) error {
+	msg := `Sources failed dry run checks: <em>%d</em>
+
+<em>How to fix:</em>
+  1. Check errors listed for these sources above
+  2. Fix their settings in sources.yaml and run the dry run again`
+
+	vars := []any{count}
+
+	return &gn.Error{
+		Code: errcode.PopulatePreflightError,
+		Msg:  msg,
+		Vars: vars,
+		Err:  fmt.Errorf("%d sources failed dry run checks", count),
+	}
+}
+
+// ConvertError creates an error for when a DwC-A or CoLDP archive
+// cannot be converted to SFGA.
+func ConvertError(sourceID int, format, archive string, err error) error {
+	msg := `Cannot convert %s archive of data source <em>%d</em> to SFGA
+
+<em>Archive:</em> %s
+
+<em>How to fix:</em>
+  1. Check that the archive is a valid %s file
+  2. Set the correct <em>format</em> of the source in sources.yaml`
+
+	vars := []any{format, sourceID, archive, format}
+
+	return &gn.Error{
+		Code: errcode.PopulateConvertError,
+		Msg:  msg,
+		Vars: vars,
+		Err: fmt.Errorf(
+			"cannot convert %s archive %s to SFGA: %w", format, archive, err,
+		),
+	}
+}
+
+// DuplicateRecordIDsError creates an error for when name indices of
+// a source have duplicate record IDs and the policy is "fail".
+func DuplicateRecordIDsError(sourceID int, ids []string) error {
+	msg := `Data source <em>%d</em> has <em>%d</em> duplicate record IDs
+
+<em>Examples:</em> %s
+
+<em>How to fix:</em>
+  1. Send the list of IDs from the run report to the data provider
+  2. Set <em>on_duplicate_record_id</em> of the source in sources.yaml
+     or use <em>--on-duplicate-record-id keep-first|suffix</em>`
+
+	examples := strings.Join(ids[:min(len(ids), 5)], ", ")
+	vars := []any{sourceID, len(ids), examples}
+
+	return &gn.Error{
+		Code: errcode.PopulateDuplicateRecordIDsError,
+		Msg:  msg,
+		Vars: vars,
+		Err: fmt.Errorf(
+			"data source %d has %d duplicate record IDs", sourceID, len(ids),
+		),
+	}
+}
+
+// FilterError creates an error for when filters of a source cannot be
+// applied to its SFGA file.
+func FilterError(sourceID int, err error) error {
+	msg := `Cannot apply filters of data source <em>%d</em>
+
+<em>How to fix:</em>
+  1. Check the <em>filters</em> block of the source in sources.yaml
+  2. Make sure the SFGA file has taxon and name tables
+  3. Remove the filters to import the whole source`
+
+	vars := []any{sourceID}
+
+	return &gn.Error{
+		Code: errcode.PopulateFilterError,
+		Msg:  msg,
+		Vars: vars,
+		Err:  fmt.Errorf("cannot apply filters of source %d: %w", sourceID, err),
+	}
+}
+
+// HierarchyDefectsError creates an error for when too many nodes of
+// a source hierarchy have missing or circular parents.
+func HierarchyDefectsError(
+	sourceID int,
+	ratio, maxRatio float64,
+	reportPath string,
+) error {
+	msg := `Hierarchy of data source <em>%d</em> has too many defects
+
+<em>Nodes with broken parent chains:</em> %.2f%% (allowed %.2f%%)
+<em>Defect report:</em> %s
+
+<em>How to fix:</em>
+  1. Send the defect report to the data provider
+  2. Increase <em>--max-hierarchy-defect-ratio</em>
+  3. Run populate without <em>--strict-hierarchy</em>`
+
+	vars := []any{sourceID, ratio * 100, maxRatio * 100, reportPath}
+
+	return &gn.Error{
+		Code: errcode.PopulateHierarchyError,
+		Msg:  msg,
+		Vars: vars,
+		Err: fmt.Errorf(
+			"hierarchy defect ratio %.4f is greater than %.4f",
+			ratio, maxRatio,
+		),
+	}
+}
+
 // CancelledError creates an error for when populate
-// operation is cancelled.
+// operation is cancelled, for example by Ctrl-C.
 func CancelledError(err error) error {
-	msg := "Population operation was cancelled"
+	msg := `Population operation was cancelled
+   Run <em>'gndb populate --resume'</em> to continue.`
 
 	return &gn.Error{
 		Code: errcode.UnknownError,
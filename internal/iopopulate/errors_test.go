@@ -2,6 +2,7 @@ package iopopulate
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/gnames/gn"
@@ -99,6 +100,52 @@ func TestAllSourcesFailedError(t *testing.T) {
 	assert.Contains(t, gnErr.Err.Error(), "5 sources")
 }
 
+// TestAbortedError verifies error structure.
+func TestAbortedError(t *testing.T) {
+	err := AbortedError()
+
+	require.NotNil(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok, "Error should be of type *gn.Error")
+
+	assert.Equal(t, errcode.PopulateAbortedError, gnErr.Code)
+	assert.NotEmpty(t, gnErr.Msg)
+	assert.Contains(t, gnErr.Err.Error(), "aborted")
+}
+
+// TestPreflightError verifies error structure.
+func TestPreflightError(t *testing.T) {
+	err := PreflightError(2)
+
+	require.NotNil(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok, "Error should be of type *gn.Error")
+
+	assert.Equal(t, errcode.PopulatePreflightError, gnErr.Code)
+	assert.NotEmpty(t, gnErr.Msg)
+	assert.Equal(t, []any{2}, gnErr.Vars)
+	assert.Contains(t, gnErr.Err.Error(), "2 sources")
+}
+
+// TestHierarchyDefectsError verifies error structure.
+func TestHierarchyDefectsError(t *testing.T) {
+	err := HierarchyDefectsError(3, 0.1, 0.05, "/tmp/hierarchy-3.tsv")
+
+	require.NotNil(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok, "Error should be of type *gn.Error")
+
+	assert.Equal(t, errcode.PopulateHierarchyError, gnErr.Code)
+	assert.NotEmpty(t, gnErr.Msg)
+	require.Len(t, gnErr.Vars, 4)
+	assert.Equal(t, 3, gnErr.Vars[0])
+	assert.Equal(t, "/tmp/hierarchy-3.tsv", gnErr.Vars[3])
+	assert.Contains(t, gnErr.Err.Error(), "0.1000")
+}
+
 // TestMetadataError verifies error structure.
 func TestMetadataError(t *testing.T) {
 	sourceID := 10
@@ -156,6 +203,102 @@ func TestCacheError(t *testing.T) {
 	assert.ErrorIs(t, gnErr.Err, originalErr)
 }
 
+// TestConvertError verifies error structure.
+func TestConvertError(t *testing.T) {
+	sourceID := 5
+	archive := "/tmp/0005-ipni.zip"
+	originalErr := errors.New("meta.xml not found in DwC-A archive")
+
+	err := ConvertError(sourceID, "coldp", archive, originalErr)
+
+	require.NotNil(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok, "Error should be of type *gn.Error")
+
+	assert.Equal(t, errcode.PopulateConvertError, gnErr.Code)
+	assert.NotEmpty(t, gnErr.Msg)
+	require.Len(t, gnErr.Vars, 4)
+	assert.Equal(t, "coldp", gnErr.Vars[0])
+	assert.Equal(t, sourceID, gnErr.Vars[1])
+	assert.Equal(t, archive, gnErr.Vars[2])
+	assert.ErrorIs(t, gnErr.Err, originalErr)
+}
+
+// TestDuplicateRecordIDsError verifies error structure.
+func TestDuplicateRecordIDsError(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e", "f"}
+
+	err := DuplicateRecordIDsError(3, ids)
+
+	require.NotNil(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok, "Error should be of type *gn.Error")
+
+	assert.Equal(t, errcode.PopulateDuplicateRecordIDsError, gnErr.Code)
+	assert.NotEmpty(t, gnErr.Msg)
+	require.Len(t, gnErr.Vars, 3)
+	assert.Equal(t, 3, gnErr.Vars[0])
+	assert.Equal(t, 6, gnErr.Vars[1])
+	assert.Equal(t, "a, b, c, d, e", gnErr.Vars[2])
+}
+
+// TestFilterError verifies error structure.
+func TestFilterError(t *testing.T) {
+	originalErr := errors.New("no such table: taxon")
+
+	err := FilterError(7, originalErr)
+
+	require.NotNil(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok, "Error should be of type *gn.Error")
+
+	assert.Equal(t, errcode.PopulateFilterError, gnErr.Code)
+	assert.NotEmpty(t, gnErr.Msg)
+	require.Len(t, gnErr.Vars, 1)
+	assert.Equal(t, 7, gnErr.Vars[0])
+	assert.ErrorIs(t, gnErr.Err, originalErr)
+}
+
+// TestReleaseNotFoundError verifies error structure.
+func TestReleaseNotFoundError(t *testing.T) {
+	originalErr := fmt.Errorf("%w: version v2", errReleaseNotFound)
+
+	err := ReleaseNotFoundError(1, "version v2", originalErr)
+
+	require.NotNil(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok, "Error should be of type *gn.Error")
+
+	assert.Equal(t, errcode.PopulateReleaseNotFoundError, gnErr.Code)
+	assert.NotEmpty(t, gnErr.Msg)
+	require.Len(t, gnErr.Vars, 2)
+	assert.Equal(t, 1, gnErr.Vars[0])
+	assert.Equal(t, "version v2", gnErr.Vars[1])
+	assert.ErrorIs(t, gnErr.Err, errReleaseNotFound)
+}
+
+func TestFileSourceError(t *testing.T) {
+	originalErr := errors.New("no such file")
+
+	err := FileSourceError(1500, "/data/1500.sqlite", originalErr)
+
+	require.NotNil(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok, "Error should be of type *gn.Error")
+
+	assert.Equal(t, errcode.PopulateFileSourceError, gnErr.Code)
+	assert.NotEmpty(t, gnErr.Msg)
+	require.Len(t, gnErr.Vars, 2)
+	assert.Equal(t, 1500, gnErr.Vars[0])
+	assert.Equal(t, "/data/1500.sqlite", gnErr.Vars[1])
+	assert.ErrorIs(t, gnErr.Err, originalErr)
+}
+
 // TestCancelledError verifies error structure.
 func TestCancelledError(t *testing.T) {
 	originalErr := errors.New("context cancelled")
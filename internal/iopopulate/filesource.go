@@ -0,0 +1,109 @@
+package iopopulate
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/gnames/gndb/internal/iosources"
+	"github.com/gnames/gndb/pkg/sources"
+)
+
+// fileSource builds a transient data source from the file given with
+// --file. It is validated as an entry of sources.yaml would be, but
+// sources.yaml itself is not read.
+func (p *populator) fileSource() ([]sources.DataSourceConfig, error) {
+	f := p.cfg.Populate.File
+
+	file := f.Path
+	if !sources.IsValidURL(file) && !sources.IsS3URL(file) {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, FileSourceError(f.ID, file, err)
+		}
+		file = abs
+	}
+
+	source := sources.DataSourceConfig{
+		ID:              f.ID,
+		File:            file,
+		Title:           f.Title,
+		OutlinkURL:      f.OutlinkURL,
+		OutlinkIDColumn: f.OutlinkIDColumn,
+	}
+	warnings, err := source.Validate(1)
+	if err != nil {
+		return nil, FileSourceError(f.ID, file, err)
+	}
+	for _, w := range warnings {
+		p.warn("%s: %s", w.Field, w.Message)
+		p.logger().Warn("Source configuration warning",
+			"source_id", w.DataSourceID,
+			"field", w.Field,
+			"message", w.Message,
+			"suggestion", w.Suggestion,
+		)
+	}
+
+	p.info("Processing file <em>%s</em> as data source %d", file, f.ID)
+	p.logger().Info("Processing file as data source",
+		"data_source_id", f.ID,
+		"file", file,
+	)
+	return []sources.DataSourceConfig{source}, nil
+}
+
+// offerToSaveSource asks the user if a data source imported with --file
+// should be added to custom_sources.yaml, so later runs find it without
+// the flag. It is offered only for custom IDs and for files that are
+// named by the ID of the source, because other files cannot be found in
+// the parent directory.
+func (p *populator) offerToSaveSource(source sources.DataSourceConfig) {
+	if p.cfg.Populate.NonInteractive || source.ID < 1000 ||
+		!p.checkpoints.isDone(source.ID) {
+		return
+	}
+
+	filename := filepath.Base(source.File)
+	if !matchesIDPattern(filename, generateIDPatterns(source.ID)) {
+		p.message(
+			"<em>File name does not start with ID %d, "+
+				"it cannot be added to custom_sources.yaml</em>",
+			source.ID,
+		)
+		return
+	}
+
+	src := iosources.New(p.cfg)
+	if cfg, err := src.Load(); err == nil {
+		hasID := func(d sources.DataSourceConfig) bool { return d.ID == source.ID }
+		if slices.ContainsFunc(cfg.DataSources, hasID) {
+			return
+		}
+	}
+
+	promptMu.Lock()
+	answer, err := promptUserMulti(
+		"Add this data source to custom_sources.yaml? [y/N]: ",
+		[]string{"no", "yes"},
+	)
+	promptMu.Unlock()
+	if err != nil || answer != "yes" {
+		return
+	}
+
+	entry := source
+	entry.File = ""
+	if err = src.AddCustom(entry); err != nil {
+		p.warn("Cannot add data source %d to custom_sources.yaml", source.ID)
+		p.logger().Warn("Cannot add data source to custom sources",
+			"data_source_id", source.ID,
+			"error", err,
+		)
+		return
+	}
+	p.info("Data source %d is added to custom_sources.yaml", source.ID)
+	p.logger().Info("Added data source to custom sources",
+		"data_source_id", source.ID,
+		"parent", entry.Parent,
+	)
+}
@@ -0,0 +1,450 @@
+package iopopulate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gnames/gndb/pkg/sources"
+	"github.com/gnames/gnparser"
+	"golang.org/x/sync/errgroup"
+)
+
+// Taxonomic statuses that populate assigns to records without status
+// in SFGA.
+const (
+	synonymStatus  = "synonym"
+	bareNameStatus = "bare name"
+)
+
+// recordFilter decides which records of a data source are imported,
+// according to the filters of the source in sources.yaml (see
+// sources.Filters). It is prepared from the SFGA file before
+// name-strings are imported, so name-strings of excluded records are
+// not stored. A nil *recordFilter keeps all records.
+type recordFilter struct {
+	ranks    map[string]bool
+	statuses map[string]bool
+
+	// subtree has IDs of taxa inside the requested subtrees, it is nil
+	// if there is no subtree filter.
+	subtree map[string]bool
+
+	// badNames has IDs of names with parse quality worse than the
+	// ceiling, it is nil if there is no parse-quality filter.
+	badNames map[string]bool
+
+	// taxa has IDs of imported taxa, vernacular names are imported only
+	// for them.
+	taxa map[string]bool
+
+	// names has IDs of names of all imported records.
+	names map[string]bool
+
+	// excluded counts name indices removed by the filter.
+	excluded int
+}
+
+// newRecordFilter creates a filter without subtree and parse-quality
+// data, see prepareFilter.
+func newRecordFilter(f *sources.Filters) *recordFilter {
+	res := &recordFilter{
+		taxa:  make(map[string]bool),
+		names: make(map[string]bool),
+	}
+	if len(f.Ranks) > 0 {
+		res.ranks = make(map[string]bool, len(f.Ranks))
+		for _, v := range f.Ranks {
+			res.ranks[v] = true
+		}
+	}
+	if len(f.TaxonomicStatuses) > 0 {
+		res.statuses = make(map[string]bool, len(f.TaxonomicStatuses))
+		for _, v := range f.TaxonomicStatuses {
+			res.statuses[v] = true
+		}
+	}
+	return res
+}
+
+// prepareFilter creates the record filter of a source from its SFGA
+// file. Dangling synonyms are kept by the filter if the
+// on_dangling_synonym policy imports them. Returns nil if the source has
+// no filters.
+func (p *populator) prepareFilter(
+	ctx context.Context,
+	source *sources.DataSourceConfig,
+	dangling *danglingSynonyms,
+) (*recordFilter, error) {
+	if source.Filters.IsEmpty() {
+		return nil, nil
+	}
+	f := source.Filters
+	res := newRecordFilter(f)
+
+	var err error
+	if len(f.Subtree) > 0 {
+		res.subtree, err = p.subtreeTaxa(ctx, f.Subtree)
+		if err != nil {
+			return nil, err
+		}
+		if len(res.subtree) == 0 {
+			p.addWarning(fmt.Sprintf(
+				"filters.subtree %v does not match any taxon", f.Subtree,
+			))
+		}
+	}
+
+	if f.MaxParseQuality > 0 {
+		res.badNames, err = p.badQualityNames(ctx, f.MaxParseQuality)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = p.collectKeptRecords(ctx, res, dangling.importsAny())
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger().Info("Prepared record filters",
+		"data_source_id", source.ID,
+		"taxa", len(res.taxa),
+		"names", len(res.names),
+	)
+	p.message(
+		"<em>Filters keep %s taxa and %s names</em>",
+		humanize.Comma(int64(len(res.taxa))),
+		humanize.Comma(int64(len(res.names))),
+	)
+	return res, nil
+}
+
+// keepTaxon returns true if an accepted taxon is imported.
+func (f *recordFilter) keepTaxon(taxonID, nameID, rank, status string) bool {
+	if f == nil {
+		return true
+	}
+	return f.inSubtree(taxonID) && f.keepName(nameID, rank, status)
+}
+
+// keepSynonym returns true if a synonym of an accepted taxon is imported.
+// Synonyms are in the subtree of their accepted taxon.
+func (f *recordFilter) keepSynonym(
+	acceptedID, nameID, rank, status string,
+) bool {
+	if f == nil {
+		return true
+	}
+	if status == "" {
+		status = synonymStatus
+	}
+	return f.inSubtree(acceptedID) && f.keepName(nameID, rank, status)
+}
+
+// keepBareName returns true if a name without taxon or synonym is
+// imported. Bare names are never in a subtree.
+func (f *recordFilter) keepBareName(nameID, rank string) bool {
+	if f == nil {
+		return true
+	}
+	return f.subtree == nil && f.keepName(nameID, rank, bareNameStatus)
+}
+
+// keepVernacular returns true if vernacular names of a taxon are
+// imported.
+func (f *recordFilter) keepVernacular(taxonID string) bool {
+	return f == nil || f.taxa[taxonID]
+}
+
+// keepNameString returns true if the name-string of a name is imported.
+func (f *recordFilter) keepNameString(nameID string) bool {
+	return f == nil || f.names[nameID]
+}
+
+// inSubtree returns true if there is no subtree filter, or the taxon
+// is inside one of the subtrees.
+func (f *recordFilter) inSubtree(taxonID string) bool {
+	return f.subtree == nil || f.subtree[taxonID]
+}
+
+// keepName checks rank, status and parse quality filters.
+func (f *recordFilter) keepName(nameID, rank, status string) bool {
+	if f.ranks != nil && !f.ranks[strings.ToLower(rank)] {
+		return false
+	}
+	if f.statuses != nil && !f.statuses[strings.ToLower(status)] {
+		return false
+	}
+	return !f.badNames[nameID]
+}
+
+// exclude counts a record removed by the filter.
+func (f *recordFilter) exclude() {
+	if f != nil {
+		f.excluded++
+	}
+}
+
+// summary describes records removed by the filter, it is empty if
+// nothing was removed.
+func (f *recordFilter) summary() string {
+	if f == nil || f.excluded == 0 {
+		return ""
+	}
+	return fmt.Sprintf(
+		"filters excluded %s records", humanize.Comma(int64(f.excluded)),
+	)
+}
+
+// subtreeTaxa returns IDs of taxa that are roots given in subtree, by
+// their ID or case-insensitive scientific name, or their descendants.
+func (p *populator) subtreeTaxa(
+	ctx context.Context,
+	subtree []string,
+) (map[string]bool, error) {
+	rows, err := p.sfgaDB.QueryContext(ctx, `
+		SELECT t.col__id, t.col__parent_id, n.col__scientific_name
+		FROM taxon t
+		JOIN name n ON n.col__id = t.col__name_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query taxa for subtree filter: %w", err)
+	}
+	defer rows.Close()
+
+	wanted := make(map[string]bool, len(subtree))
+	for _, v := range subtree {
+		wanted[v] = true
+		wanted[strings.ToLower(v)] = true
+	}
+
+	parents := make(map[string]string)
+	roots := make(map[string]bool)
+	for rows.Next() {
+		var id, parentID, name string
+		if err = rows.Scan(&id, &parentID, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan taxon row: %w", err)
+		}
+		parents[id] = parentID
+		if wanted[id] || wanted[strings.ToLower(strings.TrimSpace(name))] {
+			roots[id] = true
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subtreeMembers(parents, roots), nil
+}
+
+// subtreeMembers returns IDs of roots and of all nodes that have a root
+// among their ancestors. Parent chains that are broken or circular are
+// handled as in checkHierarchy, every node is visited once.
+func subtreeMembers(
+	parents map[string]string,
+	roots map[string]bool,
+) map[string]bool {
+	res := make(map[string]bool)
+	checked := make(map[string]bool, len(parents))
+	onPath := make(map[string]bool)
+
+	for id := range parents {
+		var path []string
+		in := false
+		currID := id
+		for {
+			if v, ok := checked[currID]; ok {
+				in = v
+				break
+			}
+			parentID, ok := parents[currID]
+			if !ok || onPath[currID] {
+				break
+			}
+			onPath[currID] = true
+			path = append(path, currID)
+			if roots[currID] {
+				in = true
+				break
+			}
+			currID = parentID
+		}
+
+		for _, v := range path {
+			delete(onPath, v)
+			checked[v] = in
+			if in {
+				res[v] = true
+			}
+		}
+	}
+	return res
+}
+
+// badQualityNames parses all names of the SFGA file and returns IDs of
+// names that cannot be parsed or have parse quality worse than
+// maxQuality. Names are parsed by p.cfg.JobsNumber workers.
+func (p *populator) badQualityNames(
+	ctx context.Context,
+	maxQuality int,
+) (map[string]bool, error) {
+	type nameRow struct{ id, name string }
+	chIn := make(chan nameRow)
+	chOut := make(chan string)
+
+	g, ctx := errgroup.WithContext(ctx)
+	var wg sync.WaitGroup
+	for range p.cfg.JobsNumber {
+		wg.Add(1)
+		g.Go(func() error {
+			defer wg.Done()
+			parser := gnparser.New(gnparser.NewConfig())
+			for r := range chIn {
+				parsed := parser.ParseName(r.name)
+				if parsed.Parsed && parsed.ParseQuality <= maxQuality {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case chOut <- r.id:
+				}
+			}
+			return nil
+		})
+	}
+	go func() {
+		wg.Wait()
+		close(chOut)
+	}()
+
+	res := make(map[string]bool)
+	done := make(chan struct{})
+	go func() {
+		for id := range chOut {
+			res[id] = true
+		}
+		close(done)
+	}()
+
+	err := p.loadNames(ctx, func(id, name string) {
+		chIn <- nameRow{id: id, name: name}
+	})
+	close(chIn)
+	if gErr := g.Wait(); err == nil {
+		err = gErr
+	}
+	<-done
+	if err != nil {
+		return nil, fmt.Errorf("failed to check parse quality: %w", err)
+	}
+	return res, nil
+}
+
+// loadNames sends IDs and name-strings of all SFGA names to fn.
+func (p *populator) loadNames(
+	ctx context.Context,
+	fn func(id, name string),
+) error {
+	rows, err := p.sfgaDB.QueryContext(ctx, `
+		SELECT col__id, gn__scientific_name_string, col__scientific_name
+		FROM name
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		var id, colName string
+		var gnName sql.NullString
+		if err = rows.Scan(&id, &gnName, &colName); err != nil {
+			return err
+		}
+		fn(id, pickNameString(gnName, colName))
+	}
+	return rows.Err()
+}
+
+// collectKeptRecords finds taxa and names of records that pass the
+// filter. The same rules are applied again when name indices are
+// written. If withDangling is true, synonyms whose accepted taxon is
+// missing are checked too, like in getSynonymData.
+func (p *populator) collectKeptRecords(
+	ctx context.Context,
+	f *recordFilter,
+	withDangling bool,
+) error {
+	synonymJoin := "JOIN"
+	if withDangling {
+		synonymJoin = "LEFT JOIN"
+	}
+	queries := []struct {
+		kind  string
+		query string
+	}{
+		{"taxa", `
+			SELECT t.col__id, t.col__name_id, n.col__rank_id, t.col__status_id
+			FROM taxon t
+			JOIN name n ON n.col__id = t.col__name_id`},
+		{"synonyms", `
+			SELECT s.col__taxon_id, s.col__name_id, n.col__rank_id,
+			       s.col__status_id
+			FROM synonym s
+			JOIN name n ON n.col__id = s.col__name_id
+			` + synonymJoin + ` taxon t ON t.col__id = s.col__taxon_id`},
+		{"bare names", `
+			SELECT '', name.col__id, name.col__rank_id, ''
+			FROM name
+			WHERE name.col__id NOT IN (
+				SELECT col__name_id FROM taxon
+				UNION
+				SELECT col__name_id FROM synonym
+			)`},
+	}
+
+	for _, q := range queries {
+		rows, err := p.sfgaDB.QueryContext(ctx, q.query)
+		if err != nil {
+			return fmt.Errorf("failed to query %s for filters: %w", q.kind, err)
+		}
+
+		for rows.Next() {
+			var taxonID, nameID, rank, status string
+			err = rows.Scan(&taxonID, &nameID, &rank, &status)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan %s row: %w", q.kind, err)
+			}
+
+			var keep bool
+			switch q.kind {
+			case "taxa":
+				keep = f.keepTaxon(taxonID, nameID, rank, status)
+				if keep {
+					f.taxa[taxonID] = true
+				}
+			case "synonyms":
+				keep = f.keepSynonym(taxonID, nameID, rank, status)
+			default:
+				keep = f.keepBareName(nameID, rank)
+			}
+			if keep {
+				f.names[nameID] = true
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
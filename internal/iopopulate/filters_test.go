@@ -0,0 +1,189 @@
+package iopopulate
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/gnames/gndb/pkg/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubtreeMembers(t *testing.T) {
+	parents := map[string]string{
+		"1": "",  // Animalia
+		"2": "1", // Chordata
+		"3": "2", // Aves
+		"4": "3", // Passeriformes
+		"5": "2", // Mammalia
+		"6": "7", // circular
+		"7": "6",
+		"8": "9", // missing parent
+	}
+
+	res := subtreeMembers(parents, map[string]bool{"3": true})
+	assert.Equal(t, map[string]bool{"3": true, "4": true}, res)
+
+	res = subtreeMembers(parents, map[string]bool{"2": true, "6": true})
+	assert.Equal(t, map[string]bool{
+		"2": true, "3": true, "4": true, "5": true, "6": true, "7": true,
+	}, res)
+
+	assert.Empty(t, subtreeMembers(parents, nil))
+}
+
+func TestRecordFilterKeep(t *testing.T) {
+	t.Run("nil filter", func(t *testing.T) {
+		var f *recordFilter
+		assert.True(t, f.keepTaxon("t1", "n1", "species", "accepted"))
+		assert.True(t, f.keepSynonym("t1", "n2", "species", ""))
+		assert.True(t, f.keepBareName("n3", ""))
+		assert.True(t, f.keepVernacular("t1"))
+		assert.True(t, f.keepNameString("n1"))
+		f.exclude()
+		assert.Empty(t, f.summary())
+	})
+
+	t.Run("ranks and statuses", func(t *testing.T) {
+		f := newRecordFilter(&sources.Filters{
+			Ranks:             []string{"species"},
+			TaxonomicStatuses: []string{"accepted", "synonym"},
+		})
+		assert.True(t, f.keepTaxon("t1", "n1", "SPECIES", "ACCEPTED"))
+		assert.False(t, f.keepTaxon("t2", "n2", "genus", "accepted"))
+		assert.False(t, f.keepTaxon("t3", "n3", "species", "provisional"))
+		assert.True(t, f.keepSynonym("t1", "n4", "species", ""))
+		assert.False(t, f.keepBareName("n5", "species"))
+	})
+
+	t.Run("subtree and parse quality", func(t *testing.T) {
+		f := newRecordFilter(&sources.Filters{})
+		f.subtree = map[string]bool{"t1": true}
+		f.badNames = map[string]bool{"n2": true}
+		assert.True(t, f.keepTaxon("t1", "n1", "", ""))
+		assert.False(t, f.keepTaxon("t2", "n3", "", ""))
+		assert.True(t, f.keepSynonym("t1", "n4", "", ""))
+		assert.False(t, f.keepSynonym("t1", "n2", "", ""))
+		assert.False(t, f.keepSynonym("t2", "n5", "", ""))
+		assert.False(t, f.keepBareName("n6", ""))
+
+		f.exclude()
+		f.exclude()
+		assert.Equal(t, "filters excluded 2 records", f.summary())
+	})
+}
+
+func TestCollectKeptRecords(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses SQLite in short mode")
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE name (
+			col__id TEXT, col__scientific_name TEXT, col__rank_id TEXT
+		);
+		CREATE TABLE taxon (
+			col__id TEXT, col__parent_id TEXT, col__name_id TEXT,
+			col__status_id TEXT
+		);
+		CREATE TABLE synonym (
+			col__id TEXT, col__taxon_id TEXT, col__name_id TEXT,
+			col__status_id TEXT
+		);
+		INSERT INTO name VALUES
+			('n1', 'Chordata', 'phylum'),
+			('n2', 'Aves', 'class'),
+			('n3', 'Passer domesticus', 'species'),
+			('n4', 'Mammalia', 'class'),
+			('n5', 'Fringilla domestica', 'species'),
+			('n6', 'Bare name', 'species');
+		INSERT INTO taxon VALUES
+			('t1', '', 'n1', 'accepted'),
+			('t2', 't1', 'n2', 'accepted'),
+			('t3', 't2', 'n3', 'accepted'),
+			('t4', 't1', 'n4', 'accepted');
+		INSERT INTO synonym VALUES ('s1', 't3', 'n5', '');
+	`)
+	require.NoError(t, err)
+
+	p := &populator{sfgaDB: db}
+	f := newRecordFilter(&sources.Filters{Ranks: []string{"species"}})
+	f.subtree, err = p.subtreeTaxa(context.Background(), []string{"aves"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"t2": true, "t3": true}, f.subtree)
+
+	require.NoError(t, p.collectKeptRecords(context.Background(), f, false))
+	assert.Equal(t, map[string]bool{"t3": true}, f.taxa)
+	assert.Equal(t, map[string]bool{"n3": true, "n5": true}, f.names)
+}
+
+func TestCollectKeptRecordsDangling(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses SQLite in short mode")
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE name (
+			col__id TEXT, col__scientific_name TEXT, col__rank_id TEXT
+		);
+		CREATE TABLE taxon (
+			col__id TEXT, col__parent_id TEXT, col__name_id TEXT,
+			col__status_id TEXT
+		);
+		CREATE TABLE synonym (
+			col__id TEXT, col__taxon_id TEXT, col__name_id TEXT,
+			col__status_id TEXT
+		);
+		INSERT INTO name VALUES
+			('n1', 'Passer domesticus', 'species'),
+			('n2', 'Fringilla domestica', 'species'),
+			('n3', 'Passer italiae', 'species');
+		INSERT INTO taxon VALUES ('t1', '', 'n1', 'accepted');
+		INSERT INTO synonym VALUES
+			('s1', 't1', 'n2', ''),
+			('s2', 't9', 'n3', '');
+	`)
+	require.NoError(t, err)
+
+	p := &populator{sfgaDB: db}
+	keys := []recordKey{{id: "s2", acceptedID: "t9"}}
+	tests := []struct {
+		msg      string
+		dangling *danglingSynonyms
+		names    map[string]bool
+	}{
+		{"no dangling", nil, map[string]bool{"n1": true, "n2": true}},
+		{
+			"drop", newDanglingSynonyms(sources.OnDanglingDrop, keys),
+			map[string]bool{"n1": true, "n2": true},
+		},
+		{
+			"keep", newDanglingSynonyms(sources.OnDanglingKeep, keys),
+			map[string]bool{"n1": true, "n2": true, "n3": true},
+		},
+		{
+			"bare-name", newDanglingSynonyms(sources.OnDanglingBareName, keys),
+			map[string]bool{"n1": true, "n2": true, "n3": true},
+		},
+	}
+
+	for _, v := range tests {
+		t.Run(v.msg, func(t *testing.T) {
+			f := newRecordFilter(&sources.Filters{Ranks: []string{"species"}})
+			err := p.collectKeptRecords(
+				context.Background(), f, v.dangling.importsAny(),
+			)
+			require.NoError(t, err)
+			assert.Equal(t, v.names, f.names)
+		})
+	}
+}
@@ -3,12 +3,12 @@ package iopopulate
 import (
 	"context"
 	"errors"
-	"fmt"
-	"os"
+	"maps"
+	"slices"
 	"strings"
 	"sync"
 
-	"github.com/dustin/go-humanize"
+	"github.com/gnames/gndb/internal/ioprogress"
 	"github.com/gnames/gnlib/ent/nomcode"
 	"github.com/gnames/gnparser"
 	"golang.org/x/sync/errgroup"
@@ -25,18 +25,6 @@ type hNode struct {
 	rank            string
 }
 
-// badNodes tracks nodes that are referenced but don't exist in the hierarchy.
-// This prevents logging the same missing node warning multiple times.
-var badNodes = make(map[string]badNodeType)
-var badNodesMutex sync.Mutex
-
-type badNodeType int
-
-const (
-	missingBadNode badNodeType = iota + 1
-	circularBadNode
-)
-
 // buildHierarchy implements Phase 3: Classification Hierarchy construction.
 // It constructs a map of taxonomy nodes from the SFGA taxon table using
 // concurrent workers.
@@ -46,7 +34,7 @@ const (
 // like "Aus (Bus)" parsing incorrectly.
 //
 // Concurrent Processing:
-//   - Creates its own context for goroutine cancellation
+//   - Derives a context for goroutine cancellation from ctx
 //   - Uses p.cfg.JobsNumber workers to parse names in parallel
 //   - Employs errgroup for coordinated error handling
 //
@@ -54,13 +42,15 @@ const (
 //   - map[string]*hNode: Map of taxon IDs to hierarchy nodes with parent
 //     pointers
 //   - error: Any error encountered during processing
-func (p *populator) buildHierarchy() (map[string]*hNode, error) {
+func (p *populator) buildHierarchy(
+	ctx context.Context,
+) (map[string]*hNode, error) {
 	// Create channels for worker communication
 	chIn := make(chan nameUsage)
 	chOut := make(chan *hNode)
 
 	// Create error group for concurrent processing
-	g, ctx := errgroup.WithContext(context.Background())
+	g, gCtx := errgroup.WithContext(ctx)
 	var wg sync.WaitGroup
 
 	// Start worker goroutines
@@ -68,7 +58,7 @@ func (p *populator) buildHierarchy() (map[string]*hNode, error) {
 		wg.Add(1)
 		g.Go(func() error {
 			defer wg.Done()
-			return hierarchyWorker(ctx, chIn, chOut)
+			return hierarchyWorker(gCtx, chIn, chOut)
 		})
 	}
 
@@ -77,7 +67,9 @@ func (p *populator) buildHierarchy() (map[string]*hNode, error) {
 
 	// Start result collector
 	g.Go(func() error {
-		return createHierarchy(ctx, chOut, hierarchy)
+		bar := p.newCounter(0, "Processed hierarchy records: ")
+		defer bar.Finish()
+		return createHierarchy(gCtx, chOut, hierarchy, bar)
 	})
 
 	// Close chOut when all workers are done
@@ -87,16 +79,22 @@ func (p *populator) buildHierarchy() (map[string]*hNode, error) {
 	}()
 
 	// Load name usage data from SFGA
-	err := p.loadNameUsage(ctx, chIn)
+	err := p.loadNameUsage(gCtx, chIn)
+	close(chIn)
 	if err != nil {
+		// Let workers finish before returning.
+		_ = g.Wait()
 		return nil, err
 	}
-	close(chIn)
 
 	// Wait for all goroutines to complete
 	if err := g.Wait(); err != nil && !errors.Is(err, context.Canceled) {
 		return nil, err
 	}
+	// An interrupted run leaves the hierarchy incomplete.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	return hierarchy, nil
 }
@@ -161,18 +159,19 @@ func processHierarchyRow(parser gnparser.GNparser, nu nameUsage) *hNode {
 }
 
 // createHierarchy collects hNode results from workers into the hierarchy map.
-// It also logs progress periodically.
-func createHierarchy(ctx context.Context, chOut <-chan *hNode, hierarchy map[string]*hNode) error {
-	var count int
+// Every collected node is counted by bar.
+func createHierarchy(
+	ctx context.Context,
+	chOut <-chan *hNode,
+	hierarchy map[string]*hNode,
+	bar *ioprogress.Counter,
+) error {
 	for node := range chOut {
 		if node.id == "" {
 			continue
 		}
 
-		count++
-		if count%100_000 == 0 {
-			progressReport(count, "hierarchy records")
-		}
+		bar.Increment()
 
 		select {
 		case <-ctx.Done():
@@ -181,7 +180,6 @@ func createHierarchy(ctx context.Context, chOut <-chan *hNode, hierarchy map[str
 			hierarchy[node.id] = node
 		}
 	}
-	fmt.Fprintf(os.Stderr, "\r%s\r", strings.Repeat(" ", 80))
 
 	return nil
 }
@@ -199,7 +197,7 @@ func (p *populator) loadNameUsage(
 		JOIN name n ON n.col__id = t.col__name_id
 	`
 
-	rows, err := p.sfgaDB.Query(query)
+	rows, err := p.sfgaDB.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -224,7 +222,11 @@ func (p *populator) loadNameUsage(
 			return err
 		}
 
-		chIn <- nu
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case chIn <- nu:
+		}
 	}
 
 	return rows.Err()
@@ -293,26 +295,15 @@ func breadcrumbsNodes(id string, hierarchy map[string]*hNode) []*hNode {
 	visited := make(map[string]bool) // Prevent infinite loops
 
 	for {
-		// Check for circular references
+		// Check for circular references (see checkHierarchy)
 		if visited[currID] {
-			badNodesMutex.Lock()
-			if _, ok := badNodes[currID]; !ok {
-				badNodes[currID] = circularBadNode
-			}
-			badNodesMutex.Unlock()
 			return result
 		}
 		visited[currID] = true
 
-		// Get the node
+		// Get the node, missing parents are reported by checkHierarchy
 		node, ok := hierarchy[currID]
 		if !ok {
-			// Node doesn't exist
-			badNodesMutex.Lock()
-			if _, ok := badNodes[currID]; !ok {
-				badNodes[currID] = missingBadNode
-			}
-			badNodesMutex.Unlock()
 			return result
 		}
 
@@ -328,6 +319,142 @@ func breadcrumbsNodes(id string, hierarchy map[string]*hNode) []*hNode {
 	}
 }
 
+// defectType is a kind of problem in a classification hierarchy.
+type defectType string
+
+const (
+	// missingParent is a node whose parent ID has no node.
+	missingParent defectType = "missing_parent"
+
+	// circularParent is a node whose parent chain leads back to it.
+	circularParent defectType = "circular_parent"
+)
+
+// hierarchyDefect is a node where a parent chain breaks. Descendants
+// of the node get incomplete classification breadcrumbs.
+type hierarchyDefect struct {
+	NodeID      string     `json:"node_id"`
+	ParentID    string     `json:"parent_id"`
+	Type        defectType `json:"defect"`
+	Descendants int        `json:"descendants"`
+
+	// DescendantIDs are sorted IDs of the descendants.
+	DescendantIDs []string `json:"descendant_ids"`
+}
+
+// hierarchyCheck describes problems found in a classification hierarchy
+// of one data source.
+type hierarchyCheck struct {
+	// nodes is the number of nodes in the hierarchy.
+	nodes int
+
+	// affected is the number of nodes with a broken parent chain,
+	// including defect nodes.
+	affected int
+
+	// defects are sorted by node ID.
+	defects []hierarchyDefect
+}
+
+// ratio returns the share of nodes with a broken parent chain.
+func (h hierarchyCheck) ratio() float64 {
+	if h.nodes == 0 {
+		return 0
+	}
+	return float64(h.affected) / float64(h.nodes)
+}
+
+// count returns the number of defects of the given type.
+func (h hierarchyCheck) count(t defectType) int {
+	var res int
+	for _, d := range h.defects {
+		if d.Type == t {
+			res++
+		}
+	}
+	return res
+}
+
+// checkHierarchy walks parent chains of all nodes and finds nodes with
+// missing or circular parents. Every node below a defect is counted as
+// its descendant. Every node is visited once.
+func checkHierarchy(hierarchy map[string]*hNode) hierarchyCheck {
+	res := hierarchyCheck{nodes: len(hierarchy)}
+
+	// defectOf keeps the index of the defect that breaks the chain of
+	// a checked node, or -1 if the chain reaches a root.
+	defectOf := make(map[string]int, len(hierarchy))
+	onPath := make(map[string]bool)
+
+	// Sorted IDs make the node reported for a cycle stable
+	ids := slices.Sorted(maps.Keys(hierarchy))
+	for _, id := range ids {
+		if _, ok := defectOf[id]; ok {
+			continue
+		}
+
+		var path []string
+		defect := -1
+		currID := id
+		for {
+			if d, ok := defectOf[currID]; ok {
+				defect = d
+				break
+			}
+			if onPath[currID] {
+				defect = len(res.defects)
+				res.defects = append(res.defects, hierarchyDefect{
+					NodeID:        currID,
+					ParentID:      hierarchy[currID].parentID,
+					Type:          circularParent,
+					DescendantIDs: []string{},
+				})
+				break
+			}
+			node, ok := hierarchy[currID]
+			if !ok {
+				last := path[len(path)-1]
+				defect = len(res.defects)
+				res.defects = append(res.defects, hierarchyDefect{
+					NodeID:        last,
+					ParentID:      currID,
+					Type:          missingParent,
+					DescendantIDs: []string{},
+				})
+				break
+			}
+			onPath[currID] = true
+			path = append(path, currID)
+			if node.parentID == "" {
+				break
+			}
+			currID = node.parentID
+		}
+
+		for _, v := range path {
+			delete(onPath, v)
+			defectOf[v] = defect
+			if defect < 0 {
+				continue
+			}
+			res.affected++
+			// Defect nodes are not their own descendants
+			if d := &res.defects[defect]; v != d.NodeID {
+				d.Descendants++
+				d.DescendantIDs = append(d.DescendantIDs, v)
+			}
+		}
+	}
+
+	for i := range res.defects {
+		slices.Sort(res.defects[i].DescendantIDs)
+	}
+	slices.SortFunc(res.defects, func(a, b hierarchyDefect) int {
+		return strings.Compare(a.NodeID, b.NodeID)
+	})
+	return res
+}
+
 // getFlatClsf combines flat classification data with existing nodes.
 // Flat classification provides predefined ranks when hierarchical data is incomplete.
 //
@@ -373,11 +500,3 @@ func getFlatClsf(flatClsf map[string]string, nodes []*hNode) []*hNode {
 
 	return result
 }
-
-// progressReport logs progress to stderr with humanized numbers.
-// It clears the line before writing to avoid leftover characters.
-func progressReport(recNum int, entity string) {
-	str := fmt.Sprintf("Processed %s %s", humanize.Comma(int64(recNum)), entity)
-	fmt.Fprintf(os.Stderr, "\r%s", strings.Repeat(" ", 80))
-	fmt.Fprintf(os.Stderr, "\r%s", str)
-}
@@ -278,3 +278,39 @@ func TestProcessHierarchyRow(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckHierarchy(t *testing.T) {
+	// 1 <- 2 <- 3 is fine, 4 points to missing 9, 5 <-> 6 is a cycle,
+	// 7 hangs below the cycle, 8 hangs below 4.
+	hierarchy := map[string]*hNode{
+		"1": {id: "1", parentID: ""},
+		"2": {id: "2", parentID: "1"},
+		"3": {id: "3", parentID: "2"},
+		"4": {id: "4", parentID: "9"},
+		"5": {id: "5", parentID: "6"},
+		"6": {id: "6", parentID: "5"},
+		"7": {id: "7", parentID: "5"},
+		"8": {id: "8", parentID: "4"},
+	}
+
+	res := checkHierarchy(hierarchy)
+	assert.Equal(t, 8, res.nodes)
+	assert.Equal(t, 5, res.affected)
+	assert.InDelta(t, 5.0/8.0, res.ratio(), 0.0001)
+	assert.Equal(t, []hierarchyDefect{
+		{
+			NodeID: "4", ParentID: "9", Type: missingParent,
+			Descendants: 1, DescendantIDs: []string{"8"},
+		},
+		{
+			NodeID: "5", ParentID: "6", Type: circularParent,
+			Descendants: 2, DescendantIDs: []string{"6", "7"},
+		},
+	}, res.defects)
+	assert.Equal(t, 1, res.count(missingParent))
+	assert.Equal(t, 1, res.count(circularParent))
+
+	empty := checkHierarchy(nil)
+	assert.Empty(t, empty.defects)
+	assert.Zero(t, empty.ratio())
+}
@@ -3,7 +3,6 @@ package iopopulate
 import (
 	"context"
 	"fmt"
-	"log/slog"
 
 	"github.com/dustin/go-humanize"
 	"github.com/gnames/gndb/pkg/sources"
@@ -16,39 +15,48 @@ import (
 //  3. Bare names - names not in taxon or synonym tables (orphans)
 //
 // Each scenario is processed separately with its own batch insert logic.
+// Records go to the staging table of the source (see swapSource).
 // The hierarchy map (built in Phase 3) provides classification paths for taxa and synonyms.
+// Duplicate record IDs are resolved by ids (see handleDuplicateRecordIDs),
+// synonyms without accepted taxa by dangling (see handleDanglingSynonyms),
+// records that do not pass filters of the source are skipped (see
+// prepareFilter).
 func (p *populator) processNameIndices(
+	ctx context.Context,
 	source *sources.DataSourceConfig,
 	hierarchy map[string]*hNode,
-) (string, error) {
-	slog.Info("Processing name indices", "data_source_id", source.ID)
+	ids *recordIDs,
+	dangling *danglingSynonyms,
+) (string, int, error) {
+	p.logger().Info("Processing name indices", "data_source_id", source.ID)
 
-	// Clean old data for this source
-	err := p.cleanNameIndices(source.ID)
+	// Load indices into an empty staging table, live data stays
+	// untouched until the metadata stage swaps it in.
+	err := p.createStagingTable(ctx, nameIndicesTable, source.ID)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	// Process taxa (accepted names with classification)
-	taxaCount, err := p.processTaxa(source, hierarchy)
+	taxaCount, err := p.processTaxa(ctx, source, hierarchy, ids)
 	if err != nil {
-		return "", fmt.Errorf("failed to process taxa: %w", err)
+		return "", 0, fmt.Errorf("failed to process taxa: %w", err)
 	}
 
 	// Process synonyms (linked to accepted taxa)
-	synonymCount, err := p.processSynonyms(source, hierarchy)
+	synonymCount, err := p.processSynonyms(ctx, source, hierarchy, ids, dangling)
 	if err != nil {
-		return "", fmt.Errorf("failed to process synonyms: %w", err)
+		return "", 0, fmt.Errorf("failed to process synonyms: %w", err)
 	}
 
 	// Process bare names (orphans not in taxon/synonym)
-	bareCount, err := p.processBareNames(source)
+	bareCount, err := p.processBareNames(ctx, source, ids)
 	if err != nil {
-		return "", fmt.Errorf("failed to process bare names: %w", err)
+		return "", 0, fmt.Errorf("failed to process bare names: %w", err)
 	}
 
 	totalCount := taxaCount + synonymCount + bareCount
-	slog.Info("Name indices processing complete",
+	p.logger().Info("Name indices processing complete",
 		"data_source_id", source.ID, "total", totalCount)
 
 	msg := fmt.Sprintf(
@@ -58,20 +66,21 @@ func (p *populator) processNameIndices(
 		humanize.Comma(int64(synonymCount)),
 		humanize.Comma(int64(bareCount)),
 	)
-
-	return msg, nil
-}
-
-// cleanNameIndices deletes existing name indices for the given data source.
-// This ensures clean re-imports without duplicates.
-func (p *populator) cleanNameIndices(sourceID int) error {
-	query := "DELETE FROM name_string_indices WHERE data_source_id = $1"
-
-	_, err := p.operator.Pool().Exec(context.Background(), query, sourceID)
-	if err != nil {
-		return fmt.Errorf("failed to clean name indices: %w", err)
+	if summary := ids.summary(); summary != "" {
+		p.logger().Info("Resolved duplicate record IDs",
+			"data_source_id", source.ID, "result", summary)
+		msg += fmt.Sprintf(", <em>%s</em>", summary)
+	}
+	if summary := dangling.summary(); summary != "" {
+		p.logger().Info("Handled dangling synonyms",
+			"data_source_id", source.ID, "result", summary)
+		msg += fmt.Sprintf(", <em>%s</em>", summary)
+	}
+	if summary := p.filter.summary(); summary != "" {
+		p.logger().Info("Applied filters",
+			"data_source_id", source.ID, "result", summary)
+		msg += fmt.Sprintf(", <em>%s</em>", summary)
 	}
 
-	slog.Info("Cleaned old name indices", "data_source_id", sourceID)
-	return nil
+	return msg, totalCount, nil
 }
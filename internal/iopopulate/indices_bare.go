@@ -1,12 +1,11 @@
 package iopopulate
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log/slog"
 	"strings"
 
-	"github.com/cheggaaa/pb/v3"
 	"github.com/dustin/go-humanize"
 	"github.com/gnames/gndb/pkg/sources"
 	"github.com/gnames/gnuuid"
@@ -15,12 +14,14 @@ import (
 // processBareNames processes names that are not in taxon or synonym tables.
 // These are "orphan" names with no taxonomic context.
 func (p *populator) processBareNames(
+	ctx context.Context,
 	source *sources.DataSourceConfig,
+	ids *recordIDs,
 ) (int, error) {
-	slog.Info("Processing bare names", "data_source_id", source.ID)
+	p.logger().Info("Processing bare names", "data_source_id", source.ID)
 
 	// Count total bare names for progress bar
-	totalCount, err := p.getTotalBareCount()
+	totalCount, err := p.getTotalBareCount(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count bare names: %w", err)
 	}
@@ -29,7 +30,7 @@ func (p *populator) processBareNames(
 	outlinkCol := buildOutlinkColumn(source.OutlinkIDColumn, "bare_names")
 
 	// Query names not in taxon or synonym
-	rows, err := p.getBareData(outlinkCol)
+	rows, err := p.getBareData(ctx, outlinkCol)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query bare names: %w", err)
 	}
@@ -39,9 +40,7 @@ func (p *populator) processBareNames(
 	var count int
 
 	// Create progress bar with known total
-	bar := pb.Full.Start(totalCount)
-	bar.Set("prefix", "Processing bare names: ")
-	bar.Set(pb.CleanOnFinish, true)
+	bar := p.newCounter(totalCount, "Processing bare names: ")
 	defer bar.Finish()
 
 	for rows.Next() {
@@ -57,8 +56,19 @@ func (p *populator) processBareNames(
 			nameString = t.colName
 		}
 
+		if !p.filter.keepBareName(t.nameID, t.rankID) {
+			p.filter.exclude()
+			bar.Add(1)
+			continue
+		}
+
 		nameStringID := gnuuid.New(nameString).String()
-		recordID := "bare-name-" + t.nameID
+		bareID := "bare-name-" + t.nameID
+		recordID, ok := ids.resolve(bareID, bareID)
+		if !ok {
+			bar.Add(1)
+			continue
+		}
 
 		// Extract outlink ID if available
 		outlinkID := ""
@@ -86,6 +96,7 @@ func (p *populator) processBareNames(
 			"",                        // classification (NULL)
 			"",                        // classification_ids (NULL)
 			"",                        // classification_ranks (NULL)
+			t.referenceID,             // reference_id
 		}
 
 		records = append(records, record)
@@ -95,7 +106,7 @@ func (p *populator) processBareNames(
 		bar.Add(1)
 
 		if len(records) >= p.cfg.Database.BatchSize {
-			err = insertNameIndices(p, records)
+			err = insertNameIndices(ctx, p, source.ID, records)
 			if err != nil {
 				return 0, err
 			}
@@ -104,14 +115,14 @@ func (p *populator) processBareNames(
 	}
 
 	if len(records) > 0 {
-		err = insertNameIndices(p, records)
+		err = insertNameIndices(ctx, p, source.ID, records)
 		if err != nil {
 			return 0, err
 		}
 	}
 
 	if count > 0 {
-		slog.Info(
+		p.logger().Info(
 			"Processed bare names",
 			"data_source_id",
 			source.ID,
@@ -123,7 +134,7 @@ func (p *populator) processBareNames(
 	return count, rows.Err()
 }
 
-func (p *populator) getTotalBareCount() (int, error) {
+func (p *populator) getTotalBareCount(ctx context.Context) (int, error) {
 	var totalCount int
 	countQuery := `
 		SELECT COUNT(*)
@@ -134,15 +145,19 @@ func (p *populator) getTotalBareCount() (int, error) {
 			SELECT col__name_id FROM synonym
 		)
 	`
-	err := p.sfgaDB.QueryRow(countQuery).Scan(&totalCount)
+	err := p.sfgaDB.QueryRowContext(ctx, countQuery).Scan(&totalCount)
 	return totalCount, err
 }
 
-func (p *populator) getBareData(outlinkCol string) (*sql.Rows, error) {
+func (p *populator) getBareData(
+	ctx context.Context,
+	outlinkCol string,
+) (*sql.Rows, error) {
 	// Query names not in taxon or synonym
 	query := `
 		SELECT name.col__id, name.col__scientific_name, name.gn__scientific_name_string,
-		       name.col__code_id, name.col__rank_id`
+		       name.col__code_id, name.col__rank_id,
+		       COALESCE(name.col__reference_id,'')`
 
 	// Add outlink column if available
 	if outlinkCol != "" {
@@ -158,7 +173,7 @@ func (p *populator) getBareData(outlinkCol string) (*sql.Rows, error) {
 			SELECT col__name_id FROM synonym
 		)
 	`
-	return p.sfgaDB.Query(query)
+	return p.sfgaDB.QueryContext(ctx, query)
 }
 
 func (p *populator) getBareDatum(
@@ -167,7 +182,9 @@ func (p *populator) getBareDatum(
 ) (taxonDatum, error) {
 	var t taxonDatum
 
-	scanArgs := []any{&t.nameID, &t.colName, &t.gnName, &t.codeID, &t.rankID}
+	scanArgs := []any{
+		&t.nameID, &t.colName, &t.gnName, &t.codeID, &t.rankID, &t.referenceID,
+	}
 
 	// Add outlink ID to scan if column was selected
 	if outlinkCol != "" {
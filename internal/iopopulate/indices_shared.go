@@ -11,6 +11,7 @@ import (
 type taxonDatum struct {
 	colName, gnName, synonymID, taxonID, nameID     string
 	nameString, codeID, rankID, statusID            string
+	referenceID                                     string
 	kingdom, kingdomID, phylum, phylumID, subphylum sql.NullString
 	subphylumID, class, classID, order, orderID     sql.NullString
 	family, familyID, genus, genusID, species       sql.NullString
@@ -54,26 +55,29 @@ func (p *populator) flatClassification(t taxonDatum) (map[string]string, bool) {
 	}
 
 	// Get classification breadcrumbs
-	var useFlat bool
-	if p.cfg.Populate.WithFlatClassification != nil {
-		useFlat = *p.cfg.Populate.WithFlatClassification
-	}
-	return flatClsf, useFlat
+	return flatClsf, p.useFlatClassification()
 }
 
-// insertNameIndices performs bulk insert using pgx CopyFrom.
-func insertNameIndices(p *populator, records [][]any) error {
+// insertNameIndices performs bulk insert into the staging table of
+// the source using pgx CopyFrom.
+func insertNameIndices(
+	ctx context.Context,
+	p *populator,
+	sourceID int,
+	records [][]any,
+) error {
 	// Column names for CopyFrom
 	columns := []string{
 		"data_source_id", "record_id", "name_string_id",
 		"outlink_id", "global_id", "name_id", "local_id",
 		"code_id", "rank", "taxonomic_status", "accepted_record_id",
 		"classification", "classification_ids", "classification_ranks",
+		"reference_id",
 	}
 
 	_, err := p.operator.Pool().CopyFrom(
-		context.Background(),
-		pgx.Identifier{"name_string_indices"},
+		ctx,
+		stagingIdent(nameIndicesTable, sourceID),
 		columns,
 		pgx.CopyFromRows(records),
 	)
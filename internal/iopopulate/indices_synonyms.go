@@ -1,37 +1,41 @@
 package iopopulate
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log/slog"
 	"strings"
 
-	"github.com/cheggaaa/pb/v3"
 	"github.com/dustin/go-humanize"
 	"github.com/gnames/gndb/pkg/sources"
 	"github.com/gnames/gnuuid"
 )
 
 // processSynonyms processes synonym records from the SFGA synonym table.
-// Synonyms link to accepted taxa and inherit their classification.
+// Synonyms link to accepted taxa and inherit their classification. A pro
+// parte synonym gets a name index for every accepted taxon, all with the
+// same record ID (see synonymRecordID).
+// Synonyms whose accepted taxon is missing are dropped, unless dangling
+// decides to import them (see handleDanglingSynonyms).
 func (p *populator) processSynonyms(
+	ctx context.Context,
 	source *sources.DataSourceConfig,
 	hierarchy map[string]*hNode,
+	ids *recordIDs,
+	dangling *danglingSynonyms,
 ) (int, error) {
-	slog.Info("Processing synonyms", "data_source_id", source.ID)
+	p.logger().Info("Processing synonyms", "data_source_id", source.ID)
 
 	// Count total synonyms for progress bar
-	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM synonym`
-	err := p.sfgaDB.QueryRow(countQuery).Scan(&totalCount)
+	totalCount, err := p.getTotalSynonymCount(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to count synonyms: %w", err)
+		return 0, err
 	}
 
 	// Build outlink column expression if configured
 	outlinkCol := buildOutlinkColumn(source.OutlinkIDColumn, "synonyms")
 
-	rows, err := p.getSynonymData(outlinkCol)
+	rows, err := p.getSynonymData(ctx, outlinkCol, dangling.importsAny())
 	if err != nil {
 		return 0, fmt.Errorf("failed to query synonyms: %w", err)
 	}
@@ -41,9 +45,7 @@ func (p *populator) processSynonyms(
 	var count int
 
 	// Create progress bar with known total
-	bar := pb.Full.Start(totalCount)
-	bar.Set("prefix", "Processing synonyms: ")
-	bar.Set(pb.CleanOnFinish, true)
+	bar := p.newCounter(totalCount, "Processing synonyms: ")
 	defer bar.Finish()
 
 	for rows.Next() {
@@ -58,10 +60,34 @@ func (p *populator) processSynonyms(
 			continue
 		}
 
+		if !p.filter.keepSynonym(t.taxonID, t.nameID, t.rankID, t.statusID) {
+			p.filter.exclude()
+			bar.Add(1)
+			continue
+		}
+
+		recordID, ok := ids.resolve(t.synonymID, t.taxonID)
+		if !ok {
+			bar.Add(1)
+			continue
+		}
+
+		bareName, ok := dangling.toBareName(t.synonymID, t.taxonID)
+		if !ok {
+			bar.Add(1)
+			continue
+		}
+
 		if t.statusID == "" {
 			t.statusID = "SYNONYM"
 		}
 
+		// Dangling synonyms demoted to bare names accept themselves.
+		status, acceptedID := strings.ToLower(t.statusID), t.taxonID
+		if bareName {
+			status, acceptedID = "bare name", recordID
+		}
+
 		flatClsf, useFlat := p.flatClassification(t)
 
 		classification, classificationRanks, classificationIDs := getBreadcrumbs(
@@ -82,20 +108,21 @@ func (p *populator) processSynonyms(
 		}
 
 		record := []any{
-			source.ID,                   // data_source_id
-			t.synonymID,                 // record_id (col__id|col__taxon_id|name_col__id — unique per synonym-taxon-name triple)
-			nameStringID,                // name_string_id
-			outlinkID,                   // outlink_id
-			t.globalID,                  // global_id
-			t.nameID,                    // name_id
-			t.localID,                   // local_id
-			codeIDToInt(t.codeID),       // code_id
-			strings.ToLower(t.rankID),   // rank
-			strings.ToLower(t.statusID), // taxonomic_status
-			t.taxonID,                   // accepted_record_id (points to accepted taxon)
-			classification,              // classification
-			classificationIDs,           // classification_ids
-			classificationRanks,         // classification_ranks
+			source.ID,                 // data_source_id
+			recordID,                  // record_id (see synonymRecordID)
+			nameStringID,              // name_string_id
+			outlinkID,                 // outlink_id
+			t.globalID,                // global_id
+			t.nameID,                  // name_id
+			t.localID,                 // local_id
+			codeIDToInt(t.codeID),     // code_id
+			strings.ToLower(t.rankID), // rank
+			status,                    // taxonomic_status
+			acceptedID,                // accepted_record_id (points to accepted taxon)
+			classification,            // classification
+			classificationIDs,         // classification_ids
+			classificationRanks,       // classification_ranks
+			t.referenceID,             // reference_id
 		}
 
 		records = append(records, record)
@@ -105,7 +132,7 @@ func (p *populator) processSynonyms(
 		bar.Add(1)
 
 		if len(records) >= p.cfg.Database.BatchSize {
-			err = insertNameIndices(p, records)
+			err = insertNameIndices(ctx, p, source.ID, records)
 			if err != nil {
 				return 0, err
 			}
@@ -114,14 +141,14 @@ func (p *populator) processSynonyms(
 	}
 
 	if len(records) > 0 {
-		err = insertNameIndices(p, records)
+		err = insertNameIndices(ctx, p, source.ID, records)
 		if err != nil {
 			return 0, err
 		}
 	}
 
 	if count > 0 {
-		slog.Info(
+		p.logger().Info(
 			"Processed synonyms",
 			"data_source_id",
 			source.ID,
@@ -133,16 +160,36 @@ func (p *populator) processSynonyms(
 	return count, rows.Err()
 }
 
-func (p *populator) getSynonymData(outlinkCol string) (*sql.Rows, error) {
+func (p *populator) getTotalSynonymCount(ctx context.Context) (int, error) {
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM synonym`
+
+	err := p.sfgaDB.QueryRowContext(ctx, countQuery).Scan(&totalCount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count synonyms: %w", err)
+	}
+
+	return totalCount, nil
+}
+
+// getSynonymData queries synonyms with their accepted taxa. If
+// withDangling is true, synonyms whose accepted taxon is missing are
+// returned as well, with empty classification.
+func (p *populator) getSynonymData(
+	ctx context.Context,
+	outlinkCol string,
+	withDangling bool,
+) (*sql.Rows, error) {
 	// Query synonyms with their accepted taxon info
 	query := `
 		SELECT
-			COALESCE(s.col__id,'') || '|' || s.col__taxon_id || '|' || n.col__id, s.col__taxon_id, n.col__id, n.gn__scientific_name_string,
+			` + synonymRecordID + `, COALESCE(s.col__taxon_id,''), n.col__id, n.gn__scientific_name_string,
 			n.col__code_id, n.col__rank_id, s.col__status_id,
 			t.col__kingdom, t.sf__kingdom_id, t.col__phylum, t.sf__phylum_id,
 			t.col__subphylum, t.sf__subphylum_id, t.col__class, t.sf__class_id,
 			t.col__order, t.sf__order_id, t.col__family, t.sf__family_id,
-			t.col__genus, t.sf__genus_id, t.col__species, t.sf__species_id`
+			t.col__genus, t.sf__genus_id, t.col__species, t.sf__species_id,
+			COALESCE(n.col__reference_id,'')`
 
 	// Add outlink column if available
 	if outlinkCol != "" {
@@ -150,13 +197,17 @@ func (p *populator) getSynonymData(outlinkCol string) (*sql.Rows, error) {
 			` + outlinkCol
 	}
 
+	join := "JOIN"
+	if withDangling {
+		join = "LEFT JOIN"
+	}
 	query += `
 		FROM synonym s
 		JOIN name n ON n.col__id = s.col__name_id
-		JOIN taxon t ON t.col__id = s.col__taxon_id
+		` + join + ` taxon t ON t.col__id = s.col__taxon_id
 	`
 
-	return p.sfgaDB.Query(query)
+	return p.sfgaDB.QueryContext(ctx, query)
 }
 
 func (p *populator) getSynonymDatum(
@@ -173,6 +224,7 @@ func (p *populator) getSynonymDatum(
 		&t.subphylum, &t.subphylumID, &t.class, &t.classID,
 		&t.order, &t.orderID, &t.family, &t.familyID,
 		&t.genus, &t.genusID, &t.species, &t.speciesID,
+		&t.referenceID,
 	}
 
 	// Add outlink ID to scan if column was selected
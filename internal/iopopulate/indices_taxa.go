@@ -1,12 +1,11 @@
 package iopopulate
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log/slog"
 	"strings"
 
-	"github.com/cheggaaa/pb/v3"
 	"github.com/dustin/go-humanize"
 	"github.com/gnames/gndb/pkg/sources"
 	"github.com/gnames/gnuuid"
@@ -15,13 +14,15 @@ import (
 // processTaxa processes accepted taxon records from the SFGA taxon table.
 // Each taxon gets full classification via hierarchy breadcrumbs.
 func (p *populator) processTaxa(
+	ctx context.Context,
 	source *sources.DataSourceConfig,
 	hierarchy map[string]*hNode,
+	ids *recordIDs,
 ) (int, error) {
-	slog.Info("Processing taxa (accepted names)", "data_source_id", source.ID)
+	p.logger().Info("Processing taxa (accepted names)", "data_source_id", source.ID)
 
 	// Count total taxa for progress bar
-	totalCount, err := p.getTotalCount()
+	totalCount, err := p.getTotalCount(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -29,7 +30,7 @@ func (p *populator) processTaxa(
 	// Build outlink column expression if configured
 	outlinkCol := buildOutlinkColumn(source.OutlinkIDColumn, "taxa")
 
-	rows, err := p.getTaxonData(outlinkCol)
+	rows, err := p.getTaxonData(ctx, outlinkCol)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query taxa: %w", err)
 	}
@@ -40,9 +41,7 @@ func (p *populator) processTaxa(
 	var count int
 
 	// Create progress bar with known total
-	bar := pb.Full.Start(totalCount)
-	bar.Set("prefix", "Processing taxa: ")
-	bar.Set(pb.CleanOnFinish, true)
+	bar := p.newCounter(totalCount, "Processing taxa: ")
 	defer bar.Finish()
 
 	for rows.Next() {
@@ -51,6 +50,18 @@ func (p *populator) processTaxa(
 			return 0, fmt.Errorf("failed to scan taxon row: %w", err)
 		}
 
+		if !p.filter.keepTaxon(t.taxonID, t.nameID, t.rankID, t.statusID) {
+			p.filter.exclude()
+			bar.Add(1)
+			continue
+		}
+
+		recordID, ok := ids.resolve(t.taxonID, t.taxonID)
+		if !ok {
+			bar.Add(1)
+			continue
+		}
+
 		flatClsf, useFlat := p.flatClassification(t)
 
 		classification, classificationRanks, classificationIDs := getBreadcrumbs(
@@ -74,7 +85,7 @@ func (p *populator) processTaxa(
 		// Create record for bulk insert
 		record := []any{
 			source.ID,                   // data_source_id
-			t.taxonID,                   // record_id
+			recordID,                    // record_id
 			nameStringID,                // name_string_id
 			outlinkID,                   // outlink_id
 			t.globalID,                  // global_id
@@ -83,10 +94,11 @@ func (p *populator) processTaxa(
 			codeIDToInt(t.codeID),       // code_id
 			strings.ToLower(t.rankID),   // rank
 			strings.ToLower(t.statusID), // taxonomic_status
-			t.taxonID,                   // accepted_record_id (self for accepted taxa)
+			recordID,                    // accepted_record_id (self for accepted taxa)
 			classification,              // classification
 			classificationIDs,           // classification_ids
 			classificationRanks,         // classification_ranks
+			t.referenceID,               // reference_id
 		}
 
 		records = append(records, record)
@@ -97,7 +109,7 @@ func (p *populator) processTaxa(
 
 		// Bulk insert when batch is full
 		if len(records) >= p.cfg.Database.BatchSize {
-			err = insertNameIndices(p, records)
+			err = insertNameIndices(ctx, p, source.ID, records)
 			if err != nil {
 				return 0, err
 			}
@@ -107,14 +119,14 @@ func (p *populator) processTaxa(
 
 	// Insert remaining records
 	if len(records) > 0 {
-		err = insertNameIndices(p, records)
+		err = insertNameIndices(ctx, p, source.ID, records)
 		if err != nil {
 			return 0, err
 		}
 	}
 
 	if count > 0 {
-		slog.Info(
+		p.logger().Info(
 			"Processed taxa",
 			"data_source_id",
 			source.ID,
@@ -126,11 +138,11 @@ func (p *populator) processTaxa(
 	return count, rows.Err()
 }
 
-func (p *populator) getTotalCount() (int, error) {
+func (p *populator) getTotalCount(ctx context.Context) (int, error) {
 	var totalCount int
 	countQuery := `SELECT COUNT(*) FROM taxon`
 
-	err := p.sfgaDB.QueryRow(countQuery).Scan(&totalCount)
+	err := p.sfgaDB.QueryRowContext(ctx, countQuery).Scan(&totalCount)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count taxa: %w", err)
 	}
@@ -138,7 +150,10 @@ func (p *populator) getTotalCount() (int, error) {
 	return totalCount, nil
 }
 
-func (p *populator) getTaxonData(outlinkCol string) (*sql.Rows, error) {
+func (p *populator) getTaxonData(
+	ctx context.Context,
+	outlinkCol string,
+) (*sql.Rows, error) {
 	// Query taxa with flat classification fields
 	query := `
 		SELECT
@@ -148,7 +163,7 @@ func (p *populator) getTaxonData(outlinkCol string) (*sql.Rows, error) {
 			t.col__subphylum, t.sf__subphylum_id, t.col__class, t.sf__class_id,
 			t.col__order, t.sf__order_id, t.col__family, t.sf__family_id,
 			t.col__genus, t.sf__genus_id, t.col__species, t.sf__species_id,
-	    t.gn__local_id, t.gn__global_id`
+	    t.gn__local_id, t.gn__global_id, COALESCE(n.col__reference_id,'')`
 
 	// Add outlink column if available
 	if outlinkCol != "" {
@@ -161,7 +176,7 @@ func (p *populator) getTaxonData(outlinkCol string) (*sql.Rows, error) {
 		JOIN name n ON n.col__id = t.col__name_id
 	`
 
-	return p.sfgaDB.Query(query)
+	return p.sfgaDB.QueryContext(ctx, query)
 }
 
 func (p *populator) getTaxonDatum(
@@ -176,7 +191,7 @@ func (p *populator) getTaxonDatum(
 		&t.subphylum, &t.subphylumID, &t.class, &t.classID,
 		&t.order, &t.orderID, &t.family, &t.familyID,
 		&t.genus, &t.genusID, &t.species, &t.speciesID,
-		&t.localID, &t.globalID,
+		&t.localID, &t.globalID, &t.referenceID,
 	}
 
 	// Add outlink ID to scan if column was selected
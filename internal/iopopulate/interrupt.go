@@ -0,0 +1,57 @@
+package iopopulate
+
+import (
+	"context"
+	"time"
+)
+
+// cleanupTimeout limits the time spent on removing data of an interrupted
+// source, so a second Ctrl-C is not needed to stop the program.
+const cleanupTimeout = 30 * time.Second
+
+// cleanupInterrupted removes staging tables of a source whose import was
+// interrupted. Live tables are not affected: name-strings are inserted
+// in one transaction that is rolled back, and indices reach live tables
+// only at stage 6. Staging tables of finished stages are kept, together
+// with the checkpoint, so --resume continues from the last finished
+// stage.
+func (p *populator) cleanupInterrupted(ctx context.Context, sourceID int) {
+	// The run context is cancelled already.
+	ctx, cancel := context.WithTimeout(
+		context.WithoutCancel(ctx), cleanupTimeout,
+	)
+	defer cancel()
+
+	done := stageNone
+	if p.checkpoints != nil {
+		done = p.checkpoints.get(sourceID).Stage
+	}
+
+	for _, table := range unfinishedStagingTables(done) {
+		if err := p.dropStagingTable(ctx, table, sourceID); err != nil {
+			p.logger().Warn("Cannot drop staging table of interrupted source",
+				"data_source_id", sourceID,
+				"table", stagingName(table, sourceID),
+				"error", err,
+			)
+		}
+	}
+
+	p.logger().Warn("Source import was interrupted",
+		"data_source_id", sourceID,
+		"last_stage", done.String(),
+	)
+}
+
+// unfinishedStagingTables returns live tables whose staging tables may
+// contain partial data of a source that finished the given stage.
+func unfinishedStagingTables(done stage) []string {
+	var res []string
+	if done < stageIndices {
+		res = append(res, nameIndicesTable, refsTable)
+	}
+	if done < stageVernaculars {
+		res = append(res, vernIndicesTable)
+	}
+	return res
+}
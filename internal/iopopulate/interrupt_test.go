@@ -0,0 +1,43 @@
+package iopopulate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gnames/gndb/pkg/config"
+	"github.com/gnames/gndb/pkg/sources"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnfinishedStagingTables(t *testing.T) {
+	tests := []struct {
+		name string
+		done stage
+		want []string
+	}{
+		{"not started", stageNone,
+			[]string{nameIndicesTable, refsTable, vernIndicesTable}},
+		{"after hierarchy", stageHierarchy,
+			[]string{nameIndicesTable, refsTable, vernIndicesTable}},
+		{"after indices", stageIndices, []string{vernIndicesTable}},
+		{"after vernaculars", stageVernaculars, nil},
+		{"done", stageMetadata, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, unfinishedStagingTables(tt.done))
+		})
+	}
+}
+
+func TestRunSourceInterrupted(t *testing.T) {
+	p := &populator{cfg: config.New(), state: &runState{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Sources are not started after the run is interrupted.
+	res := p.runSource(ctx, sources.DataSourceConfig{ID: 1}, 0, 1)
+	assert.Equal(t, sourceSkipped, res)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log/slog"
 	"time"
 
 	"github.com/dustin/go-humanize"
@@ -20,53 +19,61 @@ import (
 // Metadata Sources:
 //  1. SFGA metadata table (p.sfgaDB): title, description, doi, citation, authors
 //  2. sources.yaml config: title_short, home_url, outlink_url, curation flags
-//  3. Database counts: name_string_indices and vernacular_string_indices
+//  3. Database counts: staged name and vernacular indices
 //  4. SFGA filename: version, revision_date
+//  5. Quality profile: staged indices and SFGA synonyms
 //
-// Uses DELETE + INSERT pattern for idempotency.
+// The record is written in the same transaction that swaps staged indices
+// into the live tables, so the source is replaced atomically.
 //
-// Returns error if SFGA query, count query, or database insert fails.
+// Returns the number of name indices added, changed and removed in the
+// live table, and an error if SFGA query, count query, or the swap fails.
 func (p *populator) updateDataSourceMetadata(
+	ctx context.Context,
 	source sources.DataSourceConfig,
 	sfgaFileMeta SFGAMetadata,
-) (string, error) {
-	slog.Info("Updating data source metadata", "data_source_id", source.ID)
+) (string, deltaStats, error) {
+	p.logger().Info("Updating data source metadata", "data_source_id", source.ID)
 
 	// Step 1: Read metadata from SFGA
-	sfgaMetadata, err := p.readSFGAMetadata()
+	sfgaMetadata, err := p.readSFGAMetadata(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to read SFGA metadata: %w", err)
+		return "", deltaStats{}, fmt.Errorf("failed to read SFGA metadata: %w", err)
 	}
 
 	// Step 2: Query record counts from database
-	recordCount, err := p.queryNameStringIndicesCount(source.ID)
+	recordCount, err := p.queryNameStringIndicesCount(ctx, source.ID)
 	if err != nil {
-		return "", fmt.Errorf("failed to query name string indices count: %w", err)
+		return "", deltaStats{}, fmt.Errorf("failed to query name string indices count: %w", err)
 	}
 
-	vernRecordCount, err := p.queryVernacularIndicesCount(source.ID)
+	vernRecordCount, err := p.queryVernacularIndicesCount(ctx, source.ID)
 	if err != nil {
-		return "", fmt.Errorf("failed to query vernacular indices count: %w", err)
+		return "", deltaStats{}, fmt.Errorf("failed to query vernacular indices count: %w", err)
+	}
+
+	// Quality profile of staged data
+	quality, err := p.queryQualityProfile(ctx, source.ID)
+	if err != nil {
+		return "", deltaStats{}, fmt.Errorf("failed to calculate quality profile: %w", err)
 	}
 
 	// Step 3: Build DataSource record merging SFGA + sources.yaml metadata
 	ds := buildDataSourceRecord(
 		source, sfgaMetadata, sfgaFileMeta, recordCount, vernRecordCount,
 	)
+	ds.Quality = quality
 
-	// Step 4: Delete existing data source record (for idempotency)
-	err = deleteDataSource(p, source.ID)
-	if err != nil {
-		return "", fmt.Errorf("failed to delete existing data source: %w", err)
-	}
-
-	// Step 5: Insert new data source record
-	err = insertDataSource(p, ds)
+	// Step 4: Replace live indices and data source record in one
+	// transaction
+	delta, err := p.swapSource(ctx, ds)
 	if err != nil {
-		return "", fmt.Errorf("failed to insert data source: %w", err)
+		return "", delta, fmt.Errorf(
+			"failed to replace source data, previous version kept: %w", err,
+		)
 	}
 
-	slog.Info("Data source metadata updated",
+	p.logger().Info("Data source metadata updated",
 		"data_source_id", source.ID,
 		"title_short", ds.TitleShort,
 		"record_count", ds.RecordCount,
@@ -79,8 +86,11 @@ func (p *populator) updateDataSourceMetadata(
 		"<em>Imported metadata and found %s total records</em>",
 		humanize.Comma(int64(totalRecords)),
 	)
+	if p.cfg.Populate.Delta {
+		msg += fmt.Sprintf("\n<em>Delta: %s</em>", delta)
+	}
 
-	return msg, nil
+	return msg, delta, nil
 }
 
 // sfgaMetadata holds metadata read from SFGA metadata table.
@@ -92,7 +102,9 @@ type sfgaMetadata struct {
 
 // readSFGAMetadata reads metadata from SFGA metadata table.
 // Returns zero values for missing/empty fields (graceful handling).
-func (p *populator) readSFGAMetadata() (*sfgaMetadata, error) {
+func (p *populator) readSFGAMetadata(
+	ctx context.Context,
+) (*sfgaMetadata, error) {
 	query := `
 		SELECT col__title, col__description, col__doi
 		FROM metadata
@@ -100,11 +112,12 @@ func (p *populator) readSFGAMetadata() (*sfgaMetadata, error) {
 	`
 
 	var meta sfgaMetadata
-	err := p.sfgaDB.QueryRow(query).Scan(&meta.Title, &meta.Description, &meta.DOI)
+	err := p.sfgaDB.QueryRowContext(ctx, query).
+		Scan(&meta.Title, &meta.Description, &meta.DOI)
 	if err != nil {
 		// If metadata table doesn't exist or is empty, return empty metadata
 		if err == sql.ErrNoRows {
-			slog.Warn("SFGA metadata table is empty, using empty metadata")
+			p.logger().Warn("SFGA metadata table is empty, using empty metadata")
 			return &sfgaMetadata{}, nil
 		}
 		return nil, fmt.Errorf("failed to query SFGA metadata: %w", err)
@@ -113,15 +126,13 @@ func (p *populator) readSFGAMetadata() (*sfgaMetadata, error) {
 	return &meta, nil
 }
 
-// queryNameStringIndicesCount queries the count of name_string_indices
-// for a given data source.
+// queryNameStringIndicesCount queries the count of staged name string
+// indices for a given data source.
 func (p *populator) queryNameStringIndicesCount(
+	ctx context.Context,
 	sourceID int,
 ) (int, error) {
-	query := `SELECT COUNT(*) FROM name_string_indices WHERE data_source_id = $1`
-
-	var count int
-	err := p.operator.Pool().QueryRow(context.Background(), query, sourceID).Scan(&count)
+	count, err := p.stagingCount(ctx, nameIndicesTable, sourceID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count name string indices: %w", err)
 	}
@@ -129,15 +140,29 @@ func (p *populator) queryNameStringIndicesCount(
 	return count, nil
 }
 
-// queryVernacularIndicesCount queries the count of vernacular_string_indices
-// for a given data source.
+// queryVernacularIndicesCount queries the count of vernacular indices
+// for a given data source. Staged vernaculars are counted if they exist,
+// otherwise the live ones, which are kept by the swap.
 func (p *populator) queryVernacularIndicesCount(
+	ctx context.Context,
 	sourceID int,
 ) (int, error) {
+	staged, err := p.stagingTableExists(ctx, vernIndicesTable, sourceID)
+	if err != nil {
+		return 0, err
+	}
+	if staged {
+		count, err := p.stagingCount(ctx, vernIndicesTable, sourceID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count vernacular indices: %w", err)
+		}
+		return count, nil
+	}
+
 	query := `SELECT COUNT(*) FROM vernacular_string_indices WHERE data_source_id = $1`
 
 	var count int
-	err := p.operator.Pool().QueryRow(context.Background(), query, sourceID).Scan(&count)
+	err = p.operator.Pool().QueryRow(ctx, query, sourceID).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count vernacular indices: %w", err)
 	}
@@ -211,10 +236,10 @@ func buildDataSourceRecord(
 // deleteDataSource deletes an existing data source record by ID.
 // This is part of the DELETE + INSERT pattern for idempotency.
 // Does not return an error if the record doesn't exist.
-func deleteDataSource(p *populator, sourceID int) error {
+func deleteDataSource(ctx context.Context, db execer, sourceID int) error {
 	query := `DELETE FROM data_sources WHERE id = $1`
 
-	_, err := p.operator.Pool().Exec(context.Background(), query, sourceID)
+	_, err := db.Exec(ctx, query, sourceID)
 	if err != nil {
 		return fmt.Errorf("failed to execute delete: %w", err)
 	}
@@ -223,21 +248,32 @@ func deleteDataSource(p *populator, sourceID int) error {
 }
 
 // insertDataSource inserts a new data source record.
-func insertDataSource(p *populator, ds schema.DataSource) error {
+func insertDataSource(
+	ctx context.Context,
+	db execer,
+	ds schema.DataSource,
+) error {
 	query := `
 		INSERT INTO data_sources (
 			id, uuid, title, title_short, version, revision_date,
 			doi, citation, authors, description,
 			website_url, data_url, outlink_url, is_outlink_ready,
 			is_curated, is_auto_curated, has_taxon_data,
-			record_count, vern_record_count, updated_at
+			record_count, vern_record_count, updated_at,
+			quality_no_parse_share, quality_clean_parse_share,
+			quality_minor_problems_share, quality_major_problems_share,
+			quality_surrogate_share, quality_hybrid_share,
+			quality_bare_name_share, quality_unresolved_synonym_share,
+			quality_classification_share, quality_vernacular_language_share
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
-			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20
+			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+			$21, $22, $23, $24, $25, $26, $27, $28, $29, $30
 		)
 	`
+	q := ds.Quality
 
-	_, err := p.operator.Pool().Exec(context.Background(), query,
+	_, err := db.Exec(ctx, query,
 		ds.ID,
 		ds.UUID,
 		ds.Title,
@@ -258,6 +294,16 @@ func insertDataSource(p *populator, ds schema.DataSource) error {
 		ds.RecordCount,
 		ds.VernRecordCount,
 		ds.UpdatedAt,
+		q.NoParseShare,
+		q.CleanParseShare,
+		q.MinorProblemsShare,
+		q.MajorProblemsShare,
+		q.SurrogateShare,
+		q.HybridShare,
+		q.BareNameShare,
+		q.UnresolvedSynonymShare,
+		q.ClassificationShare,
+		q.VernacularLanguageShare,
 	)
 
 	if err != nil {
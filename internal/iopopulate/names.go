@@ -4,60 +4,65 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log/slog"
-	"slices"
+	"io"
+	"os"
 	"strings"
 
-	"github.com/cheggaaa/pb/v3"
 	"github.com/dustin/go-humanize"
-	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/sources"
 	"github.com/gnames/gnuuid"
+	"github.com/jackc/pgx/v5"
 )
 
-type nameRecord struct {
-	gnScientificName  sql.NullString // May be NULL or empty
-	colScientificName string
-}
+// nameStringsLoadTable is a temporary table that receives name-strings
+// from COPY before they are merged into name_strings.
+const nameStringsLoadTable = "name_strings_load"
 
 // processNameStrings implements Phase 2: Name Strings import from SFGA.
-// It reads names from the SFGA name table, generates UUID v5 identifiers,
-// and inserts them into the name_strings table using batch inserts with
-// ON CONFLICT DO NOTHING for idempotency.
+// It streams names from the SFGA name table, generates UUID v5
+// identifiers, and loads them into a temporary table with COPY. The
+// temporary table is then merged into name_strings with
+// ON CONFLICT DO NOTHING for idempotency. Names are never collected in
+// memory, so memory use does not depend on the size of the source.
 //
 // Uses p.sfgaDB for SQLite queries and p.operator.Pool() for PostgreSQL
-// inserts. Prompts user if gn__scientific_name_string is empty, falling
-// back to col__scientific_name.
+// inserts. If gn__scientific_name_string is empty for some names, the
+// on_empty_name_string policy decides whether to fall back to
+// col__scientific_name.
 //
 // Returns error if:
 //   - SFGA query fails
-//   - User aborts when gn__scientific_name_string is empty
+//   - Policy for empty gn__scientific_name_string is "skip" or "abort"
+//     (errSkipSource, errAbortRun)
 //   - Database insert fails
 func (p *populator) processNameStrings(
-	sourceID int,
-) (string, error) {
-	slog.Info("Step 2/6: Processing name strings", "data_source_id", sourceID)
+	ctx context.Context,
+	source *sources.DataSourceConfig,
+) (string, int, error) {
+	sourceID := source.ID
+	p.logger().Info("Step 2/6: Processing name strings", "data_source_id", sourceID)
 
-	names, emptyGNameStr, err := p.getNames()
+	total, emptyGNameStr, err := p.countNames(ctx)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
-	err = handleEmptyGNameStr(emptyGNameStr, sourceID)
+	err = p.handleEmptyGNameStr(emptyGNameStr, source)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	var totalInserted int
-	totalInserted, err = p.insertNames(names)
+	totalInserted, err = p.insertNames(ctx, total)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	// Final log with total count
-	slog.Info("Name strings imported",
+	p.logger().Info("Name strings imported",
 		"data_source_id", sourceID,
 		"inserted", totalInserted,
-		"total_records", len(names),
+		"total_records", total,
 	)
 
 	msg := "<em>All names strings are in the database already</em>"
@@ -65,194 +70,247 @@ func (p *populator) processNameStrings(
 		msg = fmt.Sprintf("<em>Inserted %d name strings</em>", totalInserted)
 	}
 
-	return msg, nil
+	return msg, totalInserted, nil
 }
 
-func (p *populator) insertNames(
-	names []nameRecord,
-) (int, error) {
-	// Batch insert configuration
-	// PostgreSQL has a limit of 65535 parameters per query.
-	// With 2 parameters per row (id, name), max is 32767 rows.
-	// Use 30000 to stay safely under the limit.
-	const batchSize = 30000
+// insertNames streams names from SFGA into a temporary table using
+// pgx.CopyFrom and merges them into name_strings. The temporary table
+// lives only inside the transaction. The merge sorts rows by ID, so
+// sources imported in parallel lock rows in the same order and cannot
+// deadlock each other. If the source has filters, only name-strings of
+// kept records are loaded. Returns the number of new name-strings.
+func (p *populator) insertNames(ctx context.Context, total int) (int, error) {
+	query := `
+		SELECT gn__scientific_name_string, col__scientific_name
+		FROM name
+	`
+	var keep func(string) bool
+	if p.filter != nil {
+		query = `
+			SELECT gn__scientific_name_string, col__scientific_name, col__id
+			FROM name
+		`
+		keep = p.filter.keepNameString
+	}
 
-	var totalInserted int
+	rows, err := p.sfgaDB.QueryContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query SFGA name table: %w", err)
+	}
+	defer rows.Close()
+
+	tx, err := p.operator.Pool().Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start name strings transaction: %w", err)
+	}
+	// Rolled back also when ctx is cancelled.
+	defer tx.Rollback(context.WithoutCancel(ctx)) //nolint:errcheck // no-op after commit
+
+	// Same column types as name_strings, but no constraints
+	q := fmt.Sprintf(
+		`CREATE TEMP TABLE %s ON COMMIT DROP AS
+		 SELECT id, name FROM name_strings WITH NO DATA`,
+		nameStringsLoadTable,
+	)
+	if _, err = tx.Exec(ctx, q); err != nil {
+		return 0, fmt.Errorf("failed to create temporary table: %w", err)
+	}
 
 	// Create progress bar for processing names
-	bar := pb.Full.Start(len(names))
-	bar.Set("prefix", "Processing names: ")
-	bar.Set(pb.CleanOnFinish, true)
-
-	// Process names in batches
-	for i := 0; i < len(names); i += batchSize {
-		end := i + batchSize
-		end = slices.Min([]int{end, len(names)})
-
-		batch := names[i:end]
-
-		// Build parameterized INSERT with ON CONFLICT DO NOTHING
-		// This handles duplicate UUIDs gracefully (same name from multiple sources)
-		var valueStrings []string
-		var valueArgs []any
-		argIdx := 1
-
-		for _, rec := range batch {
-			// Determine which name to use
-			nameString := rec.colScientificName
-			if rec.gnScientificName.Valid &&
-				strings.TrimSpace(rec.gnScientificName.String) != "" {
-				nameString = strings.TrimSpace(rec.gnScientificName.String)
-			}
+	bar := p.newCounter(total, "Processing names: ")
+	defer bar.Finish()
 
-			// Generate UUID v5 using gnuuid (deterministic)
-			uuid := gnuuid.New(nameString).String()
+	src := &nameStringSource{
+		rows:  rows,
+		keep:  keep,
+		onRow: func() { bar.Increment() },
+	}
+	_, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{nameStringsLoadTable},
+		[]string{"id", "name"},
+		src,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy name strings: %w", err)
+	}
 
-			// Add to batch
-			// ($1, $2), ($3, $4), ...
-			valueStrings = append(
-				valueStrings,
-				fmt.Sprintf("($%d, $%d)", argIdx, argIdx+1),
-			)
-			valueArgs = append(valueArgs, uuid, nameString)
-			argIdx += 2
-		}
+	q = fmt.Sprintf(
+		`INSERT INTO name_strings (id, name)
+		 SELECT id, name FROM %s ORDER BY id
+		 ON CONFLICT (id) DO NOTHING`,
+		nameStringsLoadTable,
+	)
+	result, err := tx.Exec(ctx, q)
+	if err != nil {
+		return 0, fmt.Errorf("failed to merge name strings: %w", err)
+	}
 
-		// Build and execute INSERT statement
-		insertQuery := fmt.Sprintf(
-			`INSERT INTO name_strings (id, name) VALUES %s
-			 ON CONFLICT (id) DO NOTHING`,
-			strings.Join(valueStrings, ", "),
-		)
-
-		result, err := p.operator.Pool().Exec(
-			context.Background(),
-			insertQuery,
-			valueArgs...,
-		)
-		if err != nil {
-			return 0, fmt.Errorf("failed to insert name strings batch: %w", err)
-		}
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit name strings: %w", err)
+	}
 
-		rowsAffected := result.RowsAffected()
-		totalInserted += int(rowsAffected)
+	return int(result.RowsAffected()), nil
+}
 
-		// Update progress bar
-		bar.Add(len(batch))
-	}
+// nameStringSource implements pgx.CopyFromSource on top of SFGA name
+// rows. Every row is converted to a name-string and its UUID v5 on the
+// fly, so only one row is kept in memory at a time.
+type nameStringSource struct {
+	rows *sql.Rows
 
-	// Finish progress bar before printing final stats
-	bar.Finish()
+	// keep, if set, receives the name ID of a row (the third column)
+	// and returns false for rows that are not copied.
+	keep func(id string) bool
 
-	return totalInserted, nil
+	onRow  func()
+	values []any
+	err    error
 }
 
-func handleEmptyGNameStr(emptyGNameStr, sourceID int) error {
-	// If there are empty gn__scientific_name_string values, prompt user
-	if emptyGNameStr > 0 {
-		fmt.Println()
-		gn.Warn(
-			"<em>Warning</em>: gn__scientific_name_string is empty "+
-				"for %s records.\n",
-			humanize.Comma(int64(emptyGNameStr)),
-		)
-		fmt.Println(
-			"Falling back to col__scientific_name may lose authorship data.",
-		)
-		fmt.Println()
-		fmt.Println("Options:")
-		fmt.Println("  [Y]es    - Continue with fallback (default)")
-		fmt.Println("  [N]o     - Skip this data source")
-		fmt.Println("  [A]bort  - Cancel entire import")
-		fmt.Println()
-
-		response, err := promptUserMulti(
-			"Your choice [Y/n/a]: ",
-			[]string{"yes", "no", "abort"},
-		)
-		if err != nil {
-			return fmt.Errorf("failed to get user response: %w", err)
+// Next advances to the next SFGA name row that passes keep.
+func (s *nameStringSource) Next() bool {
+	for s.rows.Next() {
+		var gnName sql.NullString
+		var colName, id string
+		dest := []any{&gnName, &colName}
+		if s.keep != nil {
+			dest = append(dest, &id)
+		}
+		if err := s.rows.Scan(dest...); err != nil {
+			s.err = fmt.Errorf("failed to scan SFGA name row: %w", err)
+			return false
+		}
+
+		if s.keep != nil && !s.keep(id) {
+			if s.onRow != nil {
+				s.onRow()
+			}
+			continue
 		}
 
-		switch response {
-		case "yes":
-			slog.Info(
-				"User chose to continue with fallback to col__scientific_name",
-				"data_source_id",
-				sourceID,
-			)
-		case "no":
-			slog.Info("User chose to skip this source", "data_source_id", sourceID)
-			return nil // Skip this source, continue with next
-		case "abort":
-			return fmt.Errorf("user aborted populate run")
+		name := pickNameString(gnName, colName)
+		// Generate UUID v5 using gnuuid (deterministic)
+		s.values = []any{gnuuid.New(name).String(), name}
+
+		if s.onRow != nil {
+			s.onRow()
 		}
+		return true
 	}
-	return nil
+	return false
 }
 
-func (p *populator) getNames() ([]nameRecord, int, error) {
-	// Query SFGA name table
-	// gn__scientific_name_string is preferred (always includes authorship)
-	// col__scientific_name is fallback (might keep authorship in
-	// col__authorship)
-	query := `
-		SELECT gn__scientific_name_string, col__scientific_name
-		FROM name
-	`
+// Values returns the ID and the name-string of the current row.
+func (s *nameStringSource) Values() ([]any, error) {
+	return s.values, nil
+}
 
-	rows, err := p.sfgaDB.Query(query)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to query SFGA name table: %w", err)
+// Err returns an error that stopped the iteration, if any.
+func (s *nameStringSource) Err() error {
+	if s.err != nil {
+		return s.err
 	}
-	defer rows.Close()
-
-	// First pass: collect all names and check for empty gn__scientific_name_string
-	var names []nameRecord
-	emptyCount := 0
+	if err := s.rows.Err(); err != nil {
+		return fmt.Errorf("error iterating SFGA name rows: %w", err)
+	}
+	return nil
+}
 
-	for rows.Next() {
-		var rec nameRecord
-		err := rows.Scan(&rec.gnScientificName, &rec.colScientificName)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan SFGA name row: %w", err)
-		}
+// pickNameString returns gn__scientific_name_string if it is given,
+// because it always includes authorship. Otherwise it falls back to
+// col__scientific_name.
+func pickNameString(gnName sql.NullString, colName string) string {
+	if gnName.Valid && strings.TrimSpace(gnName.String) != "" {
+		return strings.TrimSpace(gnName.String)
+	}
+	return colName
+}
 
-		// Check if gn__scientific_name_string is empty
-		if !rec.gnScientificName.Valid ||
-			strings.TrimSpace(rec.gnScientificName.String) == "" {
-			emptyCount++
-		}
+// handleEmptyGNameStr decides what to do with a source that has names
+// with empty gn__scientific_name_string, according to the
+// on_empty_name_string policy (see ask). It returns errSkipSource or
+// errAbortRun if the source or the whole run should stop.
+func (p *populator) handleEmptyGNameStr(
+	emptyGNameStr int,
+	source *sources.DataSourceConfig,
+) error {
+	if emptyGNameStr == 0 {
+		return nil
+	}
 
-		names = append(names, rec)
+	q := emptyNameQuestion(humanize.Comma(int64(emptyGNameStr)))
+	response, err := p.ask(
+		source.ID, q,
+		p.cfg.Populate.OnEmptyNameString, source.OnEmptyNameString,
+	)
+	if err != nil {
+		return err
+	}
+	p.addWarning(fmt.Sprintf(
+		"%s names have empty gn__scientific_name_string, "+
+			"on_empty_name_string: %s",
+		humanize.Comma(int64(emptyGNameStr)), response,
+	))
+
+	switch response {
+	case sources.OnEmptyNameSkip:
+		return errSkipSource
+	case sources.OnEmptyNameAbort:
+		return errAbortRun
+	default:
+		return nil
 	}
+}
+
+// countNames returns the number of names in SFGA and the number of names
+// with empty gn__scientific_name_string.
+func (p *populator) countNames(ctx context.Context) (int, int, error) {
+	query := `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (
+				WHERE gn__scientific_name_string IS NULL
+				OR trim(gn__scientific_name_string) = ''
+			)
+		FROM name
+	`
 
-	if err = rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating SFGA name rows: %w", err)
+	var total, emptyCount int
+	err := p.sfgaDB.QueryRowContext(ctx, query).Scan(&total, &emptyCount)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count SFGA names: %w", err)
 	}
-	return names, emptyCount, nil
+	return total, emptyCount, nil
 }
 
 // promptUserMulti displays a message and reads user input with multiple
 // options. The first option in validOptions is the default (used on empty
 // input). Accepts both full words and single-letter shortcuts
-// (e.g., "yes"/"y", "no"/"n", "abort"/"a").
+// (e.g., "yes"/"y", "no"/"n", "abort"/"a"). The message is printed to
+// stderr, where progress of the terminal goes, so stdout stays clean for
+// JSON progress events.
 func promptUserMulti(message string, validOptions []string) (string, error) {
 	if len(validOptions) == 0 {
 		return "", fmt.Errorf("no valid options provided")
 	}
 
-	fmt.Print(message)
+	fmt.Fprint(os.Stderr, message)
+	return readOption(os.Stdin, validOptions)
+}
 
+// readOption reads one of validOptions from r, see promptUserMulti.
+func readOption(r io.Reader, validOptions []string) (string, error) {
 	var response string
-	// Scanln returns error on empty input, but we want to allow that as default
-	_, err := fmt.Scanln(&response)
-	if err != nil && err.Error() != "unexpected newline" {
+	// Fscanln returns error on empty input, but we want to allow that as
+	// default
+	_, err := fmt.Fscanln(r, &response)
+	if err != nil && err.Error() == "unexpected newline" {
 		// Empty input - use first option as default
 		return validOptions[0], nil
 	}
 	if err != nil {
-		// Real error
+		// Real error, for example EOF of closed stdin
 		return "", err
 	}
 
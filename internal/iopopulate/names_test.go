@@ -0,0 +1,135 @@
+package iopopulate
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gnames/gnuuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "modernc.org/sqlite" // Pure Go SQLite driver (no CGo)
+)
+
+func TestPickNameString(t *testing.T) {
+	tests := []struct {
+		name    string
+		gnName  sql.NullString
+		colName string
+		want    string
+	}{
+		{
+			name:    "gn name is preferred",
+			gnName:  sql.NullString{String: "Aus bus L.", Valid: true},
+			colName: "Aus bus",
+			want:    "Aus bus L.",
+		},
+		{
+			name:    "gn name is trimmed",
+			gnName:  sql.NullString{String: "  Aus bus L. ", Valid: true},
+			colName: "Aus bus",
+			want:    "Aus bus L.",
+		},
+		{
+			name:    "null gn name falls back",
+			gnName:  sql.NullString{},
+			colName: "Aus bus",
+			want:    "Aus bus",
+		},
+		{
+			name:    "blank gn name falls back",
+			gnName:  sql.NullString{String: "   ", Valid: true},
+			colName: "Aus bus",
+			want:    "Aus bus",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, pickNameString(tt.gnName, tt.colName))
+		})
+	}
+}
+
+func TestNameStringSource(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses SQLite in short mode")
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE name (
+		gn__scientific_name_string TEXT, col__scientific_name TEXT
+	)`)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`INSERT INTO name VALUES
+		('Aus bus L.', 'Aus bus'),
+		(NULL, 'Cus dus'),
+		('', 'Eus fus')`)
+	require.NoError(t, err)
+
+	p := &populator{sfgaDB: db}
+	total, empty, err := p.countNames(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, 2, empty)
+
+	rows, err := db.Query(`SELECT gn__scientific_name_string,
+		col__scientific_name FROM name`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var count int
+	src := &nameStringSource{rows: rows, onRow: func() { count++ }}
+
+	var names []string
+	for src.Next() {
+		vals, err := src.Values()
+		require.NoError(t, err)
+		require.Len(t, vals, 2)
+
+		name := vals[1].(string)
+		assert.Equal(t, gnuuid.New(name).String(), vals[0])
+		names = append(names, name)
+	}
+	require.NoError(t, src.Err())
+
+	assert.Equal(t, []string{"Aus bus L.", "Cus dus", "Eus fus"}, names)
+	assert.Equal(t, 3, count)
+}
+
+func TestReadOption(t *testing.T) {
+	options := []string{"drop", "bare-name", "keep"}
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "empty input is the default", input: "\n", want: "drop"},
+		{name: "full word", input: "keep\n", want: "keep"},
+		{name: "first letter", input: "B\n", want: "bare-name"},
+		{name: "closed stdin", input: "", wantErr: io.EOF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readOption(strings.NewReader(tt.input), options)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := readOption(strings.NewReader("maybe\n"), options)
+	assert.Error(t, err)
+}
@@ -0,0 +1,222 @@
+package iopopulate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gnames/gndb/pkg/sources"
+)
+
+// promptMu makes sure only one source at a time asks the user a question
+// when several sources are imported in parallel.
+var promptMu sync.Mutex
+
+// errSkipSource is returned when a policy decides to skip a data source.
+var errSkipSource = errors.New("data source skipped by policy")
+
+// errAbortRun is returned when a policy decides to stop the whole run.
+var errAbortRun = errors.New("populate run aborted by policy")
+
+// Origins of a policy decision, shown in the log and the run summary.
+const (
+	policyFromFlag    = "command line"
+	policyFromSource  = "sources.yaml"
+	policyFromDefault = "non-interactive default"
+	policyFromUser    = "user answer"
+)
+
+// question is a choice populate has to make during an import of a data
+// source. All questions are answered by the same rules (see ask), so
+// a new prompt only needs a question, a CLI flag value and an optional
+// sources.yaml value.
+type question struct {
+	// key is the name of the setting, e.g. "on_empty_name_string".
+	key string
+
+	// text is shown to the user before the options.
+	text string
+
+	// options are the allowed answers, the first one is the default.
+	options []string
+
+	// help describes every option for the user, in the order of options.
+	help []string
+}
+
+// decision records how a question was answered for a data source.
+type decision struct {
+	sourceID int
+	key      string
+	answer   string
+	origin   string
+}
+
+// runState is shared by all data sources of a populate run, including
+// sources imported in parallel.
+type runState struct {
+	mu        sync.Mutex
+	decisions []decision
+	aborted   atomic.Bool
+}
+
+// addDecision remembers a decision for the run summary.
+func (r *runState) addDecision(d decision) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decisions = append(r.decisions, d)
+}
+
+// getDecisions returns a copy of all decisions of the run.
+func (r *runState) getDecisions() []decision {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]decision(nil), r.decisions...)
+}
+
+// ask answers a question for a data source. The answer comes from,
+// in order of precedence:
+//  1. flagValue, set by a CLI flag for all sources;
+//  2. sourceValue, set for the source in sources.yaml;
+//  3. the default option, if populate runs with --non-interactive;
+//  4. the user, who is asked on the terminal.
+//
+// Every decision is logged and kept for the run summary.
+func (p *populator) ask(
+	sourceID int,
+	q question,
+	flagValue, sourceValue string,
+) (string, error) {
+	var answer, origin string
+
+	switch {
+	case flagValue != "":
+		answer, origin = flagValue, policyFromFlag
+	case sourceValue != "":
+		answer, origin = sourceValue, policyFromSource
+	case p.cfg.Populate.NonInteractive:
+		answer, origin = q.options[0], policyFromDefault
+	default:
+		var err error
+		answer, err = p.promptQuestion(q)
+		if err != nil {
+			return "", err
+		}
+		origin = policyFromUser
+	}
+
+	p.logger().Info("Populate policy applied",
+		"data_source_id", sourceID,
+		"policy", q.key,
+		"value", answer,
+		"origin", origin,
+	)
+	if origin != policyFromUser {
+		p.message("<em>Policy %s=%s (%s)</em>", q.key, answer, origin)
+	}
+	if p.state != nil {
+		p.state.addDecision(decision{
+			sourceID: sourceID,
+			key:      q.key,
+			answer:   answer,
+			origin:   origin,
+		})
+	}
+
+	return answer, nil
+}
+
+// promptQuestion shows a question with its options and reads the answer.
+// Prompts go to stderr, so they do not break JSON progress on stdout.
+// Parallel imports must not ask questions at the same time.
+func (p *populator) promptQuestion(q question) (string, error) {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
+	fmt.Fprintln(os.Stderr)
+	p.warn("%s", q.text)
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Options:")
+	shortcuts := make([]string, len(q.options))
+	for i, opt := range q.options {
+		help := q.help[i]
+		shortcuts[i] = opt[:1]
+		if i == 0 {
+			help += " (default)"
+			shortcuts[i] = strings.ToUpper(opt[:1])
+		}
+		fmt.Fprintf(os.Stderr, "  [%s]%-8s - %s\n",
+			strings.ToUpper(opt[:1]), opt[1:], help)
+	}
+	fmt.Fprintln(os.Stderr)
+
+	response, err := promptUserMulti(
+		fmt.Sprintf("Your choice [%s]: ", strings.Join(shortcuts, "/")),
+		q.options,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user response: %w", err)
+	}
+	return response, nil
+}
+
+// emptyNameQuestion is asked when some names of a source have empty
+// gn__scientific_name_string.
+func emptyNameQuestion(emptyCount string) question {
+	return question{
+		key: "on_empty_name_string",
+		text: fmt.Sprintf(
+			"<em>Warning</em>: gn__scientific_name_string is empty "+
+				"for %s records.\n"+
+				"Falling back to col__scientific_name may lose authorship data.",
+			emptyCount,
+		),
+		options: sources.OnEmptyNamePolicies,
+		help: []string{
+			"Continue with col__scientific_name",
+			"Skip this data source",
+			"Cancel entire import",
+		},
+	}
+}
+
+// duplicateIDQuestion is asked when name indices of a source have
+// duplicate record IDs.
+func duplicateIDQuestion(dupCount string) question {
+	return question{
+		key: "on_duplicate_record_id",
+		text: fmt.Sprintf(
+			"<em>Warning</em>: %s record IDs occur more than once.\n"+
+				"They cannot be imported as they are.",
+			dupCount,
+		),
+		options: sources.OnDuplicatePolicies,
+		help: []string{
+			"Fail the import of this data source",
+			"Keep the first record of every ID",
+			"Add -dupN suffix to repeated IDs",
+		},
+	}
+}
+
+// danglingSynonymQuestion is asked when synonyms of a source refer to
+// missing accepted taxa.
+func danglingSynonymQuestion(count string) question {
+	return question{
+		key: "on_dangling_synonym",
+		text: fmt.Sprintf(
+			"<em>Warning</em>: %s synonyms refer to missing accepted taxa.\n"+
+				"Their accepted names cannot be shown.",
+			count,
+		),
+		options: sources.OnDanglingPolicies,
+		help: []string{
+			"Do not import these synonyms",
+			"Import them as bare names",
+			"Import them as synonyms of missing taxa",
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package iopopulate
+
+import (
+	"testing"
+
+	"github.com/gnames/gndb/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsk(t *testing.T) {
+	tests := []struct {
+		name           string
+		nonInteractive bool
+		flagValue      string
+		sourceValue    string
+		wantAnswer     string
+		wantOrigin     string
+	}{
+		{
+			name:           "flag wins over source",
+			nonInteractive: true,
+			flagValue:      "skip",
+			sourceValue:    "abort",
+			wantAnswer:     "skip",
+			wantOrigin:     policyFromFlag,
+		},
+		{
+			name:           "source wins over default",
+			nonInteractive: true,
+			sourceValue:    "abort",
+			wantAnswer:     "abort",
+			wantOrigin:     policyFromSource,
+		},
+		{
+			name:           "non-interactive uses first option",
+			nonInteractive: true,
+			wantAnswer:     "fallback",
+			wantOrigin:     policyFromDefault,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Populate.NonInteractive = tt.nonInteractive
+			p := &populator{cfg: cfg, state: &runState{}}
+
+			answer, err := p.ask(
+				7, emptyNameQuestion("10"), tt.flagValue, tt.sourceValue,
+			)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAnswer, answer)
+
+			decisions := p.state.getDecisions()
+			require.Len(t, decisions, 1)
+			assert.Equal(t, decision{
+				sourceID: 7,
+				key:      "on_empty_name_string",
+				answer:   tt.wantAnswer,
+				origin:   tt.wantOrigin,
+			}, decisions[0])
+		})
+	}
+}
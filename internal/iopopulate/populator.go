@@ -5,66 +5,156 @@
 package iopopulate
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/dustin/go-humanize"
-	"github.com/gnames/gn"
+	"github.com/gnames/gndb/internal/iocache"
+	"github.com/gnames/gndb/internal/ioreport"
+	"github.com/gnames/gndb/internal/ios3"
 	"github.com/gnames/gndb/internal/iosources"
 	"github.com/gnames/gndb/pkg/config"
 	"github.com/gnames/gndb/pkg/db"
 	"github.com/gnames/gndb/pkg/gndb"
+	"github.com/gnames/gndb/pkg/report"
 	"github.com/gnames/gndb/pkg/sources"
 	"github.com/gnames/gnfmt"
+	"golang.org/x/sync/errgroup"
 )
 
 // populator implements the Populator interface.
 type populator struct {
-	cfg      *config.Config
-	operator db.Operator
-	sfgaDB   *sql.DB
+	cfg         *config.Config
+	operator    db.Operator
+	sfgaDB      *sql.DB
+	checkpoints *checkpoints
+
+	// cache keeps downloaded SFGA archives between runs.
+	cache *iocache.Cache
+
+	// s3 lists s3:// parents of data sources.
+	s3 *ios3.Client
+
+	// progress receives progress events, see emit().
+	progress gndb.ProgressSink
+
+	// sourceID is the data source being imported, it is added to
+	// progress events.
+	sourceID int
+
+	// log is the logger of the populator, see logger().
+	log *slog.Logger
+
+	// state keeps policy decisions of the run, see ask().
+	state *runState
+
+	// runReport is the report of the run, shared by all sources.
+	runReport *report.Report
+
+	// srcReport collects the report of the source being imported.
+	srcReport *report.Source
+
+	// filter selects records of the source being imported, it is nil
+	// if the source has no filters.
+	filter *recordFilter
 }
 
-// New creates a new Populator.
-func New(cfg *config.Config, op db.Operator) gndb.Populator {
-	return &populator{cfg: cfg, operator: op}
+// New creates a new Populator. Progress of the import is sent to
+// progress, or to the terminal if it is nil.
+func New(
+	cfg *config.Config,
+	op db.Operator,
+	progress gndb.ProgressSink,
+) gndb.Populator {
+	return &populator{
+		cfg:      cfg,
+		operator: op,
+		progress: progress,
+		cache:    iocache.New(cfg),
+		s3:       ios3.New(cfg.S3),
+		state:    &runState{},
+	}
 }
 
 // Populate imports data from SFGA sources into the database.
 // Orchestrates all phases: SFGA fetch, metadata, names, hierarchy,
-// indices, and vernaculars.
-func (p *populator) Populate() error {
+// indices, and vernaculars. With DryRun sources are only checked
+// (see dryRun) and the database is not used.
+//
+// When ctx is cancelled, the source being imported is cleaned up (see
+// cleanupInterrupted), remaining sources are skipped and CancelledError
+// is returned.
+func (p *populator) Populate(ctx context.Context) (err error) {
+	startTime := time.Now()
+
+	// Dry run does not change the database and has its own summary
+	if !p.cfg.Populate.DryRun {
+		p.runReport = report.New("populate", p.cfg)
+		defer func() { ioreport.Save(p.runReport, p.cfg, err) }()
+	}
+
+	sourcesToProcess, err := p.loadSources()
+	if err != nil {
+		return err
+	}
+
+	// Dry run does not need the database
+	if p.cfg.Populate.DryRun {
+		return p.dryRun(ctx, sourcesToProcess)
+	}
+
 	pool := p.operator.Pool()
 	if pool == nil {
 		return NotConnectedError()
 	}
+	p.logger().Info("Starting database population")
 
-	startTime := time.Now()
-	slog.Info("Starting database population")
-
-	// Load sources.yaml from config directory
-	src := iosources.New(p.cfg)
-	sourcesConfig, err := src.Load()
-	if err != nil {
+	if err = p.initCheckpoints(sourcesToProcess); err != nil {
 		return err
 	}
 
-	sourcesToProcess, err := p.collectSources(sourcesConfig)
-	if err != nil {
-		return err
+	if p.isParallel() {
+		// Remove files left by previous runs once, sources then work
+		// in their own subdirectories.
+		if _, err = prepareCacheDir(p.cfg.HomeDir); err != nil {
+			return CacheError("prepare cache directory", err)
+		}
 	}
 
-	if err = p.processSources(sourcesToProcess, startTime); err != nil {
+	if err = p.processSources(ctx, sourcesToProcess, startTime); err != nil {
 		return err
 	}
 
+	if !p.cfg.Populate.File.IsEmpty() {
+		p.offerToSaveSource(sourcesToProcess[0])
+	}
+
 	return nil
 }
 
+// loadSources returns data sources of the run. They come from
+// sources.yaml, or from the file given with --file.
+func (p *populator) loadSources() ([]sources.DataSourceConfig, error) {
+	if !p.cfg.Populate.File.IsEmpty() {
+		return p.fileSource()
+	}
+
+	// Load sources.yaml from config directory
+	src := iosources.New(p.cfg)
+	sourcesConfig, err := src.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.collectSources(sourcesConfig)
+}
+
 func (p *populator) collectSources(
 	sourcesConfig *sources.SourcesConfig,
 ) ([]sources.DataSourceConfig, error) {
@@ -73,7 +163,7 @@ func (p *populator) collectSources(
 	if len(p.cfg.Populate.SourceIDs) == 0 {
 		// Empty means process all sources
 		sourcesToProcess = sourcesConfig.DataSources
-		slog.Info("Processing all sources",
+		p.logger().Info("Processing all sources",
 			"count", len(sourcesToProcess))
 	} else {
 		// Filter to requested IDs
@@ -98,95 +188,376 @@ func (p *populator) collectSources(
 		}
 		msg := fmt.Sprintf("Processing %d %s",
 			len(sourcesToProcess), sources)
-		gn.Info(msg)
+		p.info(msg)
+	}
+	return p.pinRelease(sourcesToProcess), nil
+}
+
+// pinRelease applies --release-date and --release-version to the only
+// source of the run. They replace the release pinned in sources.yaml,
+// so the file of this release is imported. With several sources the
+// flags are ignored.
+func (p *populator) pinRelease(
+	srcs []sources.DataSourceConfig,
+) []sources.DataSourceConfig {
+	date := p.cfg.Populate.ReleaseDate
+	version := p.cfg.Populate.ReleaseVersion
+	if date == "" && version == "" {
+		return srcs
+	}
+	if len(srcs) != 1 {
+		p.warn(
+			"Release date and version flags are ignored for %d sources",
+			len(srcs),
+		)
+		return srcs
+	}
+
+	src := srcs[0]
+	if date != "" {
+		src.ReleaseDate = date
+	}
+	if version != "" {
+		src.Version = version
+	}
+	p.logger().Info("Pinned release",
+		"data_source_id", src.ID,
+		"release_date", src.ReleaseDate,
+		"version", src.Version,
+	)
+	return []sources.DataSourceConfig{src}
+}
+
+// initCheckpoints loads the populate state from the cache directory.
+// With --resume the saved checkpoints are kept, otherwise checkpoints of
+// the sources selected for this run are cleared, so they are imported
+// from stage 1.
+func (p *populator) initCheckpoints(
+	sourcesToProcess []sources.DataSourceConfig,
+) error {
+	path := checkpointsPath(p.cfg.HomeDir)
+	cps, err := loadCheckpoints(path, databaseKey(p.cfg.Database))
+	if err != nil {
+		return CheckpointError("load populate state", err)
+	}
+	p.checkpoints = cps
+
+	if p.cfg.Populate.Resume {
+		p.info(
+			"Resuming populate run started at <em>%s</em>",
+			cps.StartedAt.Format(time.DateTime),
+		)
+		p.logger().Info("Resuming populate run",
+			"state_file", path,
+			"started_at", cps.StartedAt,
+		)
+		return nil
+	}
+
+	cps.StartedAt = time.Now()
+	for _, src := range sourcesToProcess {
+		delete(cps.Sources, src.ID)
+	}
+	if err = cps.save(); err != nil {
+		return CheckpointError("save populate state", err)
+	}
+	return nil
+}
+
+// markStage records that a source finished a stage. Failure to save the
+// state does not affect the import itself, so it is only logged.
+func (p *populator) markStage(sourceID int, s stage, sfgaFile string) {
+	err := p.checkpoints.mark(sourceID, s, sfgaFile)
+	if err != nil {
+		p.logger().Warn("Cannot save populate checkpoint",
+			"data_source_id", sourceID,
+			"stage", s.String(),
+			"error", err,
+		)
+	}
+}
+
+// sourceResult is the outcome of importing one data source.
+type sourceResult int
+
+const (
+	sourceSucceeded sourceResult = iota
+	sourceFailed
+	sourceSkipped
+)
+
+// status returns the status of the result in progress events.
+func (r sourceResult) status() string {
+	switch r {
+	case sourceSucceeded:
+		return gndb.StatusSucceeded
+	case sourceFailed:
+		return gndb.StatusFailed
+	default:
+		return gndb.StatusSkipped
 	}
-	return sourcesToProcess, nil
 }
 
 func (p *populator) processSources(
+	ctx context.Context,
 	sourcesToProcess []sources.DataSourceConfig,
 	startTime time.Time,
 ) error {
 	// Process each source
 	successCount := 0
 	errorCount := 0
+	skippedCount := 0
 
-	for i, source := range sourcesToProcess {
-		sourceStartTime := time.Now()
-
-		fmt.Println() // Blank line between sources
-		fmt.Println(strings.Repeat("─", 60))
-		msg := fmt.Sprintf("Data Source [%d]: %s",
-			source.ID, source.TitleShort)
-		gn.Info(msg)
-		fmt.Println(strings.Repeat("─", 60))
-
-		slog.Info("Processing source",
-			"index", i+1,
-			"total", len(sourcesToProcess),
-			"data_source_id", source.ID,
-			"title", source.TitleShort,
-		)
-
-		// Process this source through all phases
-		err := p.processSource(source)
-		if err != nil {
+	count := func(res sourceResult) {
+		switch res {
+		case sourceSucceeded:
+			successCount++
+		case sourceFailed:
 			errorCount++
-			slog.Error("Failed to process source",
-				"data_source_id", source.ID,
-				"title", source.TitleShort,
-				"error", err,
-			)
-			gn.PrintErrorMessage(err)
-			// Continue with next source instead of failing
-			continue
+		case sourceSkipped:
+			skippedCount++
 		}
+	}
 
-		successCount++
-		sourceDuration := time.Since(sourceStartTime)
-		slog.Info("Source processed successfully",
-			"data_source_id", source.ID,
-			"title", source.TitleShort,
-			"duration", gnfmt.TimeString(sourceDuration.Seconds()),
-		)
-
-		msg = fmt.Sprintf("Completed in %s",
-			gnfmt.TimeString(sourceDuration.Seconds()))
-		gn.Info(msg)
+	if p.isParallel() {
+		for _, res := range p.processParallel(ctx, sourcesToProcess) {
+			count(res)
+		}
+	} else {
+		for i, source := range sourcesToProcess {
+			count(p.runSource(ctx, source, i, len(sourcesToProcess)))
+		}
 	}
 
 	// Summary
 	totalDuration := time.Since(startTime)
-	slog.Info("Population complete",
+	p.logger().Info("Population complete",
 		"success", successCount,
 		"errors", errorCount,
+		"skipped", skippedCount,
 		"total", len(sourcesToProcess),
 		"duration", gnfmt.TimeString(totalDuration.Seconds()),
 	)
-	fmt.Println(strings.Repeat("─", 60))
-	fmt.Println() // Blank line between sources
-	gn.Info(`Population complete
-Sources succeded: %d, failed %d, total %d.
+	p.emit(gndb.Event{
+		Type:        gndb.EventSummary,
+		Count:       successCount,
+		Total:       len(sourcesToProcess),
+		DurationSec: totalDuration.Seconds(),
+		Message: fmt.Sprintf(`Population complete
+Sources succeded: %d, failed %d, skipped %d, total %d.
 		Elapsed time: <em>%s</em>
 `,
-		successCount,
-		errorCount,
-		len(sourcesToProcess),
-		gnfmt.TimeString(totalDuration.Seconds()),
-	)
+			successCount,
+			errorCount,
+			skippedCount,
+			len(sourcesToProcess),
+			gnfmt.TimeString(totalDuration.Seconds()),
+		),
+	})
+
+	p.reportDecisions()
+
+	if err := ctx.Err(); err != nil {
+		return CancelledError(err)
+	}
+
+	if p.state.aborted.Load() {
+		return AbortedError()
+	}
 
 	if errorCount > 0 && successCount == 0 {
 		return AllSourcesFailedError(errorCount)
 	}
 
 	if errorCount > 0 {
-		slog.Warn("Some sources failed to process",
+		p.logger().Warn("Some sources failed to process",
 			"failed", errorCount,
 			"succeeded", successCount)
 	}
 	return nil
 }
 
+// processParallel imports up to ParallelSources data sources at the same
+// time. Every source is handled by its own populator (see forSource), so
+// SFGA files, SQLite handles and cache subdirectories are not shared.
+// Results are returned in the order of sourcesToProcess.
+func (p *populator) processParallel(
+	ctx context.Context,
+	sourcesToProcess []sources.DataSourceConfig,
+) []sourceResult {
+	p.info(
+		"Importing <em>%d</em> sources in parallel",
+		p.cfg.Populate.ParallelSources,
+	)
+
+	res := make([]sourceResult, len(sourcesToProcess))
+
+	var g errgroup.Group
+	g.SetLimit(p.cfg.Populate.ParallelSources)
+	for i, source := range sourcesToProcess {
+		g.Go(func() error {
+			res[i] = p.forSource(source.ID).
+				runSource(ctx, source, i, len(sourcesToProcess))
+			return nil
+		})
+	}
+	_ = g.Wait() // runSource reports its own errors
+
+	return res
+}
+
+// forSource creates a populator for one data source of a parallel run.
+// It shares the database operator and checkpoints with p, but has its own
+// copy of the configuration (processSource changes it for sources that
+// prefer flat classification), its own SFGA handle, and prefixes console
+// output and log records with the data source ID.
+func (p *populator) forSource(sourceID int) *populator {
+	cfg := *p.cfg
+	return &populator{
+		cfg:         &cfg,
+		operator:    p.operator,
+		checkpoints: p.checkpoints,
+		cache:       p.cache,
+		s3:          p.s3,
+		state:       p.state,
+		runReport:   p.runReport,
+		progress:    p.progress,
+		sourceID:    sourceID,
+		log:         p.logger().With("source", sourceID),
+	}
+}
+
+// runSource imports one data source, reports the result to the user and
+// to the log. Errors do not stop the run, the next source is processed.
+func (p *populator) runSource(
+	ctx context.Context,
+	source sources.DataSourceConfig,
+	i, total int,
+) (res sourceResult) {
+	sourceStartTime := time.Now()
+
+	var err error
+	p.sourceID = source.ID
+	p.startSourceReport(source)
+	defer func() {
+		p.finishSourceReport(res, err, sourceStartTime)
+		p.finishSource(res, err, sourceStartTime)
+		p.sourceID = 0
+	}()
+
+	if p.state.aborted.Load() {
+		p.logger().Info("Skipping source, run was aborted by policy",
+			"data_source_id", source.ID,
+			"title", source.TitleShort,
+		)
+		return sourceSkipped
+	}
+
+	if ctx.Err() != nil {
+		p.logger().Info("Skipping source, run was interrupted",
+			"data_source_id", source.ID,
+			"title", source.TitleShort,
+		)
+		return sourceSkipped
+	}
+
+	if p.cfg.Populate.Resume && p.checkpoints.isDone(source.ID) {
+		p.info(
+			"Data Source [%d]: %s <em>was imported already, skipping</em>",
+			source.ID, source.TitleShort,
+		)
+		p.logger().Info("Skipping source imported by previous run",
+			"data_source_id", source.ID,
+			"title", source.TitleShort,
+		)
+		return sourceSkipped
+	}
+
+	p.emit(gndb.Event{
+		Type: gndb.EventSourceStarted,
+		Message: fmt.Sprintf("Data Source [%d]: %s",
+			source.ID, source.TitleShort),
+	})
+
+	p.logger().Info("Processing source",
+		"index", i+1,
+		"total", total,
+		"data_source_id", source.ID,
+		"title", source.TitleShort,
+	)
+
+	// Process this source through all phases
+	err = p.processSource(ctx, source)
+	switch {
+	case err != nil && ctx.Err() != nil:
+		p.cleanupInterrupted(ctx, source.ID)
+		err = CancelledError(ctx.Err())
+		p.warn("Data Source [%d]: %s <em>was interrupted</em>",
+			source.ID, source.TitleShort)
+		return sourceFailed
+	case errors.Is(err, errUnchanged):
+		p.info("Data Source [%d]: %s <em>is up to date, skipping</em>",
+			source.ID, source.TitleShort)
+		p.logger().Info("Source skipped, release is imported already",
+			"data_source_id", source.ID,
+			"title", source.TitleShort,
+		)
+		err = nil
+		return sourceSkipped
+	case errors.Is(err, errSkipSource):
+		p.info("Data Source [%d]: %s <em>skipped by policy</em>",
+			source.ID, source.TitleShort)
+		p.logger().Info("Source skipped by policy",
+			"data_source_id", source.ID,
+			"title", source.TitleShort,
+		)
+		err = nil
+		return sourceSkipped
+	case errors.Is(err, errAbortRun):
+		p.state.aborted.Store(true)
+		p.warn("Data Source [%d]: %s <em>aborted the run</em>",
+			source.ID, source.TitleShort)
+		p.logger().Warn("Populate run aborted by policy",
+			"data_source_id", source.ID,
+			"title", source.TitleShort,
+		)
+		return sourceFailed
+	case err != nil:
+		p.logger().Error("Failed to process source",
+			"data_source_id", source.ID,
+			"title", source.TitleShort,
+			"error", err,
+		)
+		if p.isParallel() {
+			p.warn("Data Source [%d]: %s <em>failed</em>",
+				source.ID, source.TitleShort)
+		}
+		p.emit(gndb.Event{Type: gndb.EventError, Err: err})
+		return sourceFailed
+	}
+
+	sourceDuration := time.Since(sourceStartTime)
+	p.logger().Info("Source processed successfully",
+		"data_source_id", source.ID,
+		"title", source.TitleShort,
+		"duration", gnfmt.TimeString(sourceDuration.Seconds()),
+	)
+
+	// Files of imported sources are not kept in parallel mode, otherwise
+	// the cache would grow to the size of all processed SFGA files.
+	if p.isParallel() {
+		err = os.RemoveAll(sourceCacheDir(p.cfg.HomeDir, source.ID))
+		if err != nil {
+			p.logger().Warn("Cannot remove SFGA cache of source",
+				"data_source_id", source.ID,
+				"error", err,
+			)
+		}
+	}
+
+	return sourceSucceeded
+}
+
 // processSource handles a single data source through all phases:
 // 1. SFGA file resolution and caching
 // 2. Name strings import
@@ -194,7 +565,13 @@ Sources succeded: %d, failed %d, total %d.
 // 4. Name indices import (taxa, synonyms, bare names)
 // 5. Vernacular names import
 // 6. Data source metadata update
+//
+// A checkpoint is saved after every phase. When the run is resumed,
+// phases that finished before on the same SFGA file are skipped. Phases
+// 1 and 3 do not store anything in PostgreSQL, so they run again when
+// any later phase still has to be done.
 func (p *populator) processSource(
+	ctx context.Context,
 	source sources.DataSourceConfig,
 ) error {
 	var t time.Time
@@ -208,7 +585,7 @@ func (p *populator) processSource(
 		p.cfg.Update([]config.Option{
 			config.OptPopulateWithFlatClassification(&flat),
 		})
-		gn.Message("<em>Flat classification enabled</em>")
+		p.message("<em>Flat classification enabled</em>")
 		defer func() {
 			p.cfg.Update([]config.Option{
 				config.OptPopulateWithFlatClassification(globalFlat),
@@ -218,32 +595,45 @@ func (p *populator) processSource(
 
 	// Stage 1: Resolve and fetch SFGA file
 	t = time.Now()
-	sfgaPath, metadata, warning, err := resolveSFGAPath(source)
+	sfgaPath, metadata, warning, err := p.resolveSFGAPath(ctx, source)
 	if err != nil {
-		return SFGAFileNotFoundError(source.ID, source.Parent, err)
+		return sfgaPathError(source, err)
 	}
 
 	if warning != "" {
-		slog.Warn(warning)
+		p.logger().Warn(warning)
+		p.addWarning(warning)
 	}
+	if err = p.checkUnchanged(ctx, source, metadata); err != nil {
+		return err
+	}
+
 	file := filepath.Base(sfgaPath)
-	gn.Info("(1/6) getting SFGA file <em>%s</em>", file)
-	slog.Info("Resolved SFGA file",
+	p.startStage(stageFetch, "getting SFGA file <em>%s</em>", file)
+	p.logger().Info("Resolved SFGA file",
 		"source_id", source.ID,
 		"path", sfgaPath,
 		"version", metadata.Version,
 		"date", metadata.RevisionDate)
 
-	// Prepare cache directory
-	cacheDir, err := prepareCacheDir(p.cfg.HomeDir)
+	done := p.resumeStage(source.ID, file)
+
+	// Prepare cache directory. Parallel imports use a subdirectory per
+	// source, so they do not remove each other's files.
+	var cacheDir string
+	if p.isParallel() {
+		cacheDir, err = prepareSourceCacheDir(p.cfg.HomeDir, source.ID)
+	} else {
+		cacheDir, err = prepareCacheDir(p.cfg.HomeDir)
+	}
 	if err != nil {
 		return CacheError("prepare cache directory", err)
 	}
 
 	// Fetch SFGA file to cache
-	sqlitePath, err := fetchSFGA(sfgaPath, cacheDir)
+	sqlitePath, err := p.fetchSFGA(ctx, source, sfgaPath, metadata.SHA256, cacheDir)
 	if err != nil {
-		return SFGAReadError(sfgaPath, err)
+		return err
 	}
 
 	// Open SFGA database
@@ -252,79 +642,234 @@ func (p *populator) processSource(
 		return SFGAReadError(sqlitePath, err)
 	}
 	defer p.sfgaDB.Close()
-	gn.Message(
-		"<em>Prepared SFGA file for import</em> %s",
-		gnfmt.TimeString(time.Since(t).Seconds()),
-	)
-
-	// Stage 2: Import name-strings
-	t = time.Now()
-	gn.Info("(2/6) Importing name-strings...")
+	p.finishStage(stageFetch, t, 0, "<em>Prepared SFGA file for import</em>")
+	p.markStage(source.ID, max(done, stageFetch), file)
+	p.addStage(stageFetch, t, 0, 0, 0)
 
-	err = p.checkSfgaVersion(source.ID)
+	err = p.checkSfgaVersion(ctx, source.ID)
 	if err != nil {
 		return err
 	}
 
-	msg, err = p.processNameStrings(source.ID)
-	if err != nil {
-		return NamesError(source.ID, err)
+	// The policy for dangling synonyms is decided before filters, they
+	// keep name-strings of dangling synonyms that are imported.
+	var dangling *danglingSynonyms
+	if done < stageIndices {
+		dangling, err = p.handleDanglingSynonyms(ctx, &source)
+		if err != nil {
+			return err
+		}
+	}
+
+	p.filter = nil
+	if done < stageVernaculars {
+		p.filter, err = p.prepareFilter(ctx, &source, dangling)
+		if err != nil {
+			return FilterError(source.ID, err)
+		}
+	}
+
+	// Stage 2: Import name-strings
+	t = time.Now()
+	p.startStage(stageNames, "Importing name-strings...")
+	var count int
+	if done >= stageNames {
+		p.skipStage(stageNames)
+	} else {
+		msg, count, err = p.processNameStrings(ctx, &source)
+		if errors.Is(err, errSkipSource) || errors.Is(err, errAbortRun) {
+			return err
+		}
+		if err != nil {
+			return NamesError(source.ID, err)
+		}
+		p.finishStage(stageNames, t, count, msg)
+		p.markStage(source.ID, stageNames, file)
+		p.addStage(stageNames, t, count, 0, 0)
 	}
-	gn.Message("%s %s", msg, gnfmt.TimeString(time.Since(t).Seconds()))
 
 	// Stage 3: Build classification hierarchy
+	// The hierarchy is kept in memory and is only needed for stage 4.
 	t = time.Now()
-	gn.Info("(3/6) Building classification hierarchy...")
-	hierarchy, err := p.buildHierarchy()
-	if err != nil {
-		// Hierarchy is optional, log warning and continue
-		slog.Warn("Failed to build hierarchy",
-			"source_id", source.ID,
-			"error", err)
-	}
-	msg = "<em>Did not detect hierarchy existance</em>"
-	if len(hierarchy) > 0 {
-		msg = fmt.Sprintf(
-			"<em>Finished building hierarchy with %s nodes</em>",
-			humanize.Comma(int64(len(hierarchy))),
-		)
+	p.startStage(stageHierarchy, "Building classification hierarchy...")
+	var hierarchy map[string]*hNode
+	if done >= stageIndices {
+		p.skipStage(stageHierarchy)
+	} else {
+		hierarchy, err = p.buildHierarchy(ctx)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			// Hierarchy is optional, log warning and continue
+			p.logger().Warn("Failed to build hierarchy",
+				"source_id", source.ID,
+				"error", err)
+			p.addWarning(fmt.Sprintf("failed to build hierarchy: %s", err))
+		}
+		err = p.reportHierarchyDefects(source.ID, checkHierarchy(hierarchy))
+		if err != nil {
+			return err
+		}
+		msg = "<em>Did not detect hierarchy existance</em>"
+		if len(hierarchy) > 0 {
+			msg = fmt.Sprintf(
+				"<em>Finished building hierarchy with %s nodes</em>",
+				humanize.Comma(int64(len(hierarchy))),
+			)
+		}
+		p.finishStage(stageHierarchy, t, len(hierarchy), msg)
+		p.markStage(source.ID, max(done, stageHierarchy), file)
+		p.addStage(stageHierarchy, t, 0, 0, 0)
 	}
-	gn.Message(
-		"%s %s", msg, gnfmt.TimeString(time.Since(t).Seconds()),
-	)
 
 	// Stage 4: Import name-string indices
 	t = time.Now()
-	gn.Info("(4/6) Importing name-string indices...")
-	msg, err = p.processNameIndices(&source, hierarchy)
-	if err != nil {
-		return NamesError(source.ID, err)
+	p.startStage(stageIndices, "Importing name-string indices...")
+	if done >= stageIndices {
+		p.skipStage(stageIndices)
+	} else {
+		ids, err := p.handleDuplicateRecordIDs(ctx, &source)
+		if err != nil {
+			return err
+		}
+		msg, count, err = p.processNameIndices(
+			ctx, &source, hierarchy, ids, dangling,
+		)
+		if err != nil {
+			return NamesError(source.ID, err)
+		}
+		refs, err := p.processReferences(ctx, source.ID)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			// References are optional, report error and continue
+			p.logger().Error("Failed to import references",
+				"source_id", source.ID,
+				"error", err)
+			p.addWarning(fmt.Sprintf("failed to import references: %s", err))
+			// Keep previously imported references of the source
+			if err = p.dropStagingTable(ctx, refsTable, source.ID); err != nil {
+				p.logger().Warn("Cannot drop staging table", "error", err)
+			}
+		} else if refs > 0 {
+			msg += fmt.Sprintf(
+				", <em>%s references</em>", humanize.Comma(int64(refs)),
+			)
+		}
+		p.finishStage(stageIndices, t, count, msg)
+		p.markStage(source.ID, stageIndices, file)
+		p.addStage(stageIndices, t, count, 0, 0)
 	}
-	gn.Message("%s %s", msg, gnfmt.TimeString(time.Since(t).Seconds()))
 
 	// Stage 5: Import vernacular names
 	t = time.Now()
-	gn.Info("(5/6) Importing vernacular names...")
-	msg, err = p.processVernaculars(source.ID)
-	if err != nil {
-		// Vernaculars are optional, report error and continue
-		slog.Error("Failed to import vernaculars",
-			"source_id", source.ID,
-			"error", err)
+	p.startStage(stageVernaculars, "Importing vernacular names...")
+	if done >= stageVernaculars {
+		p.skipStage(stageVernaculars)
+	} else {
+		msg, count, err = p.processVernaculars(ctx, source.ID)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			// Vernaculars are optional, report error and continue
+			p.logger().Error("Failed to import vernaculars",
+				"source_id", source.ID,
+				"error", err)
+			p.addWarning(fmt.Sprintf("failed to import vernaculars: %s", err))
+			// Keep previously imported vernaculars of the source
+			if err = p.dropStagingTable(ctx, vernIndicesTable, source.ID); err != nil {
+				p.logger().Warn("Cannot drop staging table", "error", err)
+			}
+		}
+		p.finishStage(stageVernaculars, t, count, msg)
+		p.markStage(source.ID, stageVernaculars, file)
+		p.addStage(stageVernaculars, t, count, 0, 0)
 	}
-	gn.Message("%s %s", msg, gnfmt.TimeString(time.Since(t).Seconds()))
 
 	// Stage 6: Update data source metadata
 	t = time.Now()
-	gn.Info("(6/6) Importing metadata...")
-	msg, err = p.updateDataSourceMetadata(source, metadata)
+	p.startStage(stageMetadata, "Importing metadata...")
+	msg, stats, err := p.updateDataSourceMetadata(ctx, source, metadata)
 	if err != nil {
 		return MetadataError(source.ID, err)
 	}
-	gn.Message("%s %s", msg, gnfmt.TimeString(time.Since(t).Seconds()))
+	p.finishStage(stageMetadata, t, stats.added, msg)
+	p.markStage(source.ID, stageMetadata, file)
+	p.saveRelease(source, file, metadata)
+	p.addStage(stageMetadata, t, stats.added, stats.changed, stats.removed)
 
-	slog.Info("Source processing complete",
+	p.logger().Info("Source processing complete",
 		"source_id", source.ID)
 
 	return nil
 }
+
+// resumeStage returns the last stage the source finished in a previous
+// run, or stageNone if the source has to be imported from the start.
+// A checkpoint made for a different SFGA file is discarded.
+func (p *populator) resumeStage(sourceID int, sfgaFile string) stage {
+	if !p.cfg.Populate.Resume {
+		return stageNone
+	}
+
+	cp := p.checkpoints.get(sourceID)
+	if cp.Stage == stageNone {
+		return stageNone
+	}
+
+	if cp.SFGAFile != sfgaFile {
+		p.logger().Info("SFGA file changed since previous run, starting over",
+			"data_source_id", sourceID,
+			"previous_file", cp.SFGAFile,
+			"file", sfgaFile,
+		)
+		if err := p.checkpoints.reset(sourceID); err != nil {
+			p.logger().Warn("Cannot reset populate checkpoint",
+				"data_source_id", sourceID,
+				"error", err,
+			)
+		}
+		return stageNone
+	}
+
+	p.message(
+		"<em>Resuming after stage %d/6 (%s)</em>",
+		int(cp.Stage), cp.Stage.String(),
+	)
+	p.logger().Info("Resuming source",
+		"data_source_id", sourceID,
+		"stage", cp.Stage.String(),
+	)
+	return cp.Stage
+}
+
+// reportDecisions shows policies applied during the run, so
+// non-interactive runs can be audited.
+func (p *populator) reportDecisions() {
+	decisions := p.state.getDecisions()
+	if len(decisions) == 0 {
+		return
+	}
+
+	p.info("Policies applied:")
+	for _, d := range decisions {
+		p.message("[%d] %s=%s (%s)", d.sourceID, d.key, d.answer, d.origin)
+	}
+}
+
+// skipStage tells the user that a stage was done by a previous run and
+// adds it to the report.
+func (p *populator) skipStage(s stage) {
+	p.emit(gndb.Event{
+		Type:    gndb.EventStageFinished,
+		Stage:   s.String(),
+		Step:    int(s),
+		Steps:   int(stageFinal),
+		Status:  gndb.StatusSkipped,
+		Message: "<em>Skipped, finished by previous run</em>",
+	})
+	p.addSkippedStage(s)
+}
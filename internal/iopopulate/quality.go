@@ -0,0 +1,260 @@
+package iopopulate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gnames/gndb/pkg/schema"
+	"github.com/gnames/gnparser"
+	"github.com/gnames/gnparser/ent/parsed"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/sync/errgroup"
+)
+
+// nameQuality holds counts of staged name indices used by the quality
+// profile of a source.
+type nameQuality struct {
+	total          int
+	noParse        int
+	cleanParse     int
+	minorProblems  int
+	majorProblems  int
+	surrogates     int
+	hybrids        int
+	bareNames      int
+	classification int
+}
+
+// queryQualityProfile calculates the data quality profile of a source
+// from its staged name and vernacular indices and from synonyms of the
+// SFGA file. It runs before swapSource, while staging tables exist.
+func (p *populator) queryQualityProfile(
+	ctx context.Context,
+	sourceID int,
+) (schema.QualityProfile, error) {
+	var res schema.QualityProfile
+
+	nq, err := p.queryNameQuality(ctx, sourceID)
+	if err != nil {
+		return res, err
+	}
+
+	synonyms, unresolved, err := p.countUnresolvedSynonyms(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	vern, withLang, err := p.countVernacularLanguages(ctx, sourceID)
+	if err != nil {
+		return res, err
+	}
+
+	res = schema.QualityProfile{
+		NoParseShare:            share(nq.noParse, nq.total),
+		CleanParseShare:         share(nq.cleanParse, nq.total),
+		MinorProblemsShare:      share(nq.minorProblems, nq.total),
+		MajorProblemsShare:      share(nq.majorProblems, nq.total),
+		SurrogateShare:          share(nq.surrogates, nq.total),
+		HybridShare:             share(nq.hybrids, nq.total),
+		BareNameShare:           share(nq.bareNames, nq.total),
+		UnresolvedSynonymShare:  share(unresolved, synonyms),
+		ClassificationShare:     share(nq.classification, nq.total),
+		VernacularLanguageShare: share(withLang, vern),
+	}
+
+	p.logger().Info("Calculated quality profile",
+		"data_source_id", sourceID,
+		"no_parse_share", res.NoParseShare,
+		"bare_name_share", res.BareNameShare,
+		"unresolved_synonym_share", res.UnresolvedSynonymShare,
+	)
+	return res, nil
+}
+
+// queryNameQuality counts staged name indices of a source by parse
+// quality and by kinds of names. Populate does not fill parse results of
+// name_strings, only optimize does, so names are parsed here (see
+// parseNameQuality).
+func (p *populator) queryNameQuality(
+	ctx context.Context,
+	sourceID int,
+) (nameQuality, error) {
+	q := fmt.Sprintf(`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE taxonomic_status = 'bare name'),
+			COUNT(*) FILTER (WHERE COALESCE(classification, '') <> '')
+		FROM %s`,
+		stagingName(nameIndicesTable, sourceID),
+	)
+
+	var res nameQuality
+	err := p.operator.Pool().QueryRow(ctx, q).Scan(
+		&res.total, &res.bareNames, &res.classification,
+	)
+	if err != nil {
+		return res, fmt.Errorf("failed to query name quality: %w", err)
+	}
+
+	if err = p.parseNameQuality(ctx, sourceID, &res); err != nil {
+		return res, fmt.Errorf("failed to parse names for quality: %w", err)
+	}
+	return res, nil
+}
+
+// parseNameQuality parses name-strings of staged name indices of a
+// source and adds their parse results to nq. Names are parsed by
+// p.cfg.JobsNumber workers.
+func (p *populator) parseNameQuality(
+	ctx context.Context,
+	sourceID int,
+	nq *nameQuality,
+) error {
+	q := fmt.Sprintf(`
+		SELECT ns.name
+		FROM %s nsi
+		JOIN name_strings ns ON ns.id = nsi.name_string_id`,
+		stagingName(nameIndicesTable, sourceID),
+	)
+	rows, err := p.operator.Pool().Query(ctx, q)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	chIn := make(chan string)
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	for range max(p.cfg.JobsNumber, 1) {
+		g.Go(func() error {
+			var counts nameQuality
+			parser := gnparser.New(gnparser.NewConfig())
+			for name := range chIn {
+				counts.addParsed(parser.ParseName(name))
+			}
+			mu.Lock()
+			nq.merge(counts)
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			break
+		}
+		select {
+		case <-gctx.Done():
+			err = gctx.Err()
+		case chIn <- name:
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	close(chIn)
+	if gErr := g.Wait(); err == nil {
+		err = gErr
+	}
+	return err
+}
+
+// addParsed counts the parse quality and the kind of a parsed name.
+func (nq *nameQuality) addParsed(p parsed.Parsed) {
+	switch {
+	case !p.Parsed || p.ParseQuality == 0:
+		nq.noParse++
+	case p.ParseQuality == 1:
+		nq.cleanParse++
+	case p.ParseQuality == 2:
+		nq.minorProblems++
+	default:
+		nq.majorProblems++
+	}
+	if p.Surrogate != nil {
+		nq.surrogates++
+	}
+	if p.Hybrid != nil {
+		nq.hybrids++
+	}
+}
+
+// merge adds parse counts of other to nq.
+func (nq *nameQuality) merge(other nameQuality) {
+	nq.noParse += other.noParse
+	nq.cleanParse += other.cleanParse
+	nq.minorProblems += other.minorProblems
+	nq.majorProblems += other.majorProblems
+	nq.surrogates += other.surrogates
+	nq.hybrids += other.hybrids
+}
+
+// countUnresolvedSynonyms returns the number of synonyms in the SFGA file
+// and the number of those whose accepted taxon does not exist.
+func (p *populator) countUnresolvedSynonyms(
+	ctx context.Context,
+) (int, int, error) {
+	q := `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE t.col__id IS NULL)
+		FROM synonym s
+		LEFT JOIN taxon t ON t.col__id = s.col__taxon_id
+	`
+
+	var total, unresolved int
+	err := p.sfgaDB.QueryRowContext(ctx, q).Scan(&total, &unresolved)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count unresolved synonyms: %w", err)
+	}
+	return total, unresolved, nil
+}
+
+// countVernacularLanguages returns the number of vernacular indices of a
+// source and the number of those with a language. Staged vernaculars are
+// counted if they exist, otherwise the live ones, which are kept by the
+// swap.
+func (p *populator) countVernacularLanguages(
+	ctx context.Context,
+	sourceID int,
+) (int, int, error) {
+	staged, err := p.stagingTableExists(ctx, vernIndicesTable, sourceID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	table := pgx.Identifier{vernIndicesTable}.Sanitize()
+	if staged {
+		table = stagingName(vernIndicesTable, sourceID)
+	}
+
+	q := fmt.Sprintf(`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (
+				WHERE COALESCE(language, '') <> ''
+				OR COALESCE(lang_code, '') <> ''
+			)
+		FROM %s
+		WHERE data_source_id = $1`, table)
+
+	var total, withLang int
+	err = p.operator.Pool().QueryRow(ctx, q, sourceID).Scan(&total, &withLang)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count vernacular languages: %w", err)
+	}
+	return total, withLang, nil
+}
+
+// share returns part as a fraction of total, 0 if total is 0.
+func share(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
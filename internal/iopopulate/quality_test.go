@@ -0,0 +1,74 @@
+package iopopulate
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/gnames/gnparser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShare(t *testing.T) {
+	assert.Equal(t, 0.0, share(0, 0))
+	assert.Equal(t, 0.0, share(5, 0))
+	assert.Equal(t, 0.25, share(1, 4))
+	assert.Equal(t, 1.0, share(3, 3))
+}
+
+// TestNameQualityNotOptimized checks that parse quality of a freshly
+// imported source comes from parsing its names, parse results of
+// name_strings are empty until optimize runs.
+func TestNameQualityNotOptimized(t *testing.T) {
+	parser := gnparser.New(gnparser.NewConfig())
+	names := []string{
+		"Homo sapiens Linnaeus, 1758",
+		"Bubo bubo (Linnaeus, 1758)",
+		"Abies sp.",
+		"Carex × ludibunda",
+		"12345",
+	}
+
+	var nq nameQuality
+	for _, name := range names {
+		nq.addParsed(parser.ParseName(name))
+	}
+
+	assert.Equal(t, 1, nq.noParse)
+	assert.GreaterOrEqual(t, nq.cleanParse, 2)
+	assert.Equal(t, 4, nq.cleanParse+nq.minorProblems+nq.majorProblems)
+	assert.Equal(t, 1, nq.surrogates)
+	assert.Equal(t, 1, nq.hybrids)
+
+	var total nameQuality
+	total.merge(nq)
+	total.merge(nq)
+	assert.Equal(t, 2, total.noParse)
+	assert.Equal(t, 2, total.hybrids)
+}
+
+func TestCountUnresolvedSynonyms(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses SQLite in short mode")
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE taxon (col__id TEXT);
+		CREATE TABLE synonym (col__id TEXT, col__taxon_id TEXT);
+		INSERT INTO taxon VALUES ('t1'), ('t2');
+		INSERT INTO synonym VALUES
+			('s1', 't1'), ('s2', 't2'), ('s3', 't3'), ('s4', '');
+	`)
+	require.NoError(t, err)
+
+	p := &populator{sfgaDB: db}
+	total, unresolved, err := p.countUnresolvedSynonyms(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	assert.Equal(t, 2, unresolved)
+}
@@ -0,0 +1,185 @@
+package iopopulate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gnames/gnlib"
+	"github.com/jackc/pgx/v5"
+)
+
+// yearRe finds a four-digit year in publication dates and citations.
+var yearRe = regexp.MustCompile(`\b(1[5-9]|20)\d\d\b`)
+
+// processReferences imports bibliographic references of the SFGA file
+// into the staging table of source_references. Name indices link to them
+// by reference_id. If the source has filters, only references of kept
+// name indices are imported.
+//
+// Returns the number of imported references.
+func (p *populator) processReferences(
+	ctx context.Context,
+	sourceID int,
+) (int, error) {
+	p.logger().Info("Processing references", "data_source_id", sourceID)
+
+	err := p.createStagingTable(ctx, refsTable, sourceID)
+	if err != nil {
+		return 0, err
+	}
+
+	totalCount, err := p.getTotalReferenceCount(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		SELECT
+			col__id, COALESCE(col__citation,''), COALESCE(col__doi,''),
+			COALESCE(col__issued,''), COALESCE(col__link,'')
+		FROM reference
+		WHERE COALESCE(col__id,'') <> ''
+	`
+	rows, err := p.sfgaDB.QueryContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query SFGA references: %w", err)
+	}
+	defer rows.Close()
+
+	bar := p.newCounter(totalCount, "Processing references: ")
+	defer bar.Finish()
+
+	var records [][]any
+	var count int
+	seen := make(map[string]struct{})
+	for rows.Next() {
+		var id, citation, doi, issued, link string
+		err = rows.Scan(&id, &citation, &doi, &issued, &link)
+		if err != nil {
+			return 0, fmt.Errorf("failed to scan reference row: %w", err)
+		}
+		bar.Add(1)
+
+		// The first reference with a repeated ID wins.
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		if len(doi) > 255 {
+			doi = doi[:255]
+		}
+
+		records = append(records, []any{
+			sourceID,                        // data_source_id
+			id,                              // record_id
+			gnlib.FixUtf8(citation),         // citation
+			doi,                             // doi
+			referenceYear(issued, citation), // year
+			link,                            // link
+		})
+		count++
+
+		if len(records) >= p.cfg.Database.BatchSize {
+			if err = insertReferences(ctx, p, sourceID, records); err != nil {
+				return 0, err
+			}
+			records = records[:0]
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating reference rows: %w", err)
+	}
+
+	if len(records) > 0 {
+		if err = insertReferences(ctx, p, sourceID, records); err != nil {
+			return 0, err
+		}
+	}
+
+	if p.filter != nil && count > 0 {
+		if count, err = p.removeUnusedReferences(ctx, sourceID); err != nil {
+			return 0, err
+		}
+	}
+
+	p.logger().Info("Processed references",
+		"data_source_id", sourceID,
+		"count", humanize.Comma(int64(count)),
+	)
+	return count, nil
+}
+
+// getTotalReferenceCount returns the number of references in the SFGA
+// file.
+func (p *populator) getTotalReferenceCount(ctx context.Context) (int, error) {
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM reference`
+
+	err := p.sfgaDB.QueryRowContext(ctx, countQuery).Scan(&totalCount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count references: %w", err)
+	}
+
+	return totalCount, nil
+}
+
+// insertReferences performs bulk insert into the staging table of
+// references of the source using pgx CopyFrom.
+func insertReferences(
+	ctx context.Context,
+	p *populator,
+	sourceID int,
+	records [][]any,
+) error {
+	columns := []string{
+		"data_source_id", "record_id", "citation", "doi", "year", "link",
+	}
+
+	_, err := p.operator.Pool().CopyFrom(
+		ctx,
+		stagingIdent(refsTable, sourceID),
+		columns,
+		pgx.CopyFromRows(records),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert references: %w", err)
+	}
+	return nil
+}
+
+// removeUnusedReferences removes staged references that no staged name
+// index of the source links to. Returns the number of references left.
+func (p *populator) removeUnusedReferences(
+	ctx context.Context,
+	sourceID int,
+) (int, error) {
+	q := fmt.Sprintf(
+		`DELETE FROM %s r
+		 WHERE NOT EXISTS (
+			SELECT 1 FROM %s nsi WHERE nsi.reference_id = r.record_id
+		 )`,
+		stagingName(refsTable, sourceID),
+		stagingName(nameIndicesTable, sourceID),
+	)
+	if _, err := p.operator.Pool().Exec(ctx, q); err != nil {
+		return 0, fmt.Errorf("failed to remove unused references: %w", err)
+	}
+	return p.stagingCount(ctx, refsTable, sourceID)
+}
+
+// referenceYear returns the year a reference was published. It is taken
+// from the issued date, or from the citation if the date has no year.
+func referenceYear(issued, citation string) sql.NullInt16 {
+	for _, s := range []string{issued, citation} {
+		if y := yearRe.FindString(s); y != "" {
+			year, _ := strconv.Atoi(y)
+			return sql.NullInt16{Int16: int16(year), Valid: true}
+		}
+	}
+	return sql.NullInt16{}
+}
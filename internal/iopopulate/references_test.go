@@ -0,0 +1,33 @@
+package iopopulate
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferenceYear(t *testing.T) {
+	tests := []struct {
+		name     string
+		issued   string
+		citation string
+		want     sql.NullInt16
+	}{
+		{"issued year", "1758", "", sql.NullInt16{Int16: 1758, Valid: true}},
+		{"issued date", "2001-05-12", "",
+			sql.NullInt16{Int16: 2001, Valid: true}},
+		{"citation", "", "Linnaeus, C. (1753). Species Plantarum.",
+			sql.NullInt16{Int16: 1753, Valid: true}},
+		{"issued wins", "1999", "Smith (2001)",
+			sql.NullInt16{Int16: 1999, Valid: true}},
+		{"page numbers", "", "Bull. Zool. 12: 3456-3470", sql.NullInt16{}},
+		{"no year", "", "", sql.NullInt16{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, referenceYear(tt.issued, tt.citation))
+		})
+	}
+}
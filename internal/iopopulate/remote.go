@@ -0,0 +1,390 @@
+package iopopulate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gnames/gndb/internal/ios3"
+	"github.com/gnames/gndb/pkg/sources"
+)
+
+// maxListingPages limits the number of pages of a remote manifest or
+// directory listing, so a listing that links to itself cannot make
+// populate run forever.
+const maxListingPages = 1000
+
+// errNoManifest means that the parent URL does not have index.json.
+var errNoManifest = errors.New("no manifest")
+
+var (
+	hrefPattern = regexp.MustCompile(`href=["']([^"']+)["']`)
+	nextPattern = regexp.MustCompile(
+		`<(?:a|link)\s[^>]*rel=["']?next["']?[^>]*>`,
+	)
+)
+
+// resolveRemoteSFGAFile finds the SFGA file of a data source at a remote
+// URL. The index.json manifest of the URL is preferred. If there is no
+// manifest, or gndb does not support its schema version, the HTML
+// directory listing is scraped for links. Both can have several pages.
+// Matches patterns: {ID}-, {ID}_, or {ID}.ext with varying digit lengths (0001, 001, 01, 1)
+// If multiple files match, selects the one with the latest date, or the
+// pinned release.
+// Returns (fullURL, metadata, warning, error). Warning is non-empty when
+// multiple files found.
+func resolveRemoteSFGAFile(
+	ctx context.Context,
+	baseURL string,
+	id int,
+	format string,
+	pin releasePin,
+) (string, SFGAMetadata, string, error) {
+	files, err := fetchManifest(ctx, baseURL)
+	if err == nil {
+		return resolveFromManifest(files, baseURL, id, format, pin)
+	}
+	if !errors.Is(err, errNoManifest) {
+		slog.Warn("Cannot use remote manifest, reading HTML listing instead",
+			"url", baseURL,
+			"error", err,
+		)
+	}
+
+	fullURL, warning, err := resolveFromListing(ctx, baseURL, id, format, pin)
+	if err != nil {
+		return "", SFGAMetadata{}, "", err
+	}
+	return fullURL, parseSFGAFilename(path.Base(fullURL)), warning, nil
+}
+
+// fetchManifest reads all pages of the manifest at baseURL. URLs of
+// files are resolved relative to their page. Returns errNoManifest if
+// the first page does not exist.
+func fetchManifest(
+	ctx context.Context,
+	baseURL string,
+) ([]sources.ManifestFile, error) {
+	pageURL, err := url.Parse(
+		strings.TrimSuffix(baseURL, "/") + "/" + sources.ManifestFileName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []sources.ManifestFile
+	seen := make(map[string]bool)
+	for page := 1; ; page++ {
+		if seen[pageURL.String()] {
+			return nil, fmt.Errorf("manifest page %s repeats", pageURL)
+		}
+		if page > maxListingPages {
+			return nil, fmt.Errorf(
+				"manifest has more than %d pages", maxListingPages,
+			)
+		}
+		seen[pageURL.String()] = true
+
+		body, status, err := httpGet(ctx, pageURL.String())
+		if err != nil {
+			return nil, err
+		}
+		if status == http.StatusNotFound && page == 1 {
+			return nil, errNoManifest
+		}
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("manifest page %s: status %d", pageURL, status)
+		}
+
+		m, err := sources.ParseManifest(body)
+		if err != nil {
+			return nil, fmt.Errorf("manifest page %s: %w", pageURL, err)
+		}
+		for _, f := range m.Files {
+			ref := f.URL
+			if ref == "" {
+				ref = f.Name
+			}
+			u, err := pageURL.Parse(ref)
+			if err != nil {
+				return nil, fmt.Errorf("manifest file %s: %w", ref, err)
+			}
+			f.URL = u.String()
+			res = append(res, f)
+		}
+
+		if m.Next == "" {
+			return res, nil
+		}
+		if pageURL, err = pageURL.Parse(m.Next); err != nil {
+			return nil, fmt.Errorf("manifest next page %s: %w", m.Next, err)
+		}
+	}
+}
+
+// resolveFromManifest selects the latest SFGA file of a data source from
+// manifest files, or the latest file of the pinned release. Release
+// dates and versions of the manifest take precedence over the ones in
+// the file name.
+func resolveFromManifest(
+	files []sources.ManifestFile,
+	baseURL string,
+	id int,
+	format string,
+	pin releasePin,
+) (string, SFGAMetadata, string, error) {
+	var matches []sources.ManifestFile
+	var names []string
+	for _, f := range files {
+		if f.SourceID == id && isSourceFile(path.Base(f.URL), format) {
+			names = append(names, path.Base(f.URL))
+			if pin.matches(manifestMetadata(f)) {
+				matches = append(matches, f)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return "", SFGAMetadata{}, "", fmt.Errorf(
+			"no files with source_id %d in %s of %s",
+			id, sources.ManifestFileName, baseURL,
+		)
+	}
+	if len(matches) == 0 {
+		return "", SFGAMetadata{}, "", fmt.Errorf(
+			"%w: %s, found files %v in %s of %s",
+			errReleaseNotFound, pin, names, sources.ManifestFileName, baseURL,
+		)
+	}
+
+	// Latest release date wins, on the same date the preferred file type.
+	best := matches[0]
+	for _, f := range matches[1:] {
+		if f.ReleaseDate > best.ReleaseDate ||
+			(f.ReleaseDate == best.ReleaseDate &&
+				getFileTypePriority(f.URL) > getFileTypePriority(best.URL)) {
+			best = f
+		}
+	}
+
+	var warning string
+	if len(names) > 1 && !pin.isSet() {
+		warning = fmt.Sprintf(
+			"found %d files with source_id %d in %s of %s: %v - selected latest: %s",
+			len(names), id, sources.ManifestFileName, baseURL, names,
+			path.Base(best.URL),
+		)
+	}
+
+	return best.URL, manifestMetadata(best), warning, nil
+}
+
+// manifestMetadata returns metadata of a manifest file. Release date and
+// version of the manifest take precedence over the ones in the file name.
+func manifestMetadata(f sources.ManifestFile) SFGAMetadata {
+	res := parseSFGAFilename(path.Base(f.URL))
+	if f.ReleaseDate != "" {
+		res.RevisionDate = f.ReleaseDate
+	}
+	if f.Version != "" {
+		res.Version = f.Version
+	}
+	res.SHA256 = strings.ToLower(f.SHA256)
+	return res
+}
+
+// resolveFromListing finds the SFGA file at a remote URL by scraping
+// links of its HTML directory listing (Apache/nginx style). Listings
+// split into pages are followed by their rel="next" links.
+// Returns (fullURL, warningMessage, error). Warning is non-empty when
+// multiple files found.
+func resolveFromListing(
+	ctx context.Context,
+	baseURL string,
+	id int,
+	format string,
+	pin releasePin,
+) (string, string, error) {
+	// Generate ID patterns to try: 0001, 001, 01, 1 (descending order)
+	idPatterns := generateIDPatterns(id)
+
+	pageURL, err := url.Parse(strings.TrimSuffix(baseURL, "/") + "/")
+	if err != nil {
+		return "", "", fmt.Errorf("invalid URL %s: %w", baseURL, err)
+	}
+
+	// Find matching files, names of files are mapped to their URLs
+	var matches []string
+	fileURLs := make(map[string]string)
+	seen := make(map[string]bool)
+	for page := 1; page <= maxListingPages; page++ {
+		seen[pageURL.String()] = true
+
+		body, status, err := httpGet(ctx, pageURL.String())
+		if err != nil {
+			return "", "", fmt.Errorf(
+				"failed to fetch directory listing from %s: %w", pageURL, err,
+			)
+		}
+		if status != http.StatusOK {
+			return "", "", fmt.Errorf(
+				"failed to fetch directory listing from %s: status %d",
+				pageURL, status,
+			)
+		}
+
+		// Pattern matches: href="0196_something.sqlite.zip" or href='0196_something.sql'
+		for _, match := range hrefPattern.FindAllStringSubmatch(string(body), -1) {
+			href := match[1]
+
+			// Skip parent directory links and non-files
+			if href == "../" || strings.HasSuffix(href, "/") {
+				continue
+			}
+
+			u, err := pageURL.Parse(href)
+			if err != nil {
+				continue
+			}
+			filename := path.Base(u.Path)
+			if _, ok := fileURLs[filename]; ok {
+				continue
+			}
+
+			// Check if this file matches any of our ID patterns
+			if matchesIDPattern(filename, idPatterns) &&
+				isSourceFile(filename, format) {
+				matches = append(matches, filename)
+				fileURLs[filename] = u.String()
+			}
+		}
+
+		next := nextPageLink(string(body))
+		if next == "" {
+			break
+		}
+		u, err := pageURL.Parse(next)
+		if err != nil || seen[u.String()] {
+			break
+		}
+		pageURL = u
+	}
+
+	// Handle no matches
+	if len(matches) == 0 {
+		return "", "", fmt.Errorf(
+			"no files found matching ID %d (patterns: %v) at %s",
+			id,
+			idPatterns,
+			baseURL,
+		)
+	}
+
+	selected, err := selectFile(matches, pin)
+	if err != nil {
+		return "", "", fmt.Errorf("%w at %s", err, baseURL)
+	}
+
+	// Warn about multiple matches unless the release is pinned
+	var warning string
+	if len(matches) > 1 && !pin.isSet() {
+		warning = fmt.Sprintf("found %d files matching ID %d at %s: %v - selected latest: %s",
+			len(matches), id, baseURL, matches, selected)
+	}
+
+	return fileURLs[selected], warning, nil
+}
+
+// resolveS3SFGAFile finds the SFGA file of a data source in an
+// s3://bucket/prefix/ parent. Objects directly under the prefix are
+// matched by ID the same way as files of a local directory.
+// Returns (s3URL, metadata, warning, error). Warning is non-empty when
+// multiple files found.
+func resolveS3SFGAFile(
+	s3 *ios3.Client,
+	parent string,
+	id int,
+	format string,
+	pin releasePin,
+) (string, SFGAMetadata, string, error) {
+	names, err := s3.List(parent)
+	if err != nil {
+		return "", SFGAMetadata{}, "", err
+	}
+
+	idPatterns := generateIDPatterns(id)
+	var matches []string
+	for _, name := range names {
+		if matchesIDPattern(name, idPatterns) && isSourceFile(name, format) {
+			matches = append(matches, name)
+		}
+	}
+
+	if len(matches) == 0 {
+		return "", SFGAMetadata{}, "", fmt.Errorf(
+			"no files found matching ID %d (patterns: %v) at %s",
+			id,
+			idPatterns,
+			parent,
+		)
+	}
+
+	selected, err := selectFile(matches, pin)
+	if err != nil {
+		return "", SFGAMetadata{}, "", fmt.Errorf("%w at %s", err, parent)
+	}
+
+	var warning string
+	if len(matches) > 1 && !pin.isSet() {
+		warning = fmt.Sprintf(
+			"found %d files matching ID %d at %s: %v - selected latest: %s",
+			len(matches), id, parent, matches, selected,
+		)
+	}
+
+	s3URL := strings.TrimSuffix(parent, "/") + "/" + selected
+	return s3URL, parseSFGAFilename(selected), warning, nil
+}
+
+// nextPageLink returns the href of the rel="next" link of an HTML page,
+// or an empty string if the page is the last one.
+func nextPageLink(html string) string {
+	tag := nextPattern.FindString(html)
+	if tag == "" {
+		return ""
+	}
+	if m := hrefPattern.FindStringSubmatch(tag); len(m) > 1 {
+		return m[1]
+	}
+	return ""
+}
+
+// httpGet reads the body of a remote page and returns it with the
+// status code. The request stops when ctx is cancelled.
+func httpGet(ctx context.Context, url string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	return body, resp.StatusCode, nil
+}
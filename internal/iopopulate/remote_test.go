@@ -0,0 +1,270 @@
+package iopopulate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gnames/gndb/internal/ios3"
+	"github.com/gnames/gndb/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRemoteServer serves pages from the map, other paths return 404.
+// Query strings are part of the keys of pages.
+func newRemoteServer(pages map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			key := r.URL.Path
+			if r.URL.RawQuery != "" {
+				key += "?" + r.URL.RawQuery
+			}
+			page, ok := pages[key]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(page))
+		}))
+}
+
+func TestResolveRemoteSFGAFile(t *testing.T) {
+	sum := strings.Repeat("AB", 32)
+
+	tests := []struct {
+		name        string
+		pages       map[string]string
+		id          int
+		pin         releasePin
+		wantPath    string
+		wantVersion string
+		wantDate    string
+		wantSHA256  string
+		wantWarning bool
+		wantErr     bool
+	}{
+		{
+			name: "manifest",
+			pages: map[string]string{
+				"/sfga/index.json": `{"schema_version": 1, "files": [
+					{"name": "0001-col.sqlite.zip", "source_id": 1,
+					 "release_date": "2025-10-01", "version": "2025.10",
+					 "sha256": "` + sum + `"},
+					{"name": "0003-itis.sqlite.zip", "source_id": 3}
+				]}`,
+			},
+			id:          1,
+			wantPath:    "/sfga/0001-col.sqlite.zip",
+			wantVersion: "2025.10",
+			wantDate:    "2025-10-01",
+			wantSHA256:  strings.ToLower(sum),
+		},
+		{
+			name: "manifest with pages selects latest release",
+			pages: map[string]string{
+				"/sfga/index.json": `{"schema_version": 1, "next": "p/2.json",
+					"files": [{"name": "0001-old.sqlite.zip", "source_id": 1,
+					"release_date": "2024-01-01"}]}`,
+				"/sfga/p/2.json": `{"schema_version": 1, "files": [
+					{"name": "../0001-new.sqlite.zip", "source_id": 1,
+					 "release_date": "2025-01-01"}]}`,
+			},
+			id:          1,
+			wantPath:    "/sfga/0001-new.sqlite.zip",
+			wantDate:    "2025-01-01",
+			wantWarning: true,
+		},
+		{
+			name: "manifest with pinned version",
+			pages: map[string]string{
+				"/sfga/index.json": `{"schema_version": 1, "files": [
+					{"name": "0001-a.sqlite.zip", "source_id": 1,
+					 "release_date": "2024-01-01", "version": "2024.1"},
+					{"name": "0001-b.sqlite.zip", "source_id": 1,
+					 "release_date": "2025-01-01", "version": "2025.1"}]}`,
+			},
+			id:          1,
+			pin:         releasePin{version: "2024.1"},
+			wantPath:    "/sfga/0001-a.sqlite.zip",
+			wantVersion: "2024.1",
+			wantDate:    "2024-01-01",
+		},
+		{
+			name: "manifest without pinned release",
+			pages: map[string]string{
+				"/sfga/index.json": `{"schema_version": 1, "files": [
+					{"name": "0001-a.sqlite.zip", "source_id": 1,
+					 "release_date": "2025-01-01"}]}`,
+			},
+			id:      1,
+			pin:     releasePin{date: "2024-01-01"},
+			wantErr: true,
+		},
+		{
+			name: "listing with pinned date",
+			pages: map[string]string{
+				"/sfga/": `<a href="0001-col-2024-05-01.sql.zip">old</a>
+					<a href="0001-col-2024-05-01.sqlite.zip">old</a>
+					<a href="0001-col-2025-02-03.sqlite.zip">new</a>`,
+			},
+			id:       1,
+			pin:      releasePin{date: "2024-05-01"},
+			wantPath: "/sfga/0001-col-2024-05-01.sqlite.zip",
+			wantDate: "2024-05-01",
+		},
+		{
+			name: "manifest pages that loop",
+			pages: map[string]string{
+				"/sfga/index.json": `{"schema_version": 1, "next": "index.json",
+					"files": []}`,
+				"/sfga/": `<a href="0001-col.sqlite.zip">col</a>`,
+			},
+			id:       1,
+			wantPath: "/sfga/0001-col.sqlite.zip",
+		},
+		{
+			name: "newer manifest schema falls back to listing",
+			pages: map[string]string{
+				"/sfga/index.json": `{"schema_version": 99, "files": []}`,
+				"/sfga/":           `<a href="0001-col-2025-02-03.sqlite.zip">col</a>`,
+			},
+			id:       1,
+			wantPath: "/sfga/0001-col-2025-02-03.sqlite.zip",
+			wantDate: "2025-02-03",
+		},
+		{
+			name: "source missing from manifest",
+			pages: map[string]string{
+				"/sfga/index.json": `{"schema_version": 1, "files": [
+					{"name": "0003-itis.sqlite.zip", "source_id": 3}]}`,
+			},
+			id:      1,
+			wantErr: true,
+		},
+		{
+			name: "paginated HTML listing",
+			pages: map[string]string{
+				"/sfga/": `<a href="../">..</a>
+					<a href="0002-other.sqlite.zip">other</a>
+					<a rel="next" href="?page=2">next</a>`,
+				"/sfga/?page=2": `<a href="0001-col-2025-01-01.sqlite.zip">col</a>`,
+			},
+			id:       1,
+			wantPath: "/sfga/0001-col-2025-01-01.sqlite.zip",
+			wantDate: "2025-01-01",
+		},
+		{
+			name: "listing without matches",
+			pages: map[string]string{
+				"/sfga/": `<a href="0002-other.sqlite.zip">other</a>`,
+			},
+			id:      1,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newRemoteServer(tt.pages)
+			defer srv.Close()
+
+			url, meta, warning, err := resolveRemoteSFGAFile(
+				context.Background(), srv.URL+"/sfga", tt.id, "", tt.pin,
+			)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, srv.URL+tt.wantPath, url)
+			assert.Equal(t, tt.wantVersion, meta.Version)
+			assert.Equal(t, tt.wantDate, meta.RevisionDate)
+			assert.Equal(t, tt.wantSHA256, meta.SHA256)
+			assert.Equal(t, tt.wantWarning, warning != "")
+		})
+	}
+}
+
+func TestFetchManifestNotFound(t *testing.T) {
+	srv := newRemoteServer(nil)
+	defer srv.Close()
+
+	_, err := fetchManifest(context.Background(), srv.URL+"/sfga/")
+	assert.ErrorIs(t, err, errNoManifest)
+}
+
+func TestResolveRemoteSFGAFileCancel(t *testing.T) {
+	// The server never answers.
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, _, _, err := resolveRemoteSFGAFile(ctx, srv.URL+"/sfga", 1, "", releasePin{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestNextPageLink(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"anchor", `<a class="x" rel="next" href="?p=2">Next</a>`, "?p=2"},
+		{"link tag", `<link href='/list/3' rel='next'>`, "/list/3"},
+		{"no next", `<a href="0001.sqlite">x</a>`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, nextPageLink(tt.html))
+		})
+	}
+}
+
+func TestResolveS3SFGAFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/bucket" || r.URL.Query().Get("prefix") != "sfga/" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(`<ListBucketResult>
+				<Contents><Key>sfga/0001-col-2024-01-01.sqlite.zip</Key></Contents>
+				<Contents><Key>sfga/0001-col-2025-01-01.sqlite.zip</Key></Contents>
+				<Contents><Key>sfga/0002-gbif.sqlite.zip</Key></Contents>
+				</ListBucketResult>`))
+		}))
+	defer srv.Close()
+
+	cfg := config.New()
+	cfg.Update([]config.Option{config.OptS3Endpoint(srv.URL)})
+	s3 := ios3.New(cfg.S3)
+
+	url, meta, warning, err := resolveS3SFGAFile(s3, "s3://bucket/sfga/", 1, "", releasePin{})
+	require.NoError(t, err)
+	assert.Equal(t, "s3://bucket/sfga/0001-col-2025-01-01.sqlite.zip", url)
+	assert.Equal(t, "2025-01-01", meta.RevisionDate)
+	assert.NotEmpty(t, warning)
+
+	url, _, warning, err = resolveS3SFGAFile(s3, "s3://bucket/sfga", 2, "", releasePin{})
+	require.NoError(t, err)
+	assert.Equal(t, "s3://bucket/sfga/0002-gbif.sqlite.zip", url)
+	assert.Empty(t, warning)
+
+	_, _, _, err = resolveS3SFGAFile(s3, "s3://bucket/sfga/", 3, "", releasePin{})
+	assert.Error(t, err)
+
+	_, _, _, err = resolveS3SFGAFile(s3, "s3://bucket/other/", 1, "", releasePin{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package iopopulate
+
+import (
+	"time"
+
+	"github.com/gnames/gndb/pkg/report"
+	"github.com/gnames/gndb/pkg/sources"
+)
+
+// startSourceReport starts collecting the report of a data source.
+func (p *populator) startSourceReport(source sources.DataSourceConfig) {
+	p.srcReport = &report.Source{ID: source.ID, Title: source.TitleShort}
+}
+
+// addStage records a finished stage of the current source.
+func (p *populator) addStage(s stage, start time.Time, inserted, updated, deleted int) {
+	if p.srcReport == nil {
+		return
+	}
+	p.srcReport.Stages = append(p.srcReport.Stages, report.Stage{
+		Name:        s.String(),
+		DurationSec: time.Since(start).Seconds(),
+		Inserted:    inserted,
+		Updated:     updated,
+		Deleted:     deleted,
+	})
+}
+
+// addSkippedStage records a stage that was done by a previous run.
+func (p *populator) addSkippedStage(s stage) {
+	if p.srcReport == nil {
+		return
+	}
+	p.srcReport.Stages = append(p.srcReport.Stages, report.Stage{
+		Name:    s.String(),
+		Skipped: true,
+	})
+}
+
+// addWarning records a problem that did not stop the import of the
+// current source.
+func (p *populator) addWarning(msg string) {
+	if p.srcReport == nil {
+		return
+	}
+	p.srcReport.Warnings = append(p.srcReport.Warnings, msg)
+}
+
+// finishSourceReport adds the report of the current source to the run
+// report.
+func (p *populator) finishSourceReport(
+	res sourceResult,
+	err error,
+	start time.Time,
+) {
+	if p.srcReport == nil || p.runReport == nil {
+		return
+	}
+
+	src := *p.srcReport
+	src.DurationSec = time.Since(start).Seconds()
+	src.Error = report.NewError(err)
+	switch res {
+	case sourceSucceeded:
+		src.Status = report.StatusSucceeded
+	case sourceFailed:
+		src.Status = report.StatusFailed
+	case sourceSkipped:
+		src.Status = report.StatusSkipped
+	}
+	p.runReport.AddSource(src)
+	p.srcReport = nil
+}
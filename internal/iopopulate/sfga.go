@@ -1,10 +1,10 @@
 package iopopulate
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -18,9 +18,16 @@ import (
 // resolveSFGAFile finds the SFGA file in the parent directory matching the given ID.
 // Matches patterns: {ID}-, {ID}_, or {ID}.ext with varying digit lengths (0001, 001, 01, 1)
 // SFGA extensions: .sql, .sql.zip, .sqlite, .sqlite.zip
-// If multiple files match, selects the one with the latest date.
+// DwC-A and CoLDP sources match .zip files instead (see isSourceFile).
+// If multiple files match, selects the one with the latest date, or the
+// pinned release (see selectFile).
 // Returns (filePath, warningMessage, error). Warning is non-empty when multiple files found.
-func resolveSFGAFile(parentDir string, id int) (string, string, error) {
+func resolveSFGAFile(
+	parentDir string,
+	id int,
+	format string,
+	pin releasePin,
+) (string, string, error) {
 	// Generate ID patterns to try: 0001, 001, 01, 1 (descending order)
 	idPatterns := generateIDPatterns(id)
 
@@ -39,7 +46,7 @@ func resolveSFGAFile(parentDir string, id int) (string, string, error) {
 
 		filename := entry.Name()
 		// Check if this file matches any of our ID patterns
-		if matchesIDPattern(filename, idPatterns) && isSFGAFile(filename) {
+		if matchesIDPattern(filename, idPatterns) && isSourceFile(filename, format) {
 			matches = append(matches, filename)
 		}
 	}
@@ -54,95 +61,83 @@ func resolveSFGAFile(parentDir string, id int) (string, string, error) {
 		)
 	}
 
-	// Handle single match
-	if len(matches) == 1 {
-		return filepath.Join(parentDir, matches[0]), "", nil
+	selected, err := selectFile(matches, pin)
+	if err != nil {
+		return "", "", fmt.Errorf("%w in %s", err, parentDir)
 	}
 
-	// Handle multiple matches - select latest by date
-	selected := selectLatestFile(matches)
-	warning := fmt.Sprintf("found %d files matching ID %d in %s: %v - selected latest: %s",
-		len(matches), id, parentDir, matches, selected)
+	// Warn about multiple matches unless the release is pinned
+	var warning string
+	if len(matches) > 1 && !pin.isSet() {
+		warning = fmt.Sprintf("found %d files matching ID %d in %s: %v - selected latest: %s",
+			len(matches), id, parentDir, matches, selected)
+	}
 
 	return filepath.Join(parentDir, selected), warning, nil
 }
 
-// resolveRemoteSFGAFile finds the SFGA file at a remote URL by listing the directory.
-// Matches patterns: {ID}-, {ID}_, or {ID}.ext with varying digit lengths (0001, 001, 01, 1)
-// If multiple files match, selects the one with the latest date.
-// Returns (fullURL, warningMessage, error). Warning is non-empty when multiple files found.
-func resolveRemoteSFGAFile(baseURL string, id int) (string, string, error) {
-	// Generate ID patterns to try: 0001, 001, 01, 1 (descending order)
-	idPatterns := generateIDPatterns(id)
+// errReleaseNotFound means that there is no file of the pinned release
+// of a data source.
+var errReleaseNotFound = errors.New("pinned release not found")
 
-	// Fetch directory listing
-	resp, err := http.Get(baseURL)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to fetch directory listing from %s: %w", baseURL, err)
-	}
-	defer resp.Body.Close()
+// releasePin selects a release of a data source by its release date
+// and/or version (see sources.DataSourceConfig). An empty pin selects
+// the latest release.
+type releasePin struct {
+	date    string
+	version string
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf(
-			"failed to fetch directory listing from %s: status %d",
-			baseURL,
-			resp.StatusCode,
-		)
-	}
+// pinOf returns the release pin of a data source.
+func pinOf(source sources.DataSourceConfig) releasePin {
+	return releasePin{date: source.ReleaseDate, version: source.Version}
+}
 
-	// Read HTML content
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to read directory listing from %s: %w", baseURL, err)
-	}
+// isSet returns true if a release is pinned.
+func (r releasePin) isSet() bool {
+	return r.date != "" || r.version != ""
+}
 
-	// Parse HTML for href links (simple pattern matching for Apache/nginx directory listings)
-	// Pattern matches: href="0196_something.sqlite.zip" or href='0196_something.sql'
-	hrefPattern := regexp.MustCompile(`href=["']([^"']+)["']`)
-	hrefs := hrefPattern.FindAllStringSubmatch(string(body), -1)
+// matches returns true if a file with the metadata belongs to the
+// pinned release.
+func (r releasePin) matches(m SFGAMetadata) bool {
+	return (r.date == "" || r.date == m.RevisionDate) &&
+		(r.version == "" || r.version == m.Version)
+}
 
-	// Find matching files
-	var matches []string
-	for _, match := range hrefs {
-		if len(match) < 2 {
-			continue
-		}
-		filename := match[1]
+// String describes the pin for messages.
+func (r releasePin) String() string {
+	var res []string
+	if r.date != "" {
+		res = append(res, "release_date "+r.date)
+	}
+	if r.version != "" {
+		res = append(res, "version "+r.version)
+	}
+	return strings.Join(res, ", ")
+}
 
-		// Skip parent directory links and non-files
-		if filename == "../" || strings.HasSuffix(filename, "/") {
-			continue
-		}
+// selectFile selects the file to import from files matching a data
+// source. Without a pin it is the latest file (see selectLatestFile),
+// with a pin the preferred file type of the pinned release. Returns
+// errReleaseNotFound if no file belongs to the pinned release.
+func selectFile(filenames []string, pin releasePin) (string, error) {
+	if !pin.isSet() {
+		return selectLatestFile(filenames), nil
+	}
 
-		// Check if this file matches any of our ID patterns
-		if matchesIDPattern(filename, idPatterns) && isSFGAFile(filename) {
-			matches = append(matches, filename)
+	var pinned []string
+	for _, filename := range filenames {
+		if pin.matches(parseSFGAFilename(filename)) {
+			pinned = append(pinned, filename)
 		}
 	}
-
-	// Handle no matches
-	if len(matches) == 0 {
-		return "", "", fmt.Errorf(
-			"no files found matching ID %d (patterns: %v) at %s",
-			id,
-			idPatterns,
-			baseURL,
+	if len(pinned) == 0 {
+		return "", fmt.Errorf(
+			"%w: %s, found files %v", errReleaseNotFound, pin, filenames,
 		)
 	}
-
-	// Handle single match
-	if len(matches) == 1 {
-		fullURL := strings.TrimSuffix(baseURL, "/") + "/" + matches[0]
-		return fullURL, "", nil
-	}
-
-	// Handle multiple matches - select latest by date
-	selected := selectLatestFile(matches)
-	warning := fmt.Sprintf("found %d files matching ID %d at %s: %v - selected latest: %s",
-		len(matches), id, baseURL, matches, selected)
-	fullURL := strings.TrimSuffix(baseURL, "/") + "/" + selected
-
-	return fullURL, warning, nil
+	return selectLatestFile(pinned), nil
 }
 
 // selectLatestFile selects the file with the latest date from a list of filenames.
@@ -228,6 +223,19 @@ func isSFGAFile(filename string) bool {
 		strings.HasSuffix(filename, ".sqlite.zip")
 }
 
+// isSourceFile checks if a filename is an archive of the given format.
+// DwC-A and CoLDP archives are .zip files that are not zipped SFGA files,
+// any other format means SFGA.
+func isSourceFile(filename, format string) bool {
+	switch format {
+	case sources.FormatDwCA, sources.FormatCoLDP:
+		return strings.HasSuffix(strings.ToLower(filename), ".zip") &&
+			!isSFGAFile(filename)
+	default:
+		return isSFGAFile(filename)
+	}
+}
+
 // generateIDPatterns creates ID patterns with varying zero-padding lengths.
 // For ID=1: returns ["0001", "001", "01", "1"]
 // For ID=42: returns ["0042", "042", "42"]
@@ -278,6 +286,7 @@ type SFGAMetadata struct {
 	Filename     string // Full filename
 	Version      string // Version string (e.g., "v1.0.0" or "1.0.0")
 	RevisionDate string // Revision date in YYYY-MM-DD format
+	SHA256       string // Checksum of the file from a remote manifest
 }
 
 // parseSFGAFilename extracts version and revision date from SFGA filename.
@@ -320,34 +329,42 @@ func parseSFGAFilename(filename string) SFGAMetadata {
 	return metadata
 }
 
-// fetchSFGA fetches and extracts an SFGA file to the cache directory.
-// For local files, it resolves by ID pattern and uses sflib Archive.Fetch.
-// For URLs, it lists the remote directory to find the matching file by ID.
-// Returns (sqlitePath, metadata, warningMessage, error). Warning is non-empty when multiple files found.
 // resolveSFGAPath determines the SFGA file path without downloading.
-// Returns (sfgaPath, metadata, warning, error).
-func resolveSFGAPath(
+// Local directories and s3:// parents are listed and files are matched
+// by ID, for HTTP(S) URLs the manifest or the directory listing is read.
+// If the source pins a release, only files of this release are selected.
+// An explicit file of the source (populate --file) is used as is.
+// Returns (sfgaPath, metadata, warning, error). Warning is non-empty when
+// multiple files found.
+func (p *populator) resolveSFGAPath(
+	ctx context.Context,
 	source sources.DataSourceConfig,
 ) (string, SFGAMetadata, string, error) {
-	var sfgaPath string
-	var warning string
-	var err error
+	if source.File != "" {
+		metadata, err := checkSourceFile(source.File, source.Format)
+		return source.File, metadata, "", err
+	}
 
 	// Determine if parent is URL or local directory
-	isURL := sources.IsValidURL(source.Parent)
+	if sources.IsValidURL(source.Parent) {
+		// For URLs, read the manifest or the directory listing and find
+		// the file matching the ID
+		return resolveRemoteSFGAFile(
+			ctx, source.Parent, source.ID, source.Format, pinOf(source),
+		)
+	}
+	if sources.IsS3URL(source.Parent) {
+		return resolveS3SFGAFile(
+			p.s3, source.Parent, source.ID, source.Format, pinOf(source),
+		)
+	}
 
-	if isURL {
-		// For URLs, list the directory and find the file matching the ID
-		sfgaPath, warning, err = resolveRemoteSFGAFile(source.Parent, source.ID)
-		if err != nil {
-			return "", SFGAMetadata{}, "", err
-		}
-	} else {
-		// For local directories, resolve the exact filename
-		sfgaPath, warning, err = resolveSFGAFile(source.Parent, source.ID)
-		if err != nil {
-			return "", SFGAMetadata{}, "", err
-		}
+	// For local directories, resolve the exact filename
+	sfgaPath, warning, err := resolveSFGAFile(
+		source.Parent, source.ID, source.Format, pinOf(source),
+	)
+	if err != nil {
+		return "", SFGAMetadata{}, "", err
 	}
 
 	// Extract filename from path
@@ -359,27 +376,100 @@ func resolveSFGAPath(
 	return sfgaPath, metadata, warning, nil
 }
 
-//nolint:unused // Will be used in Phase 3
-func fetchSFGA(
+// checkSourceFile checks an explicit file of a data source and returns
+// metadata from its name. Remote files are checked when they are
+// fetched.
+func checkSourceFile(file, format string) (SFGAMetadata, error) {
+	filename := filepath.Base(file)
+	if !sources.IsValidURL(file) && !sources.IsS3URL(file) {
+		stat, err := os.Stat(file)
+		if err != nil {
+			return SFGAMetadata{}, fmt.Errorf("failed to read file: %w", err)
+		}
+		if stat.IsDir() {
+			return SFGAMetadata{}, fmt.Errorf("%s is a directory", file)
+		}
+	}
+	if !isSourceFile(filename, format) {
+		return SFGAMetadata{}, fmt.Errorf(
+			"%s is not a file of %s format", filename, format,
+		)
+	}
+	return parseSFGAFilename(filename), nil
+}
+
+// sfgaPathError converts an error of resolveSFGAPath to an error for
+// the user.
+func sfgaPathError(source sources.DataSourceConfig, err error) error {
+	if source.File != "" {
+		return FileSourceError(source.ID, source.File, err)
+	}
+	if errors.Is(err, errReleaseNotFound) {
+		return ReleaseNotFoundError(source.ID, pinOf(source).String(), err)
+	}
+	return SFGAFileNotFoundError(source.ID, source.Parent, err)
+}
+
+// fetchSFGA extracts an SFGA file to the cache directory and returns the
+// path of its SQLite database. Remote files are downloaded through the
+// archives cache, so an unchanged file is not downloaded again. If sha256
+// is not empty, the downloaded file must have this checksum. DwC-A and
+// CoLDP archives are converted to SFGA first. Returned errors are ready
+// for the user.
+func (p *populator) fetchSFGA(
+	ctx context.Context,
+	source sources.DataSourceConfig,
 	sfgaPath string,
+	sha256 string,
 	cacheDir string,
 ) (string, error) {
+	if sources.IsValidURL(sfgaPath) || sources.IsS3URL(sfgaPath) {
+		progress, finish := p.downloadProgress(sfgaPath)
+		archive, hit, err := p.cache.Fetch(ctx, sfgaPath, sha256, progress)
+		finish()
+		if err != nil {
+			return "", SFGAReadError(sfgaPath, err)
+		}
+		if hit {
+			p.message("<em>Using cached SFGA archive</em>")
+		}
+		sfgaPath = archive
+	}
+
+	if needsConversion(source) {
+		p.message("<em>Converting %s archive to SFGA</em>", source.Format)
+		converted, err := convertArchive(
+			ctx, p.cfg.HomeDir, source.ID, source.Format, sfgaPath,
+		)
+		if err != nil {
+			return "", ConvertError(source.ID, source.Format, sfgaPath, err)
+		}
+		p.logger().Info("Converted archive to SFGA",
+			"source_id", source.ID,
+			"format", source.Format,
+			"path", converted)
+		sfgaPath = converted
+	}
+
 	// Create Archive for fetching
 	arc := sflib.NewSfga()
 
 	// Fetch and extract to cache directory
 	err := arc.Fetch(sfgaPath, cacheDir)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch SFGA from %s: %w", sfgaPath, err)
+		return "", SFGAReadError(
+			sfgaPath,
+			fmt.Errorf("failed to fetch SFGA from %s: %w", sfgaPath, err),
+		)
 	}
 
 	// Get the path to the extracted SQLite file
 	sqlitePath := arc.DbPath()
 	if sqlitePath == "" {
-		return "", fmt.Errorf(
+		return "", SFGAReadError(sfgaPath, fmt.Errorf(
 			"failed to get database path after fetching %s",
 			sfgaPath,
-		)
+		))
 	}
 
 	return sqlitePath, nil
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/gnames/gndb/pkg/sources"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -164,6 +165,29 @@ func TestIsSFGAFile(t *testing.T) {
 	}
 }
 
+func TestIsSourceFile(t *testing.T) {
+	tests := []struct {
+		filename string
+		format   string
+		expected bool
+	}{
+		{"0001-col.sqlite.zip", "", true},
+		{"0001-col.sqlite.zip", sources.FormatSFGA, true},
+		{"0001-col.zip", sources.FormatSFGA, false},
+		{"0001-col.zip", sources.FormatDwCA, true},
+		{"0001-col.ZIP", sources.FormatCoLDP, true},
+		{"0001-col.sqlite.zip", sources.FormatDwCA, false},
+		{"0001-col.sql.zip", sources.FormatCoLDP, false},
+		{"0001-col.tar.gz", sources.FormatDwCA, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.filename, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isSourceFile(tt.filename, tt.format))
+		})
+	}
+}
+
 func TestGetFileTypePriority(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -260,6 +284,44 @@ func TestSelectLatestFile(t *testing.T) {
 	}
 }
 
+func TestSelectFile(t *testing.T) {
+	files := []string{
+		"1000_ruhoff_2023-08-22_v1.0.0.sqlite.zip",
+		"1000_ruhoff_2024-02-01_v1.1.0.sql.zip",
+		"1000_ruhoff_2024-02-01_v1.1.0.sqlite.zip",
+		"1000_ruhoff_2025-01-10_v2.0.0.sqlite.zip",
+	}
+
+	tests := []struct {
+		name    string
+		pin     releasePin
+		want    string
+		wantErr bool
+	}{
+		{"no pin selects latest", releasePin{}, files[3], false},
+		{"pinned date", releasePin{date: "2023-08-22"}, files[0], false},
+		{"pinned version prefers sqlite.zip",
+			releasePin{version: "v1.1.0"}, files[2], false},
+		{"pinned date and version",
+			releasePin{date: "2025-01-10", version: "v2.0.0"}, files[3], false},
+		{"date and version of different releases",
+			releasePin{date: "2023-08-22", version: "v2.0.0"}, "", true},
+		{"missing version", releasePin{version: "v3.0.0"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := selectFile(files, tt.pin)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errReleaseNotFound)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, res)
+		})
+	}
+}
+
 func TestParseSFGAFilename(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -369,7 +431,7 @@ func TestResolveSFGAFile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			path, warning, err := resolveSFGAFile(tmpDir, tt.id)
+			path, warning, err := resolveSFGAFile(tmpDir, tt.id, "", releasePin{})
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -387,3 +449,39 @@ func TestResolveSFGAFile(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckSourceFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses file system in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	sfga := filepath.Join(tmpDir, "trial_2025-10-03_v1.0.sqlite.zip")
+	require.NoError(t, os.WriteFile(sfga, []byte("test"), 0644))
+	dwca := filepath.Join(tmpDir, "trial-dwca.zip")
+	require.NoError(t, os.WriteFile(dwca, []byte("test"), 0644))
+
+	metadata, err := checkSourceFile(sfga, sources.FormatSFGA)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-10-03", metadata.RevisionDate)
+	assert.Equal(t, "v1.0", metadata.Version)
+
+	_, err = checkSourceFile(dwca, sources.FormatDwCA)
+	require.NoError(t, err)
+
+	_, err = checkSourceFile(dwca, sources.FormatSFGA)
+	assert.Error(t, err, "DwC-A archive is not an SFGA file")
+
+	_, err = checkSourceFile(filepath.Join(tmpDir, "missing.sqlite"), "")
+	assert.Error(t, err)
+
+	_, err = checkSourceFile(tmpDir, "")
+	assert.Error(t, err)
+
+	// Remote files are checked when they are fetched
+	metadata, err = checkSourceFile(
+		"https://example.org/sfga/1500-trial-2025-10-03.sqlite.zip", "",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-10-03", metadata.RevisionDate)
+}
@@ -1,13 +1,18 @@
 package iopopulate
 
 import (
+	"context"
+
 	"github.com/gnames/gndb/pkg/config"
 	"github.com/gnames/gnlib"
 )
 
-func (p *populator) checkSfgaVersion(sourceID int) error {
+func (p *populator) checkSfgaVersion(
+	ctx context.Context,
+	sourceID int,
+) error {
 	var version string
-	row := p.sfgaDB.QueryRow("SELECT sf__id FROM VERSION LIMIT 1")
+	row := p.sfgaDB.QueryRowContext(ctx, "SELECT sf__id FROM VERSION LIMIT 1")
 	err := row.Scan(&version)
 	if err != nil {
 		return SfgaGetVersionError(sourceID, err)
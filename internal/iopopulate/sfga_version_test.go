@@ -1,6 +1,7 @@
 package iopopulate
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 
@@ -48,7 +49,7 @@ func TestCheckSfgaVersion(t *testing.T) {
 			}
 
 			// Call the method under test.
-			err = p.checkSfgaVersion(tt.sourceID)
+			err = p.checkSfgaVersion(context.Background(), tt.sourceID)
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -79,7 +80,7 @@ func TestCheckSfgaVersionGetError(t *testing.T) {
 		sfgaDB: db,
 	}
 
-	err = p.checkSfgaVersion(999)
+	err = p.checkSfgaVersion(context.Background(), 999)
 	require.Error(t, err)
 
 	gnErr, ok := err.(*gn.Error)
@@ -109,7 +110,7 @@ func TestCheckSfgaVersionInvalidFormat(t *testing.T) {
 		sfgaDB: db,
 	}
 
-	err = p.checkSfgaVersion(999)
+	err = p.checkSfgaVersion(context.Background(), 999)
 	require.Error(t, err)
 
 	gnErr, ok := err.(*gn.Error)
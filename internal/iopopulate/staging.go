@@ -0,0 +1,291 @@
+package iopopulate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gnames/gndb/pkg/schema"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// stagingSchema is the PostgreSQL schema that keeps per-source staging
+// tables. Indices of a source are loaded there first and are moved to the
+// live tables in one transaction at the end of the import, so readers of
+// the database never see a half-imported source. A separate schema keeps
+// staging tables out of Atlas migrations, which only inspect "public".
+const stagingSchema = "gndb_staging"
+
+// Live tables that receive data from staging tables.
+const (
+	nameIndicesTable = "name_string_indices"
+	vernIndicesTable = "vernacular_string_indices"
+	refsTable        = "source_references"
+)
+
+// execer is implemented by both pgxpool.Pool and pgx.Tx.
+type execer interface {
+	Exec(
+		ctx context.Context, sql string, args ...any,
+	) (pgconn.CommandTag, error)
+}
+
+// stagingIdent returns the identifier of the staging table that mirrors
+// the given live table for a data source.
+func stagingIdent(table string, sourceID int) pgx.Identifier {
+	return pgx.Identifier{stagingSchema, fmt.Sprintf("%s_%d", table, sourceID)}
+}
+
+// stagingName returns a quoted name of a staging table for use in SQL.
+func stagingName(table string, sourceID int) string {
+	return stagingIdent(table, sourceID).Sanitize()
+}
+
+// createStagingTable (re)creates an empty staging table for a data source
+// with the same columns as the live table. Indices and primary keys are
+// not copied to keep bulk loading fast; they are enforced when rows are
+// moved to the live table.
+func (p *populator) createStagingTable(
+	ctx context.Context,
+	table string,
+	sourceID int,
+) error {
+	pool := p.operator.Pool()
+
+	stmts := []string{
+		"CREATE SCHEMA IF NOT EXISTS " + stagingSchema,
+		"DROP TABLE IF EXISTS " + stagingName(table, sourceID),
+		fmt.Sprintf(
+			"CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS)",
+			stagingName(table, sourceID),
+			pgx.Identifier{"public", table}.Sanitize(),
+		),
+	}
+
+	for _, q := range stmts {
+		if _, err := pool.Exec(ctx, q); err != nil {
+			return fmt.Errorf("failed to create staging table for %s: %w",
+				table, err)
+		}
+	}
+
+	p.logger().Info("Created staging table",
+		"data_source_id", sourceID,
+		"table", stagingName(table, sourceID),
+	)
+	return nil
+}
+
+// dropStagingTable removes a staging table if it exists.
+func (p *populator) dropStagingTable(
+	ctx context.Context,
+	table string,
+	sourceID int,
+) error {
+	q := "DROP TABLE IF EXISTS " + stagingName(table, sourceID)
+	_, err := p.operator.Pool().Exec(ctx, q)
+	if err != nil {
+		return fmt.Errorf("failed to drop staging table for %s: %w",
+			table, err)
+	}
+	return nil
+}
+
+// stagingTableExists checks if a staging table was created for a source.
+func (p *populator) stagingTableExists(
+	ctx context.Context,
+	table string,
+	sourceID int,
+) (bool, error) {
+	var exists bool
+	q := "SELECT to_regclass($1) IS NOT NULL"
+	err := p.operator.Pool().QueryRow(
+		ctx, q, stagingName(table, sourceID),
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check staging table: %w", err)
+	}
+	return exists, nil
+}
+
+// stagingCount returns the number of rows in a staging table.
+func (p *populator) stagingCount(
+	ctx context.Context,
+	table string,
+	sourceID int,
+) (int, error) {
+	var count int
+	q := "SELECT COUNT(*) FROM " + stagingName(table, sourceID)
+	err := p.operator.Pool().QueryRow(ctx, q).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count staging rows of %s: %w",
+			table, err)
+	}
+	return count, nil
+}
+
+// swapSource replaces the live data of a source with the content of its
+// staging tables and writes the data_sources record. Everything happens
+// in one transaction: if any step fails, the previous version of the
+// source stays untouched. Staging tables are dropped after commit.
+//
+// Vernacular indices and references are optional. If their staging
+// table does not exist (their import failed), the live ones are kept as
+// is.
+//
+// With --delta only records that differ between staged and live tables
+// are written. The returned stats count added, changed and removed
+// name index records. Without --delta all live records are removed and
+// all staged ones are added.
+func (p *populator) swapSource(
+	ctx context.Context,
+	ds schema.DataSource,
+) (deltaStats, error) {
+	var stats deltaStats
+
+	hasVern, err := p.stagingTableExists(ctx, vernIndicesTable, ds.ID)
+	if err != nil {
+		return stats, err
+	}
+
+	hasRefs, err := p.stagingTableExists(ctx, refsTable, ds.ID)
+	if err != nil {
+		return stats, err
+	}
+
+	tables := []string{nameIndicesTable}
+	if hasVern {
+		tables = append(tables, vernIndicesTable)
+	} else {
+		p.logger().Warn("No staged vernaculars, keeping previous ones",
+			"data_source_id", ds.ID)
+	}
+	if hasRefs {
+		tables = append(tables, refsTable)
+	} else {
+		p.logger().Warn("No staged references, keeping previous ones",
+			"data_source_id", ds.ID)
+	}
+
+	tx, err := p.operator.Pool().Begin(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to start swap transaction: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx)) //nolint:errcheck // no-op after commit
+
+	if p.cfg.Populate.Delta {
+		stats, err = p.mergeDelta(ctx, tx, ds.ID, hasVern, hasRefs)
+		if err != nil {
+			return stats, err
+		}
+	} else {
+		for _, table := range tables {
+			removed, added, err := replaceRows(ctx, tx, table, ds.ID)
+			if err != nil {
+				return stats, err
+			}
+			if table == nameIndicesTable {
+				stats.added, stats.removed = added, removed
+			}
+		}
+	}
+
+	if err = deleteDataSource(ctx, tx, ds.ID); err != nil {
+		return stats, fmt.Errorf("failed to delete existing data source: %w", err)
+	}
+
+	if err = insertDataSource(ctx, tx, ds); err != nil {
+		return stats, fmt.Errorf("failed to insert data source: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return stats, fmt.Errorf("failed to commit swap transaction: %w", err)
+	}
+
+	p.logger().Info("Swapped staged data into live tables",
+		"data_source_id", ds.ID)
+
+	for _, table := range tables {
+		if err = p.dropStagingTable(ctx, table, ds.ID); err != nil {
+			p.logger().Warn("Cannot drop staging table",
+				"data_source_id", ds.ID,
+				"table", table,
+				"error", err,
+			)
+		}
+	}
+
+	return stats, nil
+}
+
+// replaceRows replaces all live rows of a source in the table with the
+// staged ones. Returns the number of removed and added rows.
+func replaceRows(
+	ctx context.Context,
+	tx pgx.Tx,
+	table string,
+	sourceID int,
+) (int, int, error) {
+	live := pgx.Identifier{table}.Sanitize()
+	del := "DELETE FROM " + live + " WHERE data_source_id = $1"
+	delTag, err := tx.Exec(ctx, del, sourceID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to remove old rows of %s: %w", table, err)
+	}
+
+	ins := fmt.Sprintf("INSERT INTO %s SELECT * FROM %s",
+		live, stagingName(table, sourceID))
+	insTag, err := tx.Exec(ctx, ins)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to move staged rows to %s: %w", table, err)
+	}
+	return int(delTag.RowsAffected()), int(insTag.RowsAffected()), nil
+}
+
+// mergeDelta writes only the differences between staged and live
+// indices and references of a source (see delta.go).
+func (p *populator) mergeDelta(
+	ctx context.Context,
+	tx pgx.Tx,
+	sourceID int,
+	hasVern, hasRefs bool,
+) (deltaStats, error) {
+	stats, err := mergeNameIndicesDelta(ctx, tx, sourceID)
+	if err != nil {
+		return stats, fmt.Errorf("failed to merge name indices: %w", err)
+	}
+	p.logger().Info("Merged name indices delta",
+		"data_source_id", sourceID,
+		"added", stats.added,
+		"changed", stats.changed,
+		"removed", stats.removed,
+	)
+
+	if hasVern {
+		vern, err := mergeVernacularIndicesDelta(ctx, tx, sourceID)
+		if err != nil {
+			return stats, fmt.Errorf(
+				"failed to merge vernacular indices: %w", err,
+			)
+		}
+		p.logger().Info("Merged vernacular indices delta",
+			"data_source_id", sourceID,
+			"added", vern.added,
+			"removed", vern.removed,
+		)
+	}
+
+	if hasRefs {
+		refs, err := mergeReferencesDelta(ctx, tx, sourceID)
+		if err != nil {
+			return stats, fmt.Errorf("failed to merge references: %w", err)
+		}
+		p.logger().Info("Merged references delta",
+			"data_source_id", sourceID,
+			"added", refs.added,
+			"changed", refs.changed,
+			"removed", refs.removed,
+		)
+	}
+	return stats, nil
+}
@@ -0,0 +1,159 @@
+package iopopulate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gnames/gndb/pkg/sources"
+	"github.com/jackc/pgx/v5"
+)
+
+// errUnchanged is returned when the database has the release of a data
+// source already, so the source is not imported again.
+var errUnchanged = errors.New("data source release is imported already")
+
+// checkUnchanged returns errUnchanged if the resolved SFGA file of a
+// source is the release stored in data_sources. Version and revision
+// date are compared with the stored ones, checksums are compared too if
+// both the resolved file and the state of the previous import have one.
+// The source is imported again if its settings changed since the
+// previous import, or if they are unknown. With --force sources are
+// always imported.
+//
+// An error reading the stored release is logged and the source is
+// imported.
+func (p *populator) checkUnchanged(
+	ctx context.Context,
+	source sources.DataSourceConfig,
+	metadata SFGAMetadata,
+) error {
+	if p.cfg.Populate.Force {
+		return nil
+	}
+
+	stored, ok, err := p.storedRelease(ctx, source.ID)
+	if err != nil {
+		// The source is imported if its stored release is unknown.
+		p.logger().Warn("Cannot compare with stored release",
+			"data_source_id", source.ID,
+			"error", err,
+		)
+		return nil
+	}
+	if !ok || !sameRelease(metadata, stored) {
+		return nil
+	}
+	if stored.Settings != p.settingsHash(source) {
+		p.logger().Info("Source settings changed since the last import",
+			"data_source_id", source.ID,
+		)
+		return nil
+	}
+
+	p.logger().Info("Source release is imported already",
+		"data_source_id", source.ID,
+		"version", metadata.Version,
+		"date", metadata.RevisionDate,
+	)
+	return errUnchanged
+}
+
+// storedRelease returns the release of a source in data_sources. The
+// checksum and the settings hash come from the state of the import that
+// stored the release.
+// Returns false if the source is not in the database.
+func (p *populator) storedRelease(
+	ctx context.Context,
+	sourceID int,
+) (release, bool, error) {
+	query := `
+		SELECT COALESCE(version, ''), COALESCE(revision_date, '')
+		FROM data_sources
+		WHERE id = $1
+	`
+
+	var res release
+	err := p.operator.Pool().QueryRow(ctx, query, sourceID).
+		Scan(&res.Version, &res.RevisionDate)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return release{}, false, nil
+	}
+	if err != nil {
+		return release{}, false, fmt.Errorf(
+			"failed to query stored release of source %d: %w", sourceID, err,
+		)
+	}
+
+	if p.checkpoints != nil {
+		r, ok := p.checkpoints.getRelease(sourceID)
+		if ok && r.Version == res.Version && r.RevisionDate == res.RevisionDate {
+			res.SHA256 = r.SHA256
+			res.Settings = r.Settings
+		}
+	}
+	return res, true, nil
+}
+
+// sameRelease returns true if the resolved file belongs to the stored
+// release. A file without version and date matches only by checksum,
+// different known checksums never match.
+func sameRelease(m SFGAMetadata, stored release) bool {
+	hasSums := m.SHA256 != "" && stored.SHA256 != ""
+	if hasSums && m.SHA256 != stored.SHA256 {
+		return false
+	}
+	if m.Version == "" && m.RevisionDate == "" {
+		return hasSums
+	}
+	return m.Version == stored.Version && m.RevisionDate == stored.RevisionDate
+}
+
+// settingsHash returns the hash of the settings that change imported
+// records of a source: its configuration in sources.yaml, like filters
+// and policies, flat classification and the policies of the run.
+func (p *populator) settingsHash(source sources.DataSourceConfig) string {
+	settings := struct {
+		Source            sources.DataSourceConfig
+		Flat              bool
+		OnEmptyNameString string
+		OnDuplicateID     string
+		OnDanglingSynonym string
+	}{
+		Source:            source,
+		Flat:              p.useFlatClassification(),
+		OnEmptyNameString: p.cfg.Populate.OnEmptyNameString,
+		OnDuplicateID:     p.cfg.Populate.OnDuplicateRecordID,
+		OnDanglingSynonym: p.cfg.Populate.OnDanglingSynonym,
+	}
+	// The struct has only plain fields, so encoding cannot fail.
+	data, _ := json.Marshal(settings)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// saveRelease records the release of a completely imported source, so
+// the next run can compare its checksum and settings. Failure to save it
+// does not affect the import, so it is only logged.
+func (p *populator) saveRelease(
+	source sources.DataSourceConfig,
+	file string,
+	m SFGAMetadata,
+) {
+	err := p.checkpoints.setRelease(source.ID, release{
+		SFGAFile:     file,
+		Version:      m.Version,
+		RevisionDate: m.RevisionDate,
+		SHA256:       m.SHA256,
+		Settings:     p.settingsHash(source),
+	})
+	if err != nil {
+		p.logger().Warn("Cannot save imported release",
+			"data_source_id", source.ID,
+			"error", err,
+		)
+	}
+}
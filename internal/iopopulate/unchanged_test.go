@@ -0,0 +1,109 @@
+package iopopulate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gnames/gndb/pkg/config"
+	"github.com/gnames/gndb/pkg/sources"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSameRelease(t *testing.T) {
+	stored := release{
+		Version:      "v1.0.0",
+		RevisionDate: "2025-10-03",
+		SHA256:       "abc",
+	}
+
+	tests := []struct {
+		name   string
+		file   SFGAMetadata
+		stored release
+		want   bool
+	}{
+		{
+			name:   "same version and date",
+			file:   SFGAMetadata{Version: "v1.0.0", RevisionDate: "2025-10-03"},
+			stored: stored,
+			want:   true,
+		},
+		{
+			name: "same version, date and checksum",
+			file: SFGAMetadata{
+				Version: "v1.0.0", RevisionDate: "2025-10-03", SHA256: "abc",
+			},
+			stored: stored,
+			want:   true,
+		},
+		{
+			name:   "newer date",
+			file:   SFGAMetadata{Version: "v1.0.0", RevisionDate: "2025-11-01"},
+			stored: stored,
+			want:   false,
+		},
+		{
+			name:   "different version",
+			file:   SFGAMetadata{Version: "v1.1.0", RevisionDate: "2025-10-03"},
+			stored: stored,
+			want:   false,
+		},
+		{
+			name: "different checksum",
+			file: SFGAMetadata{
+				Version: "v1.0.0", RevisionDate: "2025-10-03", SHA256: "def",
+			},
+			stored: stored,
+			want:   false,
+		},
+		{
+			name:   "no metadata, same checksum",
+			file:   SFGAMetadata{SHA256: "abc"},
+			stored: release{SHA256: "abc"},
+			want:   true,
+		},
+		{
+			name:   "no metadata, no checksum",
+			file:   SFGAMetadata{},
+			stored: release{},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sameRelease(tt.file, tt.stored))
+		})
+	}
+}
+
+func TestCheckUnchangedForce(t *testing.T) {
+	cfg := config.New()
+	cfg.Populate.Force = true
+	p := &populator{cfg: cfg}
+
+	// With --force the database is not queried at all.
+	err := p.checkUnchanged(
+		context.Background(),
+		sources.DataSourceConfig{ID: 1},
+		SFGAMetadata{Version: "v1.0.0"},
+	)
+	assert.NoError(t, err)
+}
+
+func TestSettingsHash(t *testing.T) {
+	p := &populator{cfg: config.New()}
+	source := sources.DataSourceConfig{ID: 1, Parent: "/data"}
+	hash := p.settingsHash(source)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, p.settingsHash(source))
+
+	// Filters change imported records.
+	filtered := source
+	filtered.Filters = &sources.Filters{Ranks: []string{"species"}}
+	assert.NotEqual(t, hash, p.settingsHash(filtered))
+
+	// So do policies of the run.
+	p.cfg.Populate.OnDuplicateRecordID = "skip"
+	assert.NotEqual(t, hash, p.settingsHash(source))
+}
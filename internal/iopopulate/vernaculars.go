@@ -3,7 +3,7 @@ package iopopulate
 import (
 	"context"
 	"fmt"
-	"log/slog"
+	"slices"
 	"strings"
 
 	"github.com/dustin/go-humanize"
@@ -33,23 +33,24 @@ type vernIndex struct {
 //
 // Returns error if SFGA query or database insert fails.
 func (p *populator) processVernaculars(
+	ctx context.Context,
 	sourceID int,
-) (string, error) {
-	slog.Info("Processing vernacular names", "data_source_id", sourceID)
+) (string, int, error) {
+	p.logger().Info("Processing vernacular names", "data_source_id", sourceID)
 
 	// Phase 1: Process vernacular strings (unique names)
-	vernStrNum, err := p.processVernacularStrings()
+	vernStrNum, err := p.processVernacularStrings(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to process vernacular strings: %w", err)
+		return "", 0, fmt.Errorf("failed to process vernacular strings: %w", err)
 	}
 
 	// Phase 2: Process vernacular indices (links to data source with metadata)
-	vernIdxNum, err := p.processVernacularIndices(sourceID)
+	vernIdxNum, err := p.processVernacularIndices(ctx, sourceID)
 	if err != nil {
-		return "", fmt.Errorf("failed to process vernacular indices: %w", err)
+		return "", 0, fmt.Errorf("failed to process vernacular indices: %w", err)
 	}
 
-	slog.Info("Vernacular processing complete",
+	p.logger().Info("Vernacular processing complete",
 		"data_source_id", sourceID,
 		"strings", vernStrNum,
 		"indices", vernIdxNum)
@@ -62,19 +63,44 @@ func (p *populator) processVernaculars(
 	if vernStrNum == 0 && vernIdxNum == 0 {
 		msg = "<em>No vernacular names found</em>"
 	}
-	return msg, nil
+	return msg, vernIdxNum, nil
+}
+
+// getTotalVernacularCount returns the number of vernacular indices the
+// SFGA file would produce.
+func (p *populator) getTotalVernacularCount(ctx context.Context) (int, error) {
+	var totalCount int
+	countQuery := `
+		SELECT COUNT(*) FROM (
+			SELECT DISTINCT
+				col__taxon_id, col__name, col__language,
+				col__area, col__country
+			FROM vernacular
+		)
+	`
+
+	err := p.sfgaDB.QueryRowContext(ctx, countQuery).Scan(&totalCount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count vernaculars: %w", err)
+	}
+
+	return totalCount, nil
 }
 
 // processVernacularStrings reads unique vernacular names from SFGA and
 // inserts them into vernacular_strings table with UUID v5 identifiers.
 // Uses ON CONFLICT DO NOTHING for deduplication across data sources.
-func (p *populator) processVernacularStrings() (int, error) {
-	slog.Info("Phase 1: Processing vernacular strings")
+// If the source has filters, only names of kept taxa are inserted.
+func (p *populator) processVernacularStrings(ctx context.Context) (int, error) {
+	p.logger().Info("Phase 1: Processing vernacular strings")
 
 	// Query unique vernacular names from SFGA
-	query := `SELECT DISTINCT col__name FROM vernacular`
+	query := `SELECT DISTINCT '', col__name FROM vernacular`
+	if p.filter != nil {
+		query = `SELECT DISTINCT col__taxon_id, col__name FROM vernacular`
+	}
 
-	rows, err := p.sfgaDB.Query(query)
+	rows, err := p.sfgaDB.QueryContext(ctx, query)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query SFGA vernacular table: %w", err)
 	}
@@ -87,12 +113,20 @@ func (p *populator) processVernacularStrings() (int, error) {
 	}
 
 	var vernStrings []vernString
+	seen := make(map[string]bool)
 	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
+		var taxonID, name string
+		if err := rows.Scan(&taxonID, &name); err != nil {
 			return 0, fmt.Errorf("failed to scan vernacular name: %w", err)
 		}
 
+		if p.filter != nil {
+			if !p.filter.keepVernacular(taxonID) || seen[name] {
+				continue
+			}
+			seen[name] = true
+		}
+
 		// Truncate if too long (vernacular_strings.name is varchar(500))
 		if len(name) > 500 {
 			name = name[:500]
@@ -116,10 +150,16 @@ func (p *populator) processVernacularStrings() (int, error) {
 
 	// If no vernaculars, nothing to do
 	if len(vernStrings) == 0 {
-		slog.Info("No vernacular names found in SFGA")
+		p.logger().Info("No vernacular names found in SFGA")
 		return 0, nil
 	}
 
+	// Sort by ID, so sources imported in parallel lock rows in the same
+	// order and cannot deadlock each other.
+	slices.SortFunc(vernStrings, func(a, b vernString) int {
+		return strings.Compare(a.id, b.id)
+	})
+
 	// Batch insert configuration
 	// PostgreSQL has a limit of 65535 parameters per query.
 	// With 2 parameters per row (id, name), max is 32767 rows.
@@ -151,26 +191,28 @@ func (p *populator) processVernacularStrings() (int, error) {
 			joinStrings(valueStrings, ","),
 		)
 
-		_, err := p.operator.Pool().Exec(context.Background(), insertQuery, valueArgs...)
+		_, err := p.operator.Pool().Exec(ctx, insertQuery, valueArgs...)
 		if err != nil {
 			return 0, fmt.Errorf("failed to insert vernacular strings batch: %w", err)
 		}
 	}
 
-	slog.Info("Processed vernacular strings", "count", len(vernStrings))
+	p.logger().Info("Processed vernacular strings", "count", len(vernStrings))
 	return len(vernStrings), nil
 }
 
 // processVernacularIndices reads vernacular records from SFGA with metadata
 // and inserts them into vernacular_string_indices table, linking to data source.
+// If the source has filters, only vernaculars of kept taxa are inserted.
 func (p *populator) processVernacularIndices(
+	ctx context.Context,
 	sourceID int,
 ) (int, error) {
-	slog.Info("Phase 2: Processing vernacular indices", "data_source_id", sourceID)
+	p.logger().Info("Phase 2: Processing vernacular indices", "data_source_id", sourceID)
 
-	// Clean old vernacular indices for this data source
-	if err := cleanVernacularIndices(p, sourceID); err != nil {
-		return 0, fmt.Errorf("failed to clean old vernacular indices: %w", err)
+	// Load vernacular indices into an empty staging table
+	if err := p.createStagingTable(ctx, vernIndicesTable, sourceID); err != nil {
+		return 0, err
 	}
 
 	// Query SFGA vernacular table with all metadata
@@ -181,7 +223,7 @@ func (p *populator) processVernacularIndices(
 		FROM vernacular
 	`
 
-	rows, err := p.sfgaDB.Query(query)
+	rows, err := p.sfgaDB.QueryContext(ctx, query)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query SFGA vernacular indices: %w", err)
 	}
@@ -197,6 +239,10 @@ func (p *populator) processVernacularIndices(
 			return 0, fmt.Errorf("failed to scan vernacular index row: %w", err)
 		}
 
+		if !p.filter.keepVernacular(recordID) {
+			continue
+		}
+
 		// Truncate name if too long (to match vernacular_strings processing)
 		if len(name) > 500 {
 			name = name[:500]
@@ -236,37 +282,24 @@ func (p *populator) processVernacularIndices(
 
 	// If no indices, nothing to do
 	if len(indices) == 0 {
-		slog.Info("No vernacular indices to process", "data_source_id", sourceID)
+		p.logger().Info("No vernacular indices to process", "data_source_id", sourceID)
 		return 0, nil
 	}
 
 	// Bulk insert using pgx.CopyFrom
-	err = bulkInsertVernacularIndices(p, sourceID, indices)
+	err = bulkInsertVernacularIndices(ctx, p, sourceID, indices)
 	if err != nil {
 		return 0, fmt.Errorf("failed to bulk insert vernacular indices: %w", err)
 	}
 
-	slog.Info("Processed vernacular indices", "data_source_id", sourceID, "count", len(indices))
+	p.logger().Info("Processed vernacular indices", "data_source_id", sourceID, "count", len(indices))
 	return len(indices), nil
 }
 
-// cleanVernacularIndices removes old vernacular indices for a data source.
-func cleanVernacularIndices(p *populator, sourceID int) error {
-	slog.Info("Cleaning old vernacular indices", "data_source_id", sourceID)
-
-	query := `DELETE FROM vernacular_string_indices WHERE data_source_id = $1`
-
-	_, err := p.operator.Pool().Exec(context.Background(), query, sourceID)
-	if err != nil {
-		return fmt.Errorf("failed to delete old vernacular indices: %w", err)
-	}
-
-	return nil
-}
-
 // bulkInsertVernacularIndices performs efficient bulk insert of vernacular indices
-// using pgx.CopyFrom.
+// into the staging table of the source using pgx.CopyFrom.
 func bulkInsertVernacularIndices(
+	ctx context.Context,
 	p *populator,
 	sourceID int,
 	indices []vernIndex,
@@ -289,8 +322,8 @@ func bulkInsertVernacularIndices(
 
 	// Use CopyFrom for efficient bulk insert
 	_, err := p.operator.Pool().CopyFrom(
-		context.Background(),
-		pgx.Identifier{"vernacular_string_indices"},
+		ctx,
+		stagingIdent(vernIndicesTable, sourceID),
 		[]string{
 			"data_source_id",
 			"record_id",
@@ -0,0 +1,87 @@
+// Package ioprogress implements gndb.ProgressSink for the terminal and
+// for JSON lines. This is an impure I/O package, events themselves are
+// defined in pkg/gndb.
+package ioprogress
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gnames/gndb/pkg/gndb"
+)
+
+// minInterval is the shortest time between two progress events of a
+// counter, so millions of records do not produce millions of events.
+const minInterval = 250 * time.Millisecond
+
+// Counter reports progress of a long operation to a ProgressSink as
+// EventProgress events. It is safe for concurrent use.
+type Counter struct {
+	sink  gndb.ProgressSink
+	event gndb.Event
+
+	mu       sync.Mutex
+	count    int
+	last     time.Time
+	finished bool
+}
+
+// NewCounter starts a progress bar of total records, 0 if the total is
+// not known. Message of e is the label of the bar, SourceID, Parallel
+// and Stage are copied to every event of the counter.
+func NewCounter(sink gndb.ProgressSink, e gndb.Event, total int) *Counter {
+	e.Type = gndb.EventProgress
+	e.Total = total
+	c := &Counter{sink: sink, event: e}
+	c.emit(false)
+	return c
+}
+
+// Increment adds one processed record.
+func (c *Counter) Increment() {
+	c.Add(1)
+}
+
+// Add adds n processed records.
+func (c *Counter) Add(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.count += n
+	c.throttle()
+}
+
+// SetCurrent sets the number of processed records.
+func (c *Counter) SetCurrent(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.count = n
+	c.throttle()
+}
+
+// Finish sends the last event of the counter. Calls after the first
+// one do nothing.
+func (c *Counter) Finish() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.finished {
+		return
+	}
+	c.finished = true
+	c.emit(true)
+}
+
+// throttle sends an event if minInterval passed since the last one.
+func (c *Counter) throttle() {
+	if c.finished || time.Since(c.last) < minInterval {
+		return
+	}
+	c.emit(false)
+}
+
+func (c *Counter) emit(done bool) {
+	e := c.event
+	e.Count = c.count
+	e.Done = done
+	c.last = time.Now()
+	c.sink.Emit(e)
+}
@@ -0,0 +1,73 @@
+package ioprogress
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gnames/gndb/pkg/gndb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder keeps emitted events.
+type recorder struct {
+	mu     sync.Mutex
+	events []gndb.Event
+}
+
+func (r *recorder) Emit(e gndb.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func TestCounter(t *testing.T) {
+	rec := &recorder{}
+	c := NewCounter(rec, gndb.Event{
+		SourceID: 1,
+		Stage:    "names",
+		Message:  "Processing names: ",
+	}, 1000)
+
+	for range 1000 {
+		c.Increment()
+	}
+	c.Finish()
+	c.Finish()
+
+	// Events are throttled, only the first and the last one are certain.
+	require.GreaterOrEqual(t, len(rec.events), 2)
+	first, last := rec.events[0], rec.events[len(rec.events)-1]
+
+	assert.Equal(t, gndb.EventProgress, first.Type)
+	assert.Equal(t, 0, first.Count)
+	assert.Equal(t, 1000, first.Total)
+	assert.False(t, first.Done)
+
+	assert.Equal(t, gndb.EventProgress, last.Type)
+	assert.Equal(t, 1, last.SourceID)
+	assert.Equal(t, "names", last.Stage)
+	assert.Equal(t, "Processing names: ", last.Message)
+	assert.Equal(t, 1000, last.Count)
+	assert.True(t, last.Done)
+
+	var done int
+	for _, e := range rec.events {
+		if e.Done {
+			done++
+		}
+	}
+	assert.Equal(t, 1, done, "Finish sends one last event")
+}
+
+func TestCounterSetCurrent(t *testing.T) {
+	rec := &recorder{}
+	c := NewCounter(rec, gndb.Event{Message: "Exporting taxa: "}, 50)
+	c.SetCurrent(20)
+	c.SetCurrent(40)
+	c.Finish()
+
+	last := rec.events[len(rec.events)-1]
+	assert.Equal(t, 40, last.Count)
+	assert.True(t, last.Done)
+}
@@ -0,0 +1,66 @@
+package ioprogress
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gnames/gndb/pkg/gndb"
+)
+
+// tagsRe matches inline formatting tags of github.com/gnames/gn.
+var tagsRe = regexp.MustCompile(`</?(title|warn|em|err)>`)
+
+// jsonLines writes every event as one line of JSON.
+type jsonLines struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// jsonEvent adds the text of the error to an event.
+type jsonEvent struct {
+	gndb.Event
+	Error string `json:"error,omitempty"`
+}
+
+// NewJSONLines creates a ProgressSink for automation. Every event is
+// written to w as a JSON object on its own line. Formatting tags are
+// removed from messages.
+func NewJSONLines(w io.Writer) gndb.ProgressSink {
+	return &jsonLines{w: w}
+}
+
+// Emit writes the event.
+func (j *jsonLines) Emit(e gndb.Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Message = plainText(e.Message)
+
+	je := jsonEvent{Event: e}
+	if e.Err != nil {
+		je.Error = e.Err.Error()
+	}
+
+	line, err := json.Marshal(je)
+	if err != nil {
+		slog.Warn("Cannot encode progress event", "type", e.Type, "error", err)
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err = j.w.Write(append(line, '\n')); err != nil {
+		slog.Warn("Cannot write progress event", "type", e.Type, "error", err)
+	}
+}
+
+// plainText removes formatting tags and surrounding white space from a
+// message.
+func plainText(msg string) string {
+	return strings.TrimSpace(tagsRe.ReplaceAllString(msg, ""))
+}
@@ -0,0 +1,68 @@
+package ioprogress
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gnames/gndb/pkg/gndb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONLines(&buf)
+
+	sink.Emit(gndb.Event{
+		Type:        gndb.EventStageFinished,
+		SourceID:    1,
+		Stage:       "names",
+		Step:        2,
+		Steps:       6,
+		Count:       42,
+		DurationSec: 1.5,
+		Message:     "<em>Inserted 42 name strings</em>",
+	})
+	sink.Emit(gndb.Event{
+		Type:     gndb.EventError,
+		SourceID: 1,
+		Err:      errors.New("connection lost"),
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var stage map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &stage))
+	assert.Equal(t, "stage_finished", stage["type"])
+	assert.Equal(t, float64(1), stage["source_id"])
+	assert.Equal(t, "names", stage["stage"])
+	assert.Equal(t, float64(42), stage["count"])
+	assert.Equal(t, "Inserted 42 name strings", stage["message"])
+	assert.NotEmpty(t, stage["time"])
+	assert.NotContains(t, stage, "error")
+	assert.NotContains(t, stage, "total")
+
+	var errEvent map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &errEvent))
+	assert.Equal(t, "error", errEvent["type"])
+	assert.Equal(t, "connection lost", errEvent["error"])
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		msg, want string
+	}{
+		{"plain", "plain"},
+		{"<em>Imported</em> 10 names", "Imported 10 names"},
+		{"<err>Error:</err> <warn>bad</warn> <title>T</title>", "Error: bad T"},
+		{"Export complete\nElapsed time: <em>1s</em>\n", "Export complete\nElapsed time: 1s"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, plainText(tt.msg))
+	}
+}
@@ -0,0 +1,129 @@
+package ioprogress
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/cheggaaa/pb/v3"
+	"github.com/dustin/go-humanize"
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/gndb"
+	"github.com/gnames/gnfmt"
+)
+
+// separator divides output of data sources.
+var separator = strings.Repeat("─", 60)
+
+// terminal shows events as colored messages and progress bars.
+type terminal struct {
+	mu   sync.Mutex
+	bars map[string]*pb.ProgressBar
+}
+
+// NewTerminal creates a ProgressSink for humans. Events of data sources
+// imported in parallel are prefixed with the data source ID, and their
+// progress bars are hidden, because they would overwrite each other.
+func NewTerminal() gndb.ProgressSink {
+	return &terminal{bars: make(map[string]*pb.ProgressBar)}
+}
+
+// defaultTerminal is shared by all users of Default.
+var defaultTerminal = sync.OnceValue(NewTerminal)
+
+// Default returns a terminal ProgressSink shared by the whole program.
+// It is used when no sink is given.
+func Default() gndb.ProgressSink {
+	return defaultTerminal()
+}
+
+// Emit prints the event.
+func (t *terminal) Emit(e gndb.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var prefix string
+	if e.Parallel && e.SourceID > 0 {
+		prefix = fmt.Sprintf("[%d] ", e.SourceID)
+	}
+	msg := prefix + e.Message
+
+	switch e.Type {
+	case gndb.EventInfo, gndb.EventStageStarted:
+		gn.Info(msg)
+	case gndb.EventMessage:
+		gn.Message(msg)
+	case gndb.EventWarning:
+		gn.Warn(msg)
+	case gndb.EventError:
+		if e.Err != nil {
+			gn.PrintErrorMessage(e.Err)
+			return
+		}
+		gn.Message(prefix + "<err>Error:</err> " + e.Message)
+	case gndb.EventSourceStarted:
+		if e.Parallel {
+			gn.Info(prefix + "Started " + e.Message)
+			return
+		}
+		fmt.Println() // Blank line between sources
+		fmt.Println(separator)
+		gn.Info(msg)
+		fmt.Println(separator)
+	case gndb.EventStageFinished:
+		if e.Message == "" {
+			return
+		}
+		if e.Status == gndb.StatusSkipped {
+			gn.Message(msg)
+			return
+		}
+		gn.Message("%s %s", msg, gnfmt.TimeString(e.DurationSec))
+	case gndb.EventSourceFinished:
+		// Failed and skipped sources are explained by other events.
+		if e.Message == "" {
+			return
+		}
+		gn.Info("%s %s", msg, gnfmt.TimeString(e.DurationSec))
+	case gndb.EventProgress:
+		t.progress(e)
+	case gndb.EventSummary:
+		fmt.Println(separator)
+		gn.Info(msg)
+	}
+}
+
+// progress updates the progress bar of the event. Bars with unknown
+// total show the number of processed records only.
+func (t *terminal) progress(e gndb.Event) {
+	if e.Parallel {
+		return
+	}
+
+	if e.Total == 0 {
+		fmt.Fprintf(os.Stderr, "\r%s", strings.Repeat(" ", 80))
+		if e.Done {
+			fmt.Fprint(os.Stderr, "\r")
+			return
+		}
+		fmt.Fprintf(os.Stderr, "\r%s%s",
+			e.Message, humanize.Comma(int64(e.Count)))
+		return
+	}
+
+	key := fmt.Sprintf("%d %s", e.SourceID, e.Message)
+	bar, ok := t.bars[key]
+	if !ok {
+		bar = pb.Full.New(e.Total)
+		bar.Set("prefix", e.Message)
+		bar.Set(pb.CleanOnFinish, true)
+		bar.Start()
+		t.bars[key] = bar
+	}
+	bar.SetCurrent(int64(e.Count))
+	if e.Done {
+		bar.Finish()
+		delete(t.bars, key)
+	}
+}
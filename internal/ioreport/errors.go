@@ -0,0 +1,21 @@
+package ioreport
+
+import (
+	"fmt"
+
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/errcode"
+)
+
+// WriteReportError creates an error for when a run report
+// cannot be saved.
+func WriteReportError(path string, err error) error {
+	msg := "Cannot write run report <em>%s</em>"
+	vars := []any{path}
+	return &gn.Error{
+		Code: errcode.WriteReportError,
+		Msg:  msg,
+		Vars: vars,
+		Err:  fmt.Errorf("cannot write run report: %w", err),
+	}
+}
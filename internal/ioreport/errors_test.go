@@ -0,0 +1,30 @@
+package ioreport
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWriteReportError verifies error structure.
+func TestWriteReportError(t *testing.T) {
+	path := "/reports/populate.json"
+	originalErr := errors.New("disk full")
+
+	err := WriteReportError(path, originalErr)
+
+	require.NotNil(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok, "Error should be of type *gn.Error")
+
+	assert.Equal(t, errcode.WriteReportError, gnErr.Code)
+	assert.NotEmpty(t, gnErr.Msg)
+	require.Len(t, gnErr.Vars, 1)
+	assert.Equal(t, path, gnErr.Vars[0])
+	assert.ErrorIs(t, gnErr.Err, originalErr)
+}
@@ -0,0 +1,70 @@
+// Package ioreport saves run reports of gndb commands to the file
+// system. This is an impure I/O package, reports themselves are built
+// by pkg/report.
+package ioreport
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/config"
+	"github.com/gnames/gndb/pkg/report"
+)
+
+// Save finishes the report with the result of the run and writes it to
+// the report directory as <run_id>.json, and as <run_id>.md if Markdown
+// is enabled. A report that cannot be written does not change the result
+// of the run, so the problem is only logged and shown to the user.
+func Save(r *report.Report, cfg *config.Config, runErr error) {
+	r.Finish(runErr)
+
+	paths, err := write(r, cfg)
+	if err != nil {
+		slog.Error("Cannot save run report", "error", err)
+		gn.PrintErrorMessage(err)
+		return
+	}
+
+	slog.Info("Run report saved", "run_id", r.RunID, "files", paths)
+	gn.Info("Run report is saved to <em>%s</em>", paths[0])
+}
+
+// Dir returns the directory for report files set in cfg, or the
+// default one.
+func Dir(cfg *config.Config) string {
+	if cfg.Report.Dir != "" {
+		return cfg.Report.Dir
+	}
+	return config.ReportDir(cfg.HomeDir)
+}
+
+// write saves the report files and returns their paths.
+func write(r *report.Report, cfg *config.Config) ([]string, error) {
+	dir := Dir(cfg)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, WriteReportError(dir, err)
+	}
+
+	data, err := r.JSON()
+	if err != nil {
+		return nil, WriteReportError(r.RunID, err)
+	}
+
+	path := filepath.Join(dir, r.RunID+".json")
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		return nil, WriteReportError(path, err)
+	}
+	paths := []string{path}
+
+	if cfg.Report.WithMarkdown {
+		path = filepath.Join(dir, r.RunID+".md")
+		if err = os.WriteFile(path, []byte(r.Markdown()), 0644); err != nil {
+			return nil, WriteReportError(path, err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
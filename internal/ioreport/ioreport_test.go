@@ -0,0 +1,66 @@
+package ioreport
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gnames/gndb/pkg/config"
+	"github.com/gnames/gndb/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses file system in short mode")
+	}
+
+	dir := t.TempDir()
+	cfg := config.New()
+	cfg.Update([]config.Option{
+		config.OptReportDir(dir),
+		config.OptReportWithMarkdown(true),
+	})
+
+	r := report.New("export", cfg)
+	r.AddSource(report.Source{ID: 1, Status: report.StatusFailed})
+	r.Finish(errors.New("export failed"))
+
+	paths, err := write(r, cfg)
+	require.NoError(t, err)
+	require.Len(t, paths, 2)
+	assert.Equal(t, filepath.Join(dir, r.RunID+".json"), paths[0])
+	assert.Equal(t, filepath.Join(dir, r.RunID+".md"), paths[1])
+
+	data, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	var res report.Report
+	require.NoError(t, json.Unmarshal(data, &res))
+	assert.Equal(t, report.StatusFailed, res.Status)
+	assert.Equal(t, "export failed", res.Error.Message)
+
+	md, err := os.ReadFile(paths[1])
+	require.NoError(t, err)
+	assert.Contains(t, string(md), "# gndb export report")
+}
+
+func TestWriteDefaultDir(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses file system in short mode")
+	}
+
+	home := t.TempDir()
+	cfg := config.New()
+	cfg.Update([]config.Option{config.OptHomeDir(home)})
+
+	r := report.New("optimize", cfg)
+	r.Finish(nil)
+
+	paths, err := write(r, cfg)
+	require.NoError(t, err)
+	require.Len(t, paths, 1)
+	assert.Equal(t, config.ReportDir(home), filepath.Dir(paths[0]))
+}
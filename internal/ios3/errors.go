@@ -0,0 +1,43 @@
+package ios3
+
+import (
+	"fmt"
+
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/errcode"
+)
+
+// ListError creates an error for when objects of an s3:// URL cannot
+// be listed.
+func ListError(url string, err error) error {
+	msg := `Cannot list objects at <em>%s</em>
+
+<em>How to fix:</em>
+  1. Check that the bucket exists and the endpoint is correct
+  2. Set credentials with GNDB_S3_ACCESS_KEY_ID and
+     GNDB_S3_SECRET_ACCESS_KEY, or AWS_ACCESS_KEY_ID and
+     AWS_SECRET_ACCESS_KEY`
+	vars := []any{url}
+	return &gn.Error{
+		Code: errcode.S3ListError,
+		Msg:  msg,
+		Vars: vars,
+		Err:  fmt.Errorf("cannot list %s: %w", url, err),
+	}
+}
+
+// UploadError creates an error for when a file cannot be uploaded to
+// an s3:// URL.
+func UploadError(path, url string, err error) error {
+	msg := `Cannot upload <em>%s</em> to <em>%s</em>
+
+<em>How to fix:</em>
+  Check that the bucket exists and the credentials allow writing to it`
+	vars := []any{path, url}
+	return &gn.Error{
+		Code: errcode.S3UploadError,
+		Msg:  msg,
+		Vars: vars,
+		Err:  fmt.Errorf("cannot upload %s to %s: %w", path, url, err),
+	}
+}
@@ -0,0 +1,51 @@
+package ios3
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListError verifies error structure.
+func TestListError(t *testing.T) {
+	url := "s3://sfga/archives/"
+	originalErr := errors.New("access denied")
+
+	err := ListError(url, originalErr)
+
+	require.NotNil(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok, "Error should be of type *gn.Error")
+
+	assert.Equal(t, errcode.S3ListError, gnErr.Code)
+	assert.NotEmpty(t, gnErr.Msg)
+	require.Len(t, gnErr.Vars, 1)
+	assert.Equal(t, url, gnErr.Vars[0])
+	assert.ErrorIs(t, gnErr.Err, originalErr)
+}
+
+// TestUploadError verifies error structure.
+func TestUploadError(t *testing.T) {
+	path := "/tmp/0001-col.sqlite"
+	url := "s3://sfga/0001-col.sqlite"
+	originalErr := errors.New("no such bucket")
+
+	err := UploadError(path, url, originalErr)
+
+	require.NotNil(t, err)
+
+	gnErr, ok := err.(*gn.Error)
+	require.True(t, ok, "Error should be of type *gn.Error")
+
+	assert.Equal(t, errcode.S3UploadError, gnErr.Code)
+	assert.NotEmpty(t, gnErr.Msg)
+	require.Len(t, gnErr.Vars, 2)
+	assert.Equal(t, path, gnErr.Vars[0])
+	assert.Equal(t, url, gnErr.Vars[1])
+	assert.ErrorIs(t, gnErr.Err, originalErr)
+}
@@ -0,0 +1,384 @@
+// Package ios3 reads and writes objects of S3-compatible storage (AWS S3,
+// MinIO and others). It lets s3://bucket/prefix/ URLs be used as parents
+// of data sources and as export targets. Requests are signed with AWS
+// Signature Version 4, credentials come from the gndb config or from the
+// standard AWS environment variables.
+// This is an impure I/O package.
+package ios3
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gnames/gndb/pkg/config"
+)
+
+const (
+	// defaultRegion is used when neither the config nor the environment
+	// sets a region.
+	defaultRegion = "us-east-1"
+
+	// defaultPartSize is the size of parts of multipart uploads. Files
+	// up to this size are uploaded with one request.
+	defaultPartSize = 64 << 20
+
+	// maxListPages limits the number of pages of a listing.
+	maxListPages = 1000
+)
+
+// Client accesses objects of S3-compatible storage.
+type Client struct {
+	// endpoint is the URL of a custom storage service, buckets are
+	// addressed by path. It is nil for AWS S3, where buckets are
+	// addressed by host name.
+	endpoint *url.URL
+
+	region    string
+	accessKey string
+	secretKey string
+	token     string
+	partSize  int64
+
+	// base sends the signed requests.
+	base http.RoundTripper
+}
+
+// New creates a Client. Empty fields of cfg are taken from the standard
+// AWS environment variables.
+func New(cfg config.S3Config) *Client {
+	res := &Client{
+		region: cmp.Or(
+			cfg.Region,
+			os.Getenv("AWS_REGION"),
+			os.Getenv("AWS_DEFAULT_REGION"),
+			defaultRegion,
+		),
+		accessKey: cfg.AccessKeyID,
+		secretKey: cfg.SecretAccessKey,
+		partSize:  defaultPartSize,
+		base:      http.DefaultTransport,
+	}
+	if res.accessKey == "" {
+		// A session token belongs to the environment credentials only.
+		res.accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		res.secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		res.token = os.Getenv("AWS_SESSION_TOKEN")
+	}
+
+	endpoint := cmp.Or(
+		cfg.Endpoint,
+		os.Getenv("AWS_ENDPOINT_URL_S3"),
+		os.Getenv("AWS_ENDPOINT_URL"),
+	)
+	if endpoint == "" {
+		return res
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		slog.Warn("Ignoring invalid S3 endpoint", "endpoint", endpoint)
+		return res
+	}
+	res.endpoint = u
+	return res
+}
+
+// HTTPClient returns an HTTP client that understands s3:// URLs. Other
+// URLs are requested as usual, so the client can replace
+// http.DefaultClient for downloads.
+func (c *Client) HTTPClient() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+// RoundTrip implements http.RoundTripper. Requests to s3://bucket/key
+// are sent to the object URL of the storage service and signed.
+func (c *Client) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "s3" {
+		return c.base.RoundTrip(req)
+	}
+
+	r := req.Clone(req.Context())
+	r.URL = c.objectURL(req.URL.Host, strings.TrimPrefix(req.URL.Path, "/"))
+	r.URL.RawQuery = req.URL.RawQuery
+	r.Host = r.URL.Host
+	c.sign(r, time.Now())
+	return c.base.RoundTrip(r)
+}
+
+// List returns names of objects directly under an s3://bucket/prefix/
+// URL. Objects in deeper "directories" of the prefix are not included.
+func (c *Client) List(dirURL string) ([]string, error) {
+	bucket, prefix, err := parseURL(dirURL)
+	if err != nil {
+		return nil, ListError(dirURL, err)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	var res []string
+	var token string
+	for range maxListPages {
+		q := url.Values{
+			"list-type": {"2"},
+			"prefix":    {prefix},
+			"delimiter": {"/"},
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		u := url.URL{Scheme: "s3", Host: bucket, Path: "/", RawQuery: q.Encode()}
+
+		var page listResult
+		if err = c.do(http.MethodGet, u.String(), nil, 0, &page); err != nil {
+			return nil, ListError(dirURL, err)
+		}
+		for _, o := range page.Contents {
+			name := strings.TrimPrefix(o.Key, prefix)
+			if name != "" && !strings.Contains(name, "/") {
+				res = append(res, name)
+			}
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return res, nil
+		}
+		token = page.NextContinuationToken
+	}
+	return nil, ListError(
+		dirURL, fmt.Errorf("more than %d pages of objects", maxListPages),
+	)
+}
+
+// Upload copies a local file to an s3://bucket/key URL. Files larger
+// than the part size are uploaded in parts, so they are not limited by
+// the 5 GB limit of a single request.
+func (c *Client) Upload(path, objURL string) error {
+	if _, _, err := parseURL(objURL); err != nil {
+		return UploadError(path, objURL, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return UploadError(path, objURL, err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return UploadError(path, objURL, err)
+	}
+
+	if fi.Size() <= c.partSize {
+		err = c.do(http.MethodPut, objURL, f, fi.Size(), nil)
+	} else {
+		err = c.uploadParts(objURL, f, fi.Size())
+	}
+	if err != nil {
+		return UploadError(path, objURL, err)
+	}
+
+	slog.Info("Uploaded file", "path", path, "url", objURL, "size", fi.Size())
+	return nil
+}
+
+// uploadParts uploads a file with the multipart upload API. An
+// unfinished upload is aborted, so its parts do not take space.
+func (c *Client) uploadParts(objURL string, f *os.File, size int64) error {
+	var created struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err := c.do(http.MethodPost, objURL+"?uploads=", nil, 0, &created)
+	if err != nil {
+		return err
+	}
+	uploadURL := objURL + "?uploadId=" + url.QueryEscape(created.UploadID)
+
+	var done completeUpload
+	for num, offset := 1, int64(0); offset < size; num++ {
+		n := min(c.partSize, size-offset)
+		partURL := fmt.Sprintf("%s&partNumber=%d", uploadURL, num)
+		etag, err := c.put(partURL, io.NewSectionReader(f, offset, n), n)
+		if err != nil {
+			c.abort(uploadURL)
+			return fmt.Errorf("part %d: %w", num, err)
+		}
+		done.Parts = append(done.Parts, completedPart{Number: num, ETag: etag})
+		offset += n
+	}
+
+	body, err := xml.Marshal(done)
+	if err != nil {
+		c.abort(uploadURL)
+		return err
+	}
+	err = c.do(
+		http.MethodPost, uploadURL, bytes.NewReader(body), int64(len(body)), nil,
+	)
+	if err != nil {
+		c.abort(uploadURL)
+		return err
+	}
+	return nil
+}
+
+// put sends a body with PUT and returns the ETag of the stored object.
+func (c *Client) put(objURL string, body io.Reader, size int64) (string, error) {
+	resp, err := c.send(http.MethodPut, objURL, body, size)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Header.Get("ETag"), nil
+}
+
+// abort cancels a multipart upload. Failures are only logged, the
+// storage removes abandoned uploads by its own lifecycle rules.
+func (c *Client) abort(uploadURL string) {
+	if err := c.do(http.MethodDelete, uploadURL, nil, 0, nil); err != nil {
+		slog.Warn("Cannot abort multipart upload", "url", uploadURL, "error", err)
+	}
+}
+
+// do sends a request and decodes the XML response into res, if res is
+// not nil.
+func (c *Client) do(
+	method, objURL string,
+	body io.Reader,
+	size int64,
+	res any,
+) error {
+	resp, err := c.send(method, objURL, body, size)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	// Some requests report errors in the body of a successful response.
+	if err = errorResult(data); err != nil {
+		return err
+	}
+	if res == nil || len(data) == 0 {
+		return nil
+	}
+	return xml.Unmarshal(data, res)
+}
+
+// send sends a request to an s3:// URL and returns the response if its
+// status is successful.
+func (c *Client) send(
+	method, objURL string,
+	body io.Reader,
+	size int64,
+) (*http.Response, error) {
+	if body == nil || size == 0 {
+		// Go sends bodies of unknown size in chunks, S3 does not accept
+		// them.
+		body = http.NoBody
+	}
+	req, err := http.NewRequest(method, objURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+
+	resp, err := c.HTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err = errorResult(data); err != nil {
+		return nil, fmt.Errorf("status %d: %w", resp.StatusCode, err)
+	}
+	return nil, fmt.Errorf("status %d", resp.StatusCode)
+}
+
+// objectURL returns the HTTP URL of an object.
+func (c *Client) objectURL(bucket, key string) *url.URL {
+	if c.endpoint != nil {
+		p := "/" + bucket
+		if key != "" {
+			p += "/" + key
+		}
+		return &url.URL{
+			Scheme: c.endpoint.Scheme,
+			Host:   c.endpoint.Host,
+			Path:   p,
+		}
+	}
+	return &url.URL{
+		Scheme: "https",
+		Host:   bucket + ".s3." + c.region + ".amazonaws.com",
+		Path:   "/" + key,
+	}
+}
+
+// listResult is a page of the ListObjectsV2 response.
+type listResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+}
+
+type completeUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type completedPart struct {
+	Number int    `xml:"PartNumber"`
+	ETag   string `xml:"ETag"`
+}
+
+// errorResult returns the error of an S3 error document, or nil if data
+// is not one.
+func errorResult(data []byte) error {
+	if !bytes.Contains(data, []byte("<Error>")) {
+		return nil
+	}
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.Unmarshal(data, &e); err != nil {
+		return errors.New(strconv.Quote(string(data)))
+	}
+	return fmt.Errorf("%s: %s", e.Code, e.Message)
+}
+
+// parseURL splits an s3://bucket/key URL into the bucket and the key.
+func parseURL(s string) (string, string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Scheme != "s3" || u.Host == "" {
+		return "", "", fmt.Errorf("%s is not an s3://bucket/key URL", s)
+	}
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
@@ -0,0 +1,146 @@
+package ios3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// unsignedPayload tells the server that the body is not part of the
+	// signature, so large files do not have to be read twice.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+
+	amzDateFormat = "20060102T150405Z"
+)
+
+// sign adds an AWS Signature Version 4 Authorization header to the
+// request. The path and the query of the request URL are replaced with
+// their canonical form, so the server sees exactly what was signed.
+// Requests without credentials stay anonymous, which is enough for
+// public buckets.
+func (c *Client) sign(r *http.Request, now time.Time) {
+	if c.accessKey == "" || c.secretKey == "" {
+		return
+	}
+
+	payload := r.Header.Get("X-Amz-Content-Sha256")
+	if payload == "" {
+		payload = unsignedPayload
+		r.Header.Set("X-Amz-Content-Sha256", payload)
+	}
+	date := now.UTC().Format(amzDateFormat)
+	r.Header.Set("X-Amz-Date", date)
+	if c.token != "" {
+		r.Header.Set("X-Amz-Security-Token", c.token)
+	}
+
+	r.URL.RawPath = uriEncode(r.URL.Path, false)
+	r.URL.RawQuery = canonicalQuery(r.URL.Query())
+
+	headers, signed := canonicalHeaders(r)
+	canonical := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		headers,
+		signed,
+		payload,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date[:8], c.region)
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		date,
+		scope,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date[:8])
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	r.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signed, signature,
+	))
+}
+
+// canonicalHeaders returns the signed headers of a request in canonical
+// form, and the list of their names.
+func canonicalHeaders(r *http.Request) (string, string) {
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for k, vv := range r.Header {
+		k = strings.ToLower(k)
+		if !strings.HasPrefix(k, "x-amz-") &&
+			k != "range" && k != "content-type" && k != "content-md5" {
+			continue
+		}
+		trimmed := make([]string, len(vv))
+		for i, v := range vv {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		values[k] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for k := range values {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+
+	var sb strings.Builder
+	for _, k := range names {
+		sb.WriteString(k + ":" + values[k] + "\n")
+	}
+	return sb.String(), strings.Join(names, ";")
+}
+
+// canonicalQuery encodes query parameters sorted by name.
+func canonicalQuery(q url.Values) string {
+	params := make([]string, 0, len(q))
+	for k, vv := range q {
+		for _, v := range vv {
+			params = append(params, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	slices.Sort(params)
+	return strings.Join(params, "&")
+}
+
+// uriEncode percent-encodes everything except unreserved characters of
+// RFC 3986, and slashes if encodeSlash is false.
+func uriEncode(s string, encodeSlash bool) string {
+	var sb strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~':
+			sb.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			sb.WriteByte(b)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+	return sb.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
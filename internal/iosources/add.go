@@ -0,0 +1,98 @@
+package iosources
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/gnames/gndb/pkg/config"
+	"github.com/gnames/gndb/pkg/sources"
+	"gopkg.in/yaml.v3"
+)
+
+// AddCustom appends a data source to custom_sources.yaml. The entry is
+// added as text to the end of the file, so comments of the file are
+// kept. The file is created if it does not exist.
+func (s *iosources) AddCustom(ds sources.DataSourceConfig) error {
+	path := config.CustomSourcesFilePath(s.cfg.HomeDir)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return SourcesConfigError(path, err)
+	}
+
+	data, err = appendSource(data, ds)
+	if err != nil {
+		return SourcesConfigError(path, err)
+	}
+
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		return SourcesConfigError(path, err)
+	}
+	return nil
+}
+
+var (
+	// dataSourcesKey matches the data_sources key of a sources file.
+	dataSourcesKey = regexp.MustCompile(`(?m)^data_sources:[ \t]*(\[\])?[ \t]*$`)
+
+	// entryIndent matches the indentation of data source entries.
+	entryIndent = regexp.MustCompile(`(?m)^([ \t]*)- id:`)
+)
+
+// appendSource adds a data source entry to the content of a sources
+// file. A data source with the same ID cannot be added twice. The
+// result is parsed again to make sure the file stays valid.
+func appendSource(data []byte, ds sources.DataSourceConfig) ([]byte, error) {
+	var current sources.SourcesConfig
+	if err := yaml.Unmarshal(data, &current); err != nil {
+		return nil, fmt.Errorf("failed to parse sources config: %w", err)
+	}
+	hasID := func(d sources.DataSourceConfig) bool { return d.ID == ds.ID }
+	if slices.ContainsFunc(current.DataSources, hasID) {
+		return nil, fmt.Errorf("data source %d exists already", ds.ID)
+	}
+
+	var entry bytes.Buffer
+	enc := yaml.NewEncoder(&entry)
+	enc.SetIndent(2)
+	if err := enc.Encode([]sources.DataSourceConfig{ds}); err != nil {
+		return nil, fmt.Errorf("failed to encode data source %d: %w", ds.ID, err)
+	}
+
+	res := bytes.Clone(data)
+	indent := []byte("  ")
+	if dataSourcesKey.Match(res) {
+		// An empty flow list cannot be continued by a block entry.
+		res = dataSourcesKey.ReplaceAll(res, []byte("data_sources:"))
+		if m := entryIndent.FindSubmatch(res); m != nil {
+			indent = m[1]
+		}
+	}
+	if len(res) > 0 && !bytes.HasSuffix(res, []byte("\n")) {
+		res = append(res, '\n')
+	}
+	if !dataSourcesKey.Match(res) {
+		if len(res) > 0 {
+			res = append(res, '\n')
+		}
+		res = append(res, []byte("data_sources:\n")...)
+	}
+
+	for line := range bytes.Lines(entry.Bytes()) {
+		res = append(res, indent...)
+		res = append(res, line...)
+	}
+
+	var check sources.SourcesConfig
+	if err := yaml.Unmarshal(res, &check); err != nil ||
+		!slices.ContainsFunc(check.DataSources, hasID) {
+		return nil, fmt.Errorf(
+			"cannot add data source %d, data_sources is not the last "+
+				"block list of the file", ds.ID,
+		)
+	}
+	return res, nil
+}
@@ -0,0 +1,111 @@
+package iosources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gnames/gndb/internal/iofs"
+	"github.com/gnames/gndb/pkg/config"
+	"github.com/gnames/gndb/pkg/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAppendSource(t *testing.T) {
+	ds := sources.DataSourceConfig{
+		ID:     1500,
+		Parent: "/data/sfga",
+		Title:  "Trial",
+	}
+
+	tests := []struct {
+		name      string
+		data      string
+		rewritten bool
+		wantIDs   []int
+		wantErr   bool
+	}{
+		{"empty file", "", false, []int{1500}, false},
+		{
+			"comments only", "# Custom sources\n# data_sources:",
+			false, []int{1500}, false,
+		},
+		{"empty list", "data_sources: []\n", true, []int{1500}, false},
+		{
+			"indented entries",
+			"data_sources:\n  - id: 1001\n    parent: /data\n" +
+				"    filters:\n      ranks: [species]\n",
+			false, []int{1001, 1500}, false,
+		},
+		{
+			"entries without indent",
+			"data_sources:\n- id: 1001\n  parent: /data\n",
+			false, []int{1001, 1500}, false,
+		},
+		{
+			"same ID",
+			"data_sources:\n  - id: 1500\n    parent: /data\n",
+			false, nil, true,
+		},
+		{
+			"flow list", "data_sources: [{id: 1001, parent: /data}]\n",
+			false, nil, true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := appendSource([]byte(tt.data), ds)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if !tt.rewritten {
+				assert.Contains(t, string(res), tt.data,
+					"Content of the file should be kept")
+			}
+
+			var cfg sources.SourcesConfig
+			require.NoError(t, yaml.Unmarshal(res, &cfg))
+			var ids []int
+			for _, d := range cfg.DataSources {
+				ids = append(ids, d.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			last := cfg.DataSources[len(cfg.DataSources)-1]
+			assert.Equal(t, "Trial", last.Title)
+		})
+	}
+}
+
+func TestAddCustom(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that uses file system in short mode")
+	}
+
+	homeDir := t.TempDir()
+	cfg := config.New()
+	cfg.Update([]config.Option{config.OptHomeDir(homeDir)})
+	path := config.CustomSourcesFilePath(homeDir)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, iofs.EnsureCustomSourcesFile(homeDir))
+
+	parent := t.TempDir()
+	src := New(cfg)
+	err := src.AddCustom(sources.DataSourceConfig{ID: 1500, Parent: parent})
+	require.NoError(t, err)
+
+	custom, err := loadCustomSourcesConfig(path)
+	require.NoError(t, err)
+	require.Len(t, custom.DataSources, 1)
+	assert.Equal(t, 1500, custom.DataSources[0].ID)
+	assert.Equal(t, parent, custom.DataSources[0].Parent)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# custom_sources.yaml",
+		"Comments of the file should be kept")
+}
@@ -86,7 +86,7 @@ func loadCustomSourcesConfig(path string) (*sources.SourcesConfig, error) {
 func validateSourcesFileSystem(config *sources.SourcesConfig) error {
 	for i, ds := range config.DataSources {
 		// Skip URLs - they'll be validated at fetch time
-		if sources.IsValidURL(ds.Parent) {
+		if sources.IsValidURL(ds.Parent) || sources.IsS3URL(ds.Parent) {
 			continue
 		}
 
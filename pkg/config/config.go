@@ -20,11 +20,16 @@
 // Persistent fields (in ToOptions, config.yaml, and env vars):
 //   - Database: host, port, user, password, database, ssl_mode, batch_size
 //   - Log: level, format, destination
+//   - Cache: max_size_gb
+//   - S3: endpoint, region, access_key_id, secret_access_key
 //   - General: jobs_number
 //
 // Runtime-only fields (CLI flags only):
-//   - Populate.SourceIDs, ReleaseVersion, ReleaseDate, WithFlatClassification
-//     (per-command)
+//   - Populate.SourceIDs, ReleaseVersion, ReleaseDate, WithFlatClassification,
+//     Resume, ParallelSources, Delta, NonInteractive, OnEmptyNameString,
+//     OnDuplicateRecordID, OnDanglingSynonym, DryRun, StrictHierarchy,
+//     MaxHierarchyDefectRatio, Force, File (per-command)
+//   - Report.Dir, WithMarkdown
 //   - HomeDir (set once at startup)
 //
 // # Environment Variables
@@ -34,6 +39,8 @@
 //	GNDB_DATABASE_HOST=localhost
 //	GNDB_DATABASE_PORT=5432
 //	GNDB_LOG_LEVEL=info
+//	GNDB_CACHE_MAX_SIZE_GB=20
+//	GNDB_S3_ENDPOINT=http://localhost:9000
 //	GNDB_JOBS_NUMBER=8
 //
 // See .envrc.example for complete list with defaults.
@@ -56,6 +63,16 @@ type Config struct {
 
 	Log LogConfig `mapstructure:"log" yaml:"log"`
 
+	// Cache contains settings of the downloaded SFGA archives cache.
+	Cache CacheConfig `mapstructure:"cache" yaml:"cache"`
+
+	// S3 contains settings of S3-compatible object storage used for
+	// s3:// source parents and export targets.
+	S3 S3Config `mapstructure:"s3" yaml:"s3"`
+
+	// Report contains settings of run reports.
+	Report ReportConfig `mapstructure:"report" yaml:"report"`
+
 	// JobsNumber is the number of concurrent workers for parallel operations.
 	// Default value is set accoring to the number of available threads.
 	JobsNumber int `mapstructure:"jobs_number" yaml:"jobs_number"`
@@ -101,12 +118,14 @@ type PopulateConfig struct {
 	// Populate() will load sources.yaml and look up each source by ID.
 	SourceIDs []int `mapstructure:"source_ids" yaml:"source_ids"`
 
-	// ReleaseVersion overrides the version for the data source being imported.
+	// ReleaseVersion pins the version of the data source being imported,
+	// the file of this release is imported instead of the latest one.
 	// Only valid when importing a single source (len(SourceIDs) == 1).
 	// The CLI validates this constraint before calling Populate().
 	ReleaseVersion string `mapstructure:"release_version" yaml:"release_version"`
 
-	// ReleaseDate overrides the release date for the data source being imported.
+	// ReleaseDate pins the release date of the data source being imported,
+	// the file of this release is imported instead of the latest one.
 	// Format: YYYY-MM-DD. Only valid when importing a single source (len(SourceIDs) == 1).
 	// The CLI validates this constraint before calling Populate().
 	ReleaseDate string `mapstructure:"release_date" yaml:"release_date"`
@@ -117,6 +136,154 @@ type PopulateConfig struct {
 	// will stay empty even if parent/child hierarchy exists.
 	// Default: false (hierarchical parent/child classification is preferred)
 	WithFlatClassification *bool `mapstructure:"with_flat_classification" yaml:"with_flat_classification"`
+
+	// Resume continues an interrupted populate run. Sources that were
+	// imported completely are skipped, other sources restart at the
+	// stage where the previous run stopped. Progress is kept in a state
+	// file in the cache directory.
+	// Default: false (every selected source is imported from stage 1)
+	Resume bool `mapstructure:"resume" yaml:"resume"`
+
+	// ParallelSources is the number of data sources imported at the same
+	// time. Each source gets its own SFGA cache subdirectory and SQLite
+	// handle, console output is prefixed with the data source ID.
+	// Default: 1 (sources are imported one after another)
+	ParallelSources int `mapstructure:"parallel_sources" yaml:"parallel_sources"`
+
+	// Delta imports only the differences between a new release of a
+	// source and the records already stored for it. Records are compared
+	// by record_id, only added, changed and removed records are written.
+	// Default: false (all records of a source are replaced)
+	Delta bool `mapstructure:"delta" yaml:"delta"`
+
+	// NonInteractive makes populate never wait for user input. Questions
+	// that have no answer from CLI flags or sources.yaml get their
+	// default answer. Use it for cron jobs and containers.
+	// Default: false (populate asks the user)
+	NonInteractive bool `mapstructure:"non_interactive" yaml:"non_interactive"`
+
+	// OnEmptyNameString decides what to do with a source that has empty
+	// gn__scientific_name_string values: "fallback" to
+	// col__scientific_name, "skip" the source, or "abort" the run.
+	// It overrides on_empty_name_string settings of sources.yaml.
+	// Default: "" (use sources.yaml, otherwise ask the user)
+	OnEmptyNameString string `mapstructure:"on_empty_name_string" yaml:"on_empty_name_string"`
+
+	// OnDuplicateRecordID decides what to do with a source that has
+	// several name indices with the same record_id: "fail" the source,
+	// "keep-first" record, or "suffix" repeated IDs.
+	// It overrides on_duplicate_record_id settings of sources.yaml.
+	// Default: "" (use sources.yaml, otherwise ask the user)
+	OnDuplicateRecordID string `mapstructure:"on_duplicate_record_id" yaml:"on_duplicate_record_id"`
+
+	// OnDanglingSynonym decides what to do with synonyms whose accepted
+	// taxon is missing from the source: "drop" them, import them as
+	// "bare-name" records, or "keep" them as they are.
+	// It overrides on_dangling_synonym settings of sources.yaml.
+	// Default: "" (use sources.yaml, otherwise ask the user)
+	OnDanglingSynonym string `mapstructure:"on_dangling_synonym" yaml:"on_dangling_synonym"`
+
+	// DryRun checks selected sources without writing to PostgreSQL.
+	// SFGA files are fetched and validated, expected record counts and
+	// problems of every source are reported.
+	// Default: false (sources are imported)
+	DryRun bool `mapstructure:"dry_run" yaml:"dry_run"`
+
+	// StrictHierarchy fails the import of a source if the share of
+	// hierarchy nodes with missing or circular parents is greater than
+	// MaxHierarchyDefectRatio. Sources that use flat classification are
+	// not checked. Defects are always saved to a report.
+	// Default: false (defects are only reported)
+	StrictHierarchy bool `mapstructure:"strict_hierarchy" yaml:"strict_hierarchy"`
+
+	// MaxHierarchyDefectRatio is the largest allowed share (0-1) of
+	// hierarchy nodes with a broken parent chain in strict mode.
+	// Default: 0 (any defect fails the import)
+	MaxHierarchyDefectRatio float64 `mapstructure:"max_hierarchy_defect_ratio" yaml:"max_hierarchy_defect_ratio"`
+
+	// Force imports sources even if the database has their release
+	// already. Without it a source is skipped when the version and the
+	// release date of its SFGA file (and the checksum, if known) match
+	// the stored ones.
+	// Default: false (unchanged sources are skipped)
+	Force bool `mapstructure:"force" yaml:"force"`
+
+	// File is a data source imported from an explicit SFGA file, without
+	// an entry in sources.yaml. SourceIDs are ignored when it is set.
+	// Default: empty (sources come from sources.yaml)
+	File FileSourceConfig `mapstructure:"file" yaml:"file"`
+}
+
+// FileSourceConfig describes a data source imported with
+// `populate --file`. Populate builds a transient source configuration
+// from it, validated the same way as entries of sources.yaml.
+// All fields are runtime-only (CLI flags only, not persisted in config.yaml).
+type FileSourceConfig struct {
+	// Path is a local path or a URL of the SFGA file.
+	Path string
+
+	// ID is the data source ID of the file.
+	ID int
+
+	// Title is the title of the data source. If empty, the title from
+	// SFGA metadata is used.
+	Title string
+
+	// OutlinkURL and OutlinkIDColumn set links to original records,
+	// as outlink_url and outlink_id_column of sources.yaml.
+	OutlinkURL      string
+	OutlinkIDColumn string
+}
+
+// IsEmpty returns true if no file is given.
+func (f FileSourceConfig) IsEmpty() bool {
+	return f.Path == ""
+}
+
+// CacheConfig contains settings of the cache of downloaded SFGA archives.
+// Archives are kept between runs and reused when a remote file did not
+// change. Least recently used archives are removed when the cache grows
+// over the size limit.
+type CacheConfig struct {
+	// MaxSizeGB is the size limit of the cache in gigabytes.
+	// Default: 20
+	MaxSizeGB int `mapstructure:"max_size_gb" yaml:"max_size_gb"`
+}
+
+// S3Config contains settings of S3-compatible object storage (AWS S3,
+// MinIO and others). Empty fields fall back to the standard AWS
+// environment variables: AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL),
+// AWS_REGION (or AWS_DEFAULT_REGION), AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+type S3Config struct {
+	// Endpoint is the URL of the storage service, for example
+	// "http://localhost:9000" for a local MinIO server. Buckets of custom
+	// endpoints are addressed by path.
+	// Default: "" (AWS S3 of the region)
+	Endpoint string `mapstructure:"endpoint" yaml:"endpoint"`
+
+	// Region is the region of the buckets.
+	// Default: "" (us-east-1)
+	Region string `mapstructure:"region" yaml:"region"`
+
+	// AccessKeyID is the access key of the storage account.
+	AccessKeyID string `mapstructure:"access_key_id" yaml:"access_key_id"`
+
+	// SecretAccessKey is the secret key of the storage account.
+	SecretAccessKey string `mapstructure:"secret_access_key" yaml:"secret_access_key"`
+}
+
+// ReportConfig contains settings of machine-readable run reports.
+// Populate, optimize and export write a JSON report after every run.
+// All fields are runtime-only (CLI flags only, not persisted in config.yaml).
+type ReportConfig struct {
+	// Dir is the directory for report files.
+	// Default: "" (~/.local/share/gndb/reports)
+	Dir string
+
+	// WithMarkdown adds a Markdown copy of every JSON report.
+	// Default: false
+	WithMarkdown bool
 }
 
 // ExportConfig contains settings specific to the export command.
@@ -169,8 +336,25 @@ func New() *Config {
 			// for now file is rewritten every time the log starts
 			Destination: "file",
 		},
+		Cache: CacheConfig{
+			MaxSizeGB: 20, // Enough for a few of the largest sources
+		},
+		Populate: PopulateConfig{
+			ParallelSources: 1, // Import sources one after another
+		},
 		JobsNumber: runtime.NumCPU(), // Default to number of CPU threads
 	}
 
 	return res
 }
+
+// Redacted returns a copy of the config without credentials: the
+// database password and the S3 access keys. Use it when the config is
+// written to reports or logs.
+func (c *Config) Redacted() *Config {
+	res := *c
+	res.Database.Password = ""
+	res.S3.AccessKeyID = ""
+	res.S3.SecretAccessKey = ""
+	return &res
+}
@@ -37,6 +37,11 @@ func TestDirs(t *testing.T) {
 			fn:  config.LogDir,
 			res: filepath.Join(tempHome, ".local", "share", "gndb", "logs"),
 		},
+		{
+			msg: "report dir",
+			fn:  config.ReportDir,
+			res: filepath.Join(tempHome, ".local", "share", "gndb", "reports"),
+		},
 	}
 
 	for _, v := range tests {
@@ -65,6 +70,9 @@ func TestNew(t *testing.T) {
 		assert.Equal(t, "info", cfg.Log.Level)
 		assert.Equal(t, "file", cfg.Log.Destination)
 
+		// Cache defaults
+		assert.Equal(t, 20, cfg.Cache.MaxSizeGB)
+
 		// JobsNumber defaults to CPU count
 		assert.Equal(t, runtime.NumCPU(), cfg.JobsNumber)
 	})
@@ -341,6 +349,36 @@ func TestOptionJobsNumber(t *testing.T) {
 	}
 }
 
+func TestOptionCacheMaxSizeGB(t *testing.T) {
+	cfg := config.New()
+	cfg.Update([]config.Option{config.OptCacheMaxSizeGB(5)})
+	assert.Equal(t, 5, cfg.Cache.MaxSizeGB)
+
+	// Invalid values keep the previous limit
+	cfg.Update([]config.Option{config.OptCacheMaxSizeGB(0)})
+	assert.Equal(t, 5, cfg.Cache.MaxSizeGB)
+}
+
+func TestOptionS3(t *testing.T) {
+	cfg := config.New()
+	assert.Empty(t, cfg.S3.Endpoint)
+
+	cfg.Update([]config.Option{
+		config.OptS3Endpoint(" http://localhost:9000/ "),
+		config.OptS3Region("eu-central-1"),
+		config.OptS3AccessKeyID("minio"),
+		config.OptS3SecretAccessKey("secret"),
+	})
+	assert.Equal(t, "http://localhost:9000", cfg.S3.Endpoint)
+	assert.Equal(t, "eu-central-1", cfg.S3.Region)
+	assert.Equal(t, "minio", cfg.S3.AccessKeyID)
+	assert.Equal(t, "secret", cfg.S3.SecretAccessKey)
+
+	// Empty values keep previous settings
+	cfg.Update([]config.Option{config.OptS3Region(" ")})
+	assert.Equal(t, "eu-central-1", cfg.S3.Region)
+}
+
 func TestOptionPopulateSourceIDs(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -415,6 +453,200 @@ func TestOptionPopulateWithFlatClassification(t *testing.T) {
 	}
 }
 
+func TestOptionPopulateResume(t *testing.T) {
+	cfg := config.New()
+	assert.False(t, cfg.Populate.Resume, "Resume should be off by default")
+
+	cfg.Update([]config.Option{config.OptPopulateResume(true)})
+	assert.True(t, cfg.Populate.Resume)
+
+	cfg.Update([]config.Option{config.OptPopulateResume(false)})
+	assert.False(t, cfg.Populate.Resume)
+}
+
+func TestOptionPopulateDelta(t *testing.T) {
+	cfg := config.New()
+	assert.False(t, cfg.Populate.Delta, "Delta should be off by default")
+
+	cfg.Update([]config.Option{config.OptPopulateDelta(true)})
+	assert.True(t, cfg.Populate.Delta)
+}
+
+func TestOptionPopulateForce(t *testing.T) {
+	cfg := config.New()
+	assert.False(t, cfg.Populate.Force, "Force should be off by default")
+
+	cfg.Update([]config.Option{config.OptPopulateForce(true)})
+	assert.True(t, cfg.Populate.Force)
+}
+
+func TestOptionPopulateFile(t *testing.T) {
+	cfg := config.New()
+	assert.True(t, cfg.Populate.File.IsEmpty(), "File should be empty by default")
+
+	cfg.Update([]config.Option{config.OptPopulateFile(config.FileSourceConfig{
+		Path:  " ~/data/1500-test-2025-10-03.sqlite.zip ",
+		ID:    1500,
+		Title: " Test ",
+	})})
+	assert.False(t, cfg.Populate.File.IsEmpty())
+	assert.Equal(t, "~/data/1500-test-2025-10-03.sqlite.zip", cfg.Populate.File.Path)
+	assert.Equal(t, 1500, cfg.Populate.File.ID)
+	assert.Equal(t, "Test", cfg.Populate.File.Title)
+
+	// A file without ID is ignored
+	cfg = config.New()
+	cfg.Update([]config.Option{config.OptPopulateFile(config.FileSourceConfig{
+		Path: "1500.sqlite",
+	})})
+	assert.True(t, cfg.Populate.File.IsEmpty())
+}
+
+func TestOptionPopulateNonInteractive(t *testing.T) {
+	cfg := config.New()
+	assert.False(t, cfg.Populate.NonInteractive,
+		"Populate should be interactive by default")
+
+	cfg.Update([]config.Option{config.OptPopulateNonInteractive(true)})
+	assert.True(t, cfg.Populate.NonInteractive)
+}
+
+func TestOptionPopulateOnEmptyNameString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"fallback", "fallback", "fallback"},
+		{"skip", "skip", "skip"},
+		{"abort uppercase", " ABORT ", "abort"},
+		{"invalid is ignored", "ignore", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Update([]config.Option{
+				config.OptPopulateOnEmptyNameString(tt.input),
+			})
+			assert.Equal(t, tt.expected, cfg.Populate.OnEmptyNameString)
+		})
+	}
+}
+
+func TestOptionPopulateOnDuplicateRecordID(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"fail", "fail", "fail"},
+		{"keep-first", "keep-first", "keep-first"},
+		{"suffix uppercase", " SUFFIX ", "suffix"},
+		{"invalid is ignored", "ignore", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Update([]config.Option{
+				config.OptPopulateOnDuplicateRecordID(tt.input),
+			})
+			assert.Equal(t, tt.expected, cfg.Populate.OnDuplicateRecordID)
+		})
+	}
+}
+
+func TestOptionPopulateOnDanglingSynonym(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"drop", "drop", "drop"},
+		{"bare-name", "bare-name", "bare-name"},
+		{"keep uppercase", " KEEP ", "keep"},
+		{"invalid is ignored", "fix", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Update([]config.Option{
+				config.OptPopulateOnDanglingSynonym(tt.input),
+			})
+			assert.Equal(t, tt.expected, cfg.Populate.OnDanglingSynonym)
+		})
+	}
+}
+
+func TestOptionPopulateDryRun(t *testing.T) {
+	cfg := config.New()
+	assert.False(t, cfg.Populate.DryRun, "Dry run should be off by default")
+
+	cfg.Update([]config.Option{config.OptPopulateDryRun(true)})
+	assert.True(t, cfg.Populate.DryRun)
+}
+
+func TestOptionPopulateStrictHierarchy(t *testing.T) {
+	cfg := config.New()
+	assert.False(t, cfg.Populate.StrictHierarchy)
+	assert.Zero(t, cfg.Populate.MaxHierarchyDefectRatio)
+
+	cfg.Update([]config.Option{
+		config.OptPopulateStrictHierarchy(true),
+		config.OptPopulateMaxHierarchyDefectRatio(0.05),
+	})
+	assert.True(t, cfg.Populate.StrictHierarchy)
+	assert.Equal(t, 0.05, cfg.Populate.MaxHierarchyDefectRatio)
+
+	// Ratios out of range are ignored
+	cfg.Update([]config.Option{
+		config.OptPopulateMaxHierarchyDefectRatio(1.5),
+	})
+	assert.Equal(t, 0.05, cfg.Populate.MaxHierarchyDefectRatio)
+	cfg.Update([]config.Option{
+		config.OptPopulateMaxHierarchyDefectRatio(-0.1),
+	})
+	assert.Equal(t, 0.05, cfg.Populate.MaxHierarchyDefectRatio)
+}
+
+func TestOptionReport(t *testing.T) {
+	cfg := config.New()
+	assert.Empty(t, cfg.Report.Dir)
+	assert.False(t, cfg.Report.WithMarkdown)
+
+	cfg.Update([]config.Option{
+		config.OptReportDir(" /tmp/reports "),
+		config.OptReportWithMarkdown(true),
+	})
+	assert.Equal(t, "/tmp/reports", cfg.Report.Dir)
+	assert.True(t, cfg.Report.WithMarkdown)
+}
+
+func TestOptionPopulateParallelSources(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    int
+		expected int
+	}{
+		{"several sources", 4, 4},
+		{"one source", 1, 1},
+		{"zero is ignored", 0, 1},
+		{"negative is ignored", -2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Update([]config.Option{
+				config.OptPopulateParallelSources(tt.input),
+			})
+			assert.Equal(t, tt.expected, cfg.Populate.ParallelSources)
+		})
+	}
+}
+
 func TestMultipleOptions(t *testing.T) {
 	t.Run("applies multiple options in order", func(t *testing.T) {
 		cfg := config.New()
@@ -469,6 +701,11 @@ func TestToOptions(t *testing.T) {
 			config.OptLogLevel("debug"),
 			config.OptLogFormat("text"),
 			config.OptLogDestination("stdout"),
+			config.OptCacheMaxSizeGB(50),
+			config.OptS3Endpoint("http://localhost:9000"),
+			config.OptS3Region("eu-central-1"),
+			config.OptS3AccessKeyID("minio"),
+			config.OptS3SecretAccessKey("secret"),
 			config.OptJobsNumber(8),
 		}
 		original.Update(opts)
@@ -489,6 +726,8 @@ func TestToOptions(t *testing.T) {
 		assert.Equal(t, original.Log.Level, newCfg.Log.Level)
 		assert.Equal(t, original.Log.Format, newCfg.Log.Format)
 		assert.Equal(t, original.Log.Destination, newCfg.Log.Destination)
+		assert.Equal(t, original.Cache.MaxSizeGB, newCfg.Cache.MaxSizeGB)
+		assert.Equal(t, original.S3, newCfg.S3)
 		assert.Equal(t, original.JobsNumber, newCfg.JobsNumber)
 	})
 
@@ -529,3 +768,22 @@ func TestToOptions(t *testing.T) {
 		assert.Nil(t, newCfg.Populate.WithFlatClassification)
 	})
 }
+
+func TestRedacted(t *testing.T) {
+	cfg := config.New()
+	cfg.Update([]config.Option{
+		config.OptS3AccessKeyID("minio"),
+		config.OptS3SecretAccessKey("secret"),
+	})
+
+	res := cfg.Redacted()
+	assert.Empty(t, res.Database.Password)
+	assert.Empty(t, res.S3.AccessKeyID)
+	assert.Empty(t, res.S3.SecretAccessKey)
+	assert.Equal(t, cfg.Database.Host, res.Database.Host)
+
+	// The original config keeps its credentials.
+	assert.Equal(t, "postgres", cfg.Database.Password)
+	assert.Equal(t, "minio", cfg.S3.AccessKeyID)
+	assert.Equal(t, "secret", cfg.S3.SecretAccessKey)
+}
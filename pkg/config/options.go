@@ -90,7 +90,7 @@ func OptPopulateSourceIDs(ii []int) Option {
 	}
 }
 
-// OptPopulateReleaseVersion overrides the version for a single-source import.
+// OptPopulateReleaseVersion pins the release version for a single-source import.
 // Only valid when importing one source. CLI validates this constraint.
 // Runtime-only field - not in ToOptions().
 func OptPopulateReleaseVersion(s string) Option {
@@ -102,7 +102,7 @@ func OptPopulateReleaseVersion(s string) Option {
 	}
 }
 
-// OptPopulateReleaseDate overrides the release date for a single-source import.
+// OptPopulateReleaseDate pins the release date for a single-source import.
 // Format: YYYY-MM-DD. Only valid when importing one source.
 // Runtime-only field - not in ToOptions().
 func OptPopulateReleaseDate(s string) Option {
@@ -126,6 +126,207 @@ func OptPopulateWithFlatClassification(b *bool) Option {
 	}
 }
 
+// OptPopulateResume sets whether to continue an interrupted populate run
+// from its saved checkpoints.
+// Runtime-only field - not in ToOptions().
+func OptPopulateResume(b bool) Option {
+	return func(c *Config) {
+		c.Populate.Resume = b
+	}
+}
+
+// OptPopulateParallelSources sets the number of data sources imported
+// at the same time.
+// Runtime-only field - not in ToOptions().
+func OptPopulateParallelSources(i int) Option {
+	return func(c *Config) {
+		if isValidInt("Parallel Sources", i) {
+			c.Populate.ParallelSources = i
+		}
+	}
+}
+
+// OptPopulateDelta sets whether to write only the differences between
+// the incoming SFGA records and the records already in the database.
+// Runtime-only field - not in ToOptions().
+func OptPopulateDelta(b bool) Option {
+	return func(c *Config) {
+		c.Populate.Delta = b
+	}
+}
+
+// OptPopulateNonInteractive sets whether populate runs without asking
+// the user any questions.
+// Runtime-only field - not in ToOptions().
+func OptPopulateNonInteractive(b bool) Option {
+	return func(c *Config) {
+		c.Populate.NonInteractive = b
+	}
+}
+
+// OptPopulateOnEmptyNameString sets the policy for sources with empty
+// gn__scientific_name_string values.
+// Valid values: "fallback", "skip", "abort".
+// Runtime-only field - not in ToOptions().
+func OptPopulateOnEmptyNameString(s string) Option {
+	s = strings.TrimSpace(s)
+	s = strings.ToLower(s)
+	return func(c *Config) {
+		if isValidEnum("Populate.OnEmptyNameString", s) {
+			c.Populate.OnEmptyNameString = s
+		}
+	}
+}
+
+// OptPopulateOnDuplicateRecordID sets the policy for sources with
+// duplicate record IDs of name indices.
+// Valid values: "fail", "keep-first", "suffix".
+// Runtime-only field - not in ToOptions().
+func OptPopulateOnDuplicateRecordID(s string) Option {
+	s = strings.TrimSpace(s)
+	s = strings.ToLower(s)
+	return func(c *Config) {
+		if isValidEnum("Populate.OnDuplicateRecordID", s) {
+			c.Populate.OnDuplicateRecordID = s
+		}
+	}
+}
+
+// OptPopulateOnDanglingSynonym sets the policy for synonyms whose
+// accepted taxon is missing from the source.
+// Valid values: "drop", "bare-name", "keep".
+// Runtime-only field - not in ToOptions().
+func OptPopulateOnDanglingSynonym(s string) Option {
+	s = strings.TrimSpace(s)
+	s = strings.ToLower(s)
+	return func(c *Config) {
+		if isValidEnum("Populate.OnDanglingSynonym", s) {
+			c.Populate.OnDanglingSynonym = s
+		}
+	}
+}
+
+// OptPopulateDryRun sets whether populate only checks sources without
+// importing them.
+// Runtime-only field - not in ToOptions().
+func OptPopulateDryRun(b bool) Option {
+	return func(c *Config) {
+		c.Populate.DryRun = b
+	}
+}
+
+// OptPopulateStrictHierarchy sets whether sources with too many
+// hierarchy defects fail to import.
+// Runtime-only field - not in ToOptions().
+func OptPopulateStrictHierarchy(b bool) Option {
+	return func(c *Config) {
+		c.Populate.StrictHierarchy = b
+	}
+}
+
+// OptPopulateMaxHierarchyDefectRatio sets the largest allowed share of
+// hierarchy nodes with broken parent chains. Valid values: 0-1.
+// Runtime-only field - not in ToOptions().
+func OptPopulateMaxHierarchyDefectRatio(f float64) Option {
+	return func(c *Config) {
+		if isValidRatio("Max Hierarchy Defect Ratio", f) {
+			c.Populate.MaxHierarchyDefectRatio = f
+		}
+	}
+}
+
+// OptPopulateForce sets whether populate imports sources that did not
+// change since their last import.
+// Runtime-only field - not in ToOptions().
+func OptPopulateForce(b bool) Option {
+	return func(c *Config) {
+		c.Populate.Force = b
+	}
+}
+
+// OptPopulateFile sets a data source imported from an explicit SFGA
+// file instead of sources.yaml. The file needs a data source ID.
+// Runtime-only field - not in ToOptions().
+func OptPopulateFile(f FileSourceConfig) Option {
+	f.Path = strings.TrimSpace(f.Path)
+	f.Title = strings.TrimSpace(f.Title)
+	f.OutlinkURL = strings.TrimSpace(f.OutlinkURL)
+	f.OutlinkIDColumn = strings.TrimSpace(f.OutlinkIDColumn)
+	return func(c *Config) {
+		if isValidString("Populate File", f.Path) &&
+			isValidInt("Populate File ID", f.ID) {
+			c.Populate.File = f
+		}
+	}
+}
+
+// OptCacheMaxSizeGB sets the size limit of the SFGA archives cache
+// in gigabytes.
+func OptCacheMaxSizeGB(i int) Option {
+	return func(c *Config) {
+		if isValidInt("Cache Max Size GB", i) {
+			c.Cache.MaxSizeGB = i
+		}
+	}
+}
+
+// OptS3Endpoint sets the URL of an S3-compatible storage service.
+func OptS3Endpoint(s string) Option {
+	s = strings.TrimSpace(s)
+	return func(c *Config) {
+		if isValidString("S3 Endpoint", s) {
+			c.S3.Endpoint = strings.TrimSuffix(s, "/")
+		}
+	}
+}
+
+// OptS3Region sets the region of S3 buckets.
+func OptS3Region(s string) Option {
+	s = strings.TrimSpace(s)
+	return func(c *Config) {
+		if isValidString("S3 Region", s) {
+			c.S3.Region = s
+		}
+	}
+}
+
+// OptS3AccessKeyID sets the access key of the S3 storage account.
+func OptS3AccessKeyID(s string) Option {
+	s = strings.TrimSpace(s)
+	return func(c *Config) {
+		if isValidString("S3 Access Key ID", s) {
+			c.S3.AccessKeyID = s
+		}
+	}
+}
+
+// OptS3SecretAccessKey sets the secret key of the S3 storage account.
+func OptS3SecretAccessKey(s string) Option {
+	s = strings.TrimSpace(s)
+	return func(c *Config) {
+		if isValidString("S3 Secret Access Key", s) {
+			c.S3.SecretAccessKey = s
+		}
+	}
+}
+
+// OptReportDir sets the directory for run reports.
+// Runtime-only field - not in ToOptions().
+func OptReportDir(s string) Option {
+	return func(c *Config) {
+		c.Report.Dir = strings.TrimSpace(s)
+	}
+}
+
+// OptReportWithMarkdown sets whether run reports are also written
+// in Markdown format.
+// Runtime-only field - not in ToOptions().
+func OptReportWithMarkdown(b bool) Option {
+	return func(c *Config) {
+		c.Report.WithMarkdown = b
+	}
+}
+
 // OptExportSourceIDs sets the list of data source IDs to export.
 // Empty slice means export all sources from the data_sources table.
 // Runtime-only field - not in ToOptions().
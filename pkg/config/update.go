@@ -68,6 +68,28 @@ func (c *Config) ToOptions() []Option {
 		res = append(res, OptLogDestination(s))
 	}
 
+	i = c.Cache.MaxSizeGB
+	if i > 0 {
+		res = append(res, OptCacheMaxSizeGB(i))
+	}
+
+	s = c.S3.Endpoint
+	if s != "" {
+		res = append(res, OptS3Endpoint(s))
+	}
+	s = c.S3.Region
+	if s != "" {
+		res = append(res, OptS3Region(s))
+	}
+	s = c.S3.AccessKeyID
+	if s != "" {
+		res = append(res, OptS3AccessKeyID(s))
+	}
+	s = c.S3.SecretAccessKey
+	if s != "" {
+		res = append(res, OptS3SecretAccessKey(s))
+	}
+
 	i = c.JobsNumber
 	if i > 0 {
 		res = append(res, OptJobsNumber(i))
@@ -91,6 +113,14 @@ func isValidInt(name string, i int) bool {
 	return res
 }
 
+func isValidRatio(name string, f float64) bool {
+	res := f >= 0 && f <= 1
+	if !res {
+		gn.Warn("<em>%s</em> has to be between 0 and 1, ignoring %g", name, f)
+	}
+	return res
+}
+
 func isValidEnum(name, val string) bool {
 	s := struct{}{}
 	data := map[string]map[string]struct{}{
@@ -99,6 +129,12 @@ func isValidEnum(name, val string) bool {
 		"Log.Level":       {"debug": s, "info": s, "warn": s, "error": s},
 		"Log.Format":      {"json": s, "text": s, "tint": s},
 		"Log.Destination": {"file": s, "stdin": s, "stdout": s},
+		"Populate.OnEmptyNameString": {"fallback": s, "skip": s,
+			"abort": s},
+		"Populate.OnDuplicateRecordID": {"fail": s, "keep-first": s,
+			"suffix": s},
+		"Populate.OnDanglingSynonym": {"drop": s, "bare-name": s,
+			"keep": s},
 	}
 	vals := slices.Sorted(maps.Keys(data[name]))
 	var lines []string
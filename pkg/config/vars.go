@@ -30,6 +30,12 @@ func LogDir(homeDir string) string {
 	return filepath.Join(homeDir, ".local", "share", AppName, "logs")
 }
 
+// ReportDir returns the default directory path for run reports.
+// Returns ~/.local/share/gndb/reports by default.
+func ReportDir(homeDir string) string {
+	return filepath.Join(homeDir, ".local", "share", AppName, "reports")
+}
+
 // ConfigFilePath returns the full path to the config.yaml file.
 // Returns ~/.config/gndb/config.yaml by default.
 func ConfigFilePath(homeDir string) string {
@@ -52,8 +52,17 @@ type Operator interface {
 	// If ids is empty, all data sources are returned.
 	GetDataSources(ctx context.Context, ids []int) ([]schema.DataSource, error)
 
+	// GetQualityProfiles returns DataSource records with record counts and
+	// quality profiles for the given IDs. If ids is empty, all data sources
+	// are returned.
+	GetQualityProfiles(
+		ctx context.Context,
+		ids []int,
+	) ([]schema.DataSource, error)
+
 	// DeleteDatasets removes all records belonging to the given data source IDs
-	// from name_string_indices, vernacular_string_indices, and data_sources.
+	// from name_string_indices, vernacular_string_indices, source_references
+	// and data_sources.
 	// Orphaned name_strings/canonicals are cleaned up by the optimize command.
 	DeleteDatasets(ctx context.Context, ids []int) error
 }
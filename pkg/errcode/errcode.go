@@ -15,6 +15,22 @@ const (
 	// Logging errors
 	CreateLogFileError
 
+	// Report errors
+	WriteReportError
+
+	// Cache errors
+	CacheIndexError
+	CacheDownloadError
+	CacheRemoveError
+
+	// Fetch errors
+	FetchError
+	FetchChecksumError
+
+	// S3 errors
+	S3ListError
+	S3UploadError
+
 	// Database errors
 	DBConnectionError
 	DBTableCheckError
@@ -54,6 +70,14 @@ const (
 	PopulateIndicesError
 	PopulateCacheError
 	PopulateAllSourcesFailedError
+	PopulateCheckpointError
+	PopulateAbortedError
+	PopulatePreflightError
+	PopulateConvertError
+	PopulateDuplicateRecordIDsError
+	PopulateFilterError
+	PopulateReleaseNotFoundError
+	PopulateFileSourceError
 
 	// Export errors
 	ExportNoSourcesError
@@ -1,10 +1,18 @@
 package gndb
 
+import (
+	"context"
+)
+
 // Populator defines the interface for populating the database with SFGA data.
 // It uses github.com/sfborg/sflib to read SFGA files and imports data into PostgreSQL.
 type Populator interface {
 	// Populate imports data from SFGA sources into the database.
 	// It reads the sources configuration, connects to SFGA files using sflib,
 	// transforms data to PostgreSQL schema, and performs batch inserts using pgx CopyFrom.
-	Populate() error
+	//
+	// Cancelling ctx stops the import: partial data of the interrupted source
+	// is removed, its checkpoint is kept for --resume, and remaining sources
+	// are skipped.
+	Populate(ctx context.Context) error
 }
@@ -0,0 +1,105 @@
+package gndb
+
+import "time"
+
+// EventType is the kind of a progress event.
+type EventType string
+
+// Progress events of populate, optimize and export.
+const (
+	// EventInfo is a notable message, for example about the run settings.
+	EventInfo EventType = "info"
+
+	// EventMessage is a detail of the current stage.
+	EventMessage EventType = "message"
+
+	// EventWarning is a problem that does not stop the run.
+	EventWarning EventType = "warning"
+
+	// EventError is a problem that stopped a data source or the run.
+	EventError EventType = "error"
+
+	// EventSourceStarted starts processing of a data source.
+	EventSourceStarted EventType = "source_started"
+
+	// EventSourceFinished ends processing of a data source, Status tells
+	// if it succeeded, failed or was skipped.
+	EventSourceFinished EventType = "source_finished"
+
+	// EventStageStarted starts a stage of a data source or of a run.
+	EventStageStarted EventType = "stage_started"
+
+	// EventStageFinished ends a stage, Count is the number of records
+	// the stage wrote.
+	EventStageFinished EventType = "stage_finished"
+
+	// EventProgress reports Count processed records out of Total. Total
+	// is 0 if it is not known. Done is set by the last event of a
+	// progress bar.
+	EventProgress EventType = "progress"
+
+	// EventSummary ends the run.
+	EventSummary EventType = "summary"
+)
+
+// Statuses of finished data sources and stages.
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusSkipped   = "skipped"
+)
+
+// Event describes progress of a populate, optimize or export run.
+// Messages may contain inline formatting tags of github.com/gnames/gn,
+// such as <em>...</em>.
+type Event struct {
+	// Type is the kind of the event.
+	Type EventType `json:"type"`
+
+	// Time is when the event happened.
+	Time time.Time `json:"time"`
+
+	// SourceID is the ID of the data source the event belongs to, 0 for
+	// events of the whole run.
+	SourceID int `json:"source_id,omitempty"`
+
+	// Parallel is true for events of data sources imported at the same
+	// time, their events are interleaved.
+	Parallel bool `json:"parallel,omitempty"`
+
+	// Stage is the name of the stage, for example "names".
+	Stage string `json:"stage,omitempty"`
+
+	// Step and Steps give the position of the stage, for example 2 of 6.
+	Step  int `json:"step,omitempty"`
+	Steps int `json:"steps,omitempty"`
+
+	// Status of a finished data source or stage.
+	Status string `json:"status,omitempty"`
+
+	// Count and Total are numbers of records, or of data sources in the
+	// summary.
+	Count int `json:"count,omitempty"`
+	Total int `json:"total,omitempty"`
+
+	// Done marks the last progress event of a progress bar.
+	Done bool `json:"done,omitempty"`
+
+	// DurationSec is the duration of a finished stage, data source or run.
+	DurationSec float64 `json:"duration_sec,omitempty"`
+
+	// Message is a human-readable description of the event.
+	Message string `json:"message,omitempty"`
+
+	// Err is the error of EventError.
+	Err error `json:"-"`
+}
+
+// ProgressSink receives progress events. Terminal output is one
+// implementation, others can send events to logs or monitoring.
+// Data sources imported in parallel send events from several goroutines,
+// so implementations must be safe for concurrent use.
+type ProgressSink interface {
+	// Emit receives one event.
+	Emit(e Event)
+}
@@ -0,0 +1,290 @@
+// Package report provides machine-readable summaries of gndb runs.
+// A Report is filled while populate, optimize or export runs and is
+// saved as JSON (and optionally Markdown) when the run finishes, so
+// dashboards and release notes do not depend on log parsing.
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/config"
+	"github.com/gnames/gndb/pkg/gndb"
+)
+
+// Status is the final state of a run or a data source.
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped"
+)
+
+// maxMarkdownIDs limits the number of record IDs listed in Markdown
+// reports, so release notes stay readable.
+const maxMarkdownIDs = 20
+
+// Report summarizes one run of a gndb command.
+type Report struct {
+	// RunID identifies the run, it is also the base name of report files.
+	RunID string `json:"run_id"`
+
+	// Command is the gndb subcommand, e.g. "populate".
+	Command string `json:"command"`
+
+	// Version is the version of gndb.
+	Version string `json:"version"`
+
+	// Config is the configuration of the run without the database
+	// password.
+	Config config.Config `json:"config"`
+
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+	DurationSec float64   `json:"duration_sec"`
+	Status      Status    `json:"status"`
+
+	// Steps are stages of commands that do not work per source,
+	// e.g. optimize.
+	Steps []Stage `json:"steps,omitempty"`
+
+	// Sources are data sources processed by the run.
+	Sources []Source `json:"sources,omitempty"`
+
+	// Error is the error that stopped the run.
+	Error *Error `json:"error,omitempty"`
+
+	mu sync.Mutex
+}
+
+// Source summarizes processing of one data source.
+type Source struct {
+	ID          int      `json:"id"`
+	Title       string   `json:"title"`
+	Status      Status   `json:"status"`
+	DurationSec float64  `json:"duration_sec"`
+	Stages      []Stage  `json:"stages,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+
+	// Duplicates are record IDs that occur more than once in the name
+	// indices of the source.
+	Duplicates *Duplicates `json:"duplicates,omitempty"`
+
+	// DanglingSynonyms are synonyms of the source whose accepted taxon
+	// is missing.
+	DanglingSynonyms *DanglingSynonyms `json:"dangling_synonyms,omitempty"`
+
+	Error *Error `json:"error,omitempty"`
+}
+
+// Duplicates lists duplicate record IDs of a source and the policy
+// that resolved them.
+type Duplicates struct {
+	// Policy is the on_duplicate_record_id policy applied to the source.
+	Policy string `json:"policy"`
+
+	// RecordIDs are all record IDs that occur more than once.
+	RecordIDs []string `json:"record_ids"`
+}
+
+// DanglingSynonyms lists synonyms whose accepted taxon is missing from
+// a source and the policy that handled them.
+type DanglingSynonyms struct {
+	// Policy is the on_dangling_synonym policy applied to the source.
+	Policy string `json:"policy"`
+
+	// Count is the number of dangling synonyms. A pro parte synonym is
+	// counted once for every missing accepted taxon.
+	Count int `json:"count"`
+
+	// RecordIDs are record IDs of all dangling synonyms.
+	RecordIDs []string `json:"record_ids"`
+}
+
+// Stage summarizes one stage of a source or a step of a run.
+type Stage struct {
+	Name        string  `json:"name"`
+	DurationSec float64 `json:"duration_sec"`
+
+	// Inserted is the number of records written by the stage.
+	Inserted int `json:"inserted,omitempty"`
+
+	// Updated is the number of records changed by the stage.
+	Updated int `json:"updated,omitempty"`
+
+	// Deleted is the number of records removed by the stage.
+	Deleted int `json:"deleted,omitempty"`
+
+	// Skipped is true if the stage was done by a previous run.
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// Error describes an error with its gndb error code.
+type Error struct {
+	Code    gn.ErrorCode `json:"code"`
+	Message string       `json:"message"`
+}
+
+// New creates a report for a command that starts now.
+func New(command string, cfg *config.Config) *Report {
+	start := time.Now()
+	res := &Report{
+		RunID:     fmt.Sprintf("%s-%s", command, start.Format("20060102-150405")),
+		Command:   command,
+		Version:   gndb.Version,
+		StartedAt: start,
+	}
+	if cfg != nil {
+		res.Config = *cfg.Redacted()
+	}
+	return res
+}
+
+// NewError converts an error to a report Error. Codes are taken from
+// gn.Error, other errors get errcode.UnknownError (0).
+func NewError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	res := &Error{Message: err.Error()}
+	var gnErr *gn.Error
+	if errors.As(err, &gnErr) {
+		res.Code = gnErr.Code
+	}
+	return res
+}
+
+// AddStep adds a step of the run. It is safe for concurrent use.
+func (r *Report) AddStep(s Stage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Steps = append(r.Steps, s)
+}
+
+// AddSource adds a processed data source. It is safe for concurrent use.
+func (r *Report) AddSource(s Source) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Sources = append(r.Sources, s)
+}
+
+// Finish sets the end time and the final status of the run. Sources are
+// sorted by ID, because parallel imports add them in random order.
+func (r *Report) Finish(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.FinishedAt = time.Now()
+	r.DurationSec = r.FinishedAt.Sub(r.StartedAt).Seconds()
+	r.Status = StatusSucceeded
+	if err != nil {
+		r.Status = StatusFailed
+		r.Error = NewError(err)
+	}
+	slices.SortStableFunc(r.Sources, func(a, b Source) int {
+		return a.ID - b.ID
+	})
+}
+
+// JSON returns the report as indented JSON.
+func (r *Report) JSON() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Markdown returns the report as a Markdown document for release notes.
+func (r *Report) Markdown() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# gndb %s report\n\n", r.Command)
+	fmt.Fprintf(&b, "- Run ID: `%s`\n", r.RunID)
+	fmt.Fprintf(&b, "- Version: %s\n", r.Version)
+	fmt.Fprintf(&b, "- Started: %s\n", r.StartedAt.Format(time.DateTime))
+	fmt.Fprintf(&b, "- Duration: %.1fs\n", r.DurationSec)
+	fmt.Fprintf(&b, "- Status: **%s**\n", r.Status)
+	if r.Error != nil {
+		fmt.Fprintf(&b, "- Error (%d): %s\n", r.Error.Code, r.Error.Message)
+	}
+
+	if len(r.Steps) > 0 {
+		b.WriteString("\n## Steps\n\n")
+		writeStages(&b, r.Steps)
+	}
+
+	if len(r.Sources) > 0 {
+		b.WriteString("\n## Sources\n\n")
+		b.WriteString("| ID | Title | Status | Duration (s) |\n")
+		b.WriteString("| --: | ----- | ------ | -----------: |\n")
+		for _, s := range r.Sources {
+			fmt.Fprintf(&b, "| %d | %s | %s | %.1f |\n",
+				s.ID, s.Title, s.Status, s.DurationSec)
+		}
+	}
+
+	for _, s := range r.Sources {
+		if len(s.Stages) == 0 && len(s.Warnings) == 0 &&
+			s.Duplicates == nil && s.DanglingSynonyms == nil &&
+			s.Error == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### [%d] %s\n\n", s.ID, s.Title)
+		if len(s.Stages) > 0 {
+			writeStages(&b, s.Stages)
+		}
+		for _, w := range s.Warnings {
+			fmt.Fprintf(&b, "- Warning: %s\n", w)
+		}
+		if d := s.Duplicates; d != nil {
+			fmt.Fprintf(&b, "- Duplicate record IDs (%s): %s\n",
+				d.Policy, listIDs(d.RecordIDs, maxMarkdownIDs))
+		}
+		if d := s.DanglingSynonyms; d != nil {
+			fmt.Fprintf(&b, "- Dangling synonyms (%s): %d, %s\n",
+				d.Policy, d.Count, listIDs(d.RecordIDs, maxMarkdownIDs))
+		}
+		if s.Error != nil {
+			fmt.Fprintf(&b, "- Error (%d): %s\n", s.Error.Code, s.Error.Message)
+		}
+	}
+
+	return b.String()
+}
+
+// listIDs formats IDs as inline code, showing at most limit of them.
+// The JSON report always has all of them.
+func listIDs(ids []string, limit int) string {
+	shown := make([]string, 0, min(len(ids), limit))
+	for _, id := range ids[:min(len(ids), limit)] {
+		shown = append(shown, "`"+id+"`")
+	}
+	res := strings.Join(shown, ", ")
+	if len(ids) > limit {
+		res += fmt.Sprintf(" and %d more", len(ids)-limit)
+	}
+	return res
+}
+
+// writeStages writes stages as a Markdown table.
+func writeStages(b *strings.Builder, stages []Stage) {
+	b.WriteString("| Stage | Duration (s) | Inserted | Updated | Deleted |\n")
+	b.WriteString("| ----- | -----------: | -------: | ------: | ------: |\n")
+	for _, st := range stages {
+		name := st.Name
+		if st.Skipped {
+			name += " (skipped)"
+		}
+		fmt.Fprintf(b, "| %s | %.1f | %d | %d | %d |\n",
+			name, st.DurationSec, st.Inserted, st.Updated, st.Deleted)
+	}
+	b.WriteString("\n")
+}
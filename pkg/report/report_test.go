@@ -0,0 +1,160 @@
+package report_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gnames/gn"
+	"github.com/gnames/gndb/pkg/config"
+	"github.com/gnames/gndb/pkg/errcode"
+	"github.com/gnames/gndb/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cfg := config.New()
+	cfg.Update([]config.Option{
+		config.OptS3AccessKeyID("minio"),
+		config.OptS3SecretAccessKey("secret"),
+	})
+	r := report.New("populate", cfg)
+
+	assert.Contains(t, r.RunID, "populate-")
+	assert.Equal(t, "populate", r.Command)
+	assert.NotEmpty(t, r.Version)
+	assert.Empty(t, r.Config.Database.Password,
+		"password should not be in the report")
+	assert.Empty(t, r.Config.S3.AccessKeyID,
+		"S3 access key should not be in the report")
+	assert.Empty(t, r.Config.S3.SecretAccessKey,
+		"S3 secret key should not be in the report")
+	assert.Equal(t, "postgres", cfg.Database.Password,
+		"original config should not change")
+	assert.Equal(t, "secret", cfg.S3.SecretAccessKey,
+		"original config should not change")
+}
+
+func TestNewError(t *testing.T) {
+	assert.Nil(t, report.NewError(nil))
+
+	res := report.NewError(errors.New("boom"))
+	assert.Equal(t, errcode.UnknownError, res.Code)
+	assert.Equal(t, "boom", res.Message)
+
+	err := &gn.Error{
+		Code: errcode.PopulateNamesError,
+		Err:  errors.New("names failed"),
+	}
+	res = report.NewError(err)
+	assert.Equal(t, errcode.PopulateNamesError, res.Code)
+	assert.Equal(t, "names failed", res.Message)
+}
+
+func TestFinish(t *testing.T) {
+	r := report.New("populate", nil)
+	r.AddSource(report.Source{ID: 5, Status: report.StatusSucceeded})
+	r.AddSource(report.Source{ID: 1, Status: report.StatusFailed})
+
+	r.Finish(nil)
+	assert.Equal(t, report.StatusSucceeded, r.Status)
+	assert.Nil(t, r.Error)
+	assert.Equal(t, 1, r.Sources[0].ID)
+	assert.Equal(t, 5, r.Sources[1].ID)
+
+	r.Finish(errors.New("all failed"))
+	assert.Equal(t, report.StatusFailed, r.Status)
+	require.NotNil(t, r.Error)
+	assert.Equal(t, "all failed", r.Error.Message)
+}
+
+func TestJSON(t *testing.T) {
+	r := report.New("optimize", nil)
+	r.AddStep(report.Stage{Name: "reparse", Inserted: 10})
+	r.Finish(nil)
+
+	data, err := r.JSON()
+	require.NoError(t, err)
+
+	var res map[string]any
+	require.NoError(t, json.Unmarshal(data, &res))
+	assert.Equal(t, "optimize", res["command"])
+	assert.Equal(t, "succeeded", res["status"])
+	assert.Len(t, res["steps"], 1)
+	assert.NotContains(t, res, "sources")
+}
+
+func TestMarkdown(t *testing.T) {
+	r := report.New("populate", nil)
+	r.AddSource(report.Source{
+		ID:     1,
+		Title:  "CoL",
+		Status: report.StatusSucceeded,
+		Stages: []report.Stage{
+			{Name: "names", Inserted: 100},
+			{Name: "hierarchy", Skipped: true},
+		},
+		Warnings: []string{"hierarchy has cycles"},
+	})
+	r.Finish(nil)
+
+	md := r.Markdown()
+	assert.Contains(t, md, "# gndb populate report")
+	assert.Contains(t, md, "| 1 | CoL | succeeded |")
+	assert.Contains(t, md, "### [1] CoL")
+	assert.Contains(t, md, "| names | 0.0 | 100 | 0 | 0 |")
+	assert.Contains(t, md, "hierarchy (skipped)")
+	assert.Contains(t, md, "- Warning: hierarchy has cycles")
+}
+
+func TestMarkdownDuplicates(t *testing.T) {
+	ids := make([]string, 25)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("tx-%d", i)
+	}
+	r := report.New("populate", nil)
+	r.AddSource(report.Source{
+		ID:         1,
+		Title:      "CoL",
+		Status:     report.StatusSucceeded,
+		Duplicates: &report.Duplicates{Policy: "suffix", RecordIDs: ids},
+	})
+	r.Finish(nil)
+
+	md := r.Markdown()
+	assert.Contains(t, md, "### [1] CoL")
+	assert.Contains(t, md, "- Duplicate record IDs (suffix): `tx-0`, `tx-1`")
+	assert.Contains(t, md, "`tx-19` and 5 more")
+	assert.NotContains(t, md, "`tx-20`")
+
+	data, err := r.JSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"tx-24"`)
+}
+
+func TestMarkdownDanglingSynonyms(t *testing.T) {
+	r := report.New("populate", nil)
+	r.AddSource(report.Source{
+		ID:     1,
+		Title:  "CoL",
+		Status: report.StatusSucceeded,
+		DanglingSynonyms: &report.DanglingSynonyms{
+			Policy:    "bare-name",
+			Count:     2,
+			RecordIDs: []string{"s1", "s2"},
+		},
+	})
+	r.Finish(nil)
+
+	md := r.Markdown()
+	assert.Contains(t, md, "### [1] CoL")
+	assert.Contains(t, md,
+		"- Dangling synonyms (bare-name): 2, `s1`, `s2`")
+
+	data, err := r.JSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"dangling_synonyms"`)
+	assert.Contains(t, string(data), `"count": 2`)
+}
@@ -13,6 +13,7 @@ func AllModels() []interface{} {
 		&CanonicalFull{},
 		&CanonicalStem{},
 		&NameStringIndex{},
+		&SourceReference{},
 		&Word{},
 		&WordNameString{},
 		&VernacularString{},
@@ -79,11 +79,59 @@ type DataSource struct {
 	// VernRecordCount is the number of vernacular string indices.
 	VernRecordCount int `gorm:"type:integer"`
 
+	// Quality is the data quality profile of the dataset computed during
+	// its import.
+	Quality QualityProfile `gorm:"embedded;embeddedPrefix:quality_"`
+
 	// UpdatedAt records the timestamp of the dataset's last import.
 	// This might not necessarily coincide with the dataset's creation date.
 	UpdatedAt time.Time `gorm:"type:timestamp without time zone"`
 }
 
+// QualityProfile summarizes how clean the data of a dataset is. Shares are
+// fractions from 0 to 1. Shares of names are calculated from all name
+// records of the dataset, other shares from synonyms or vernacular records.
+type QualityProfile struct {
+	// NoParseShare is the share of names that could not be parsed
+	// (parse quality 0).
+	NoParseShare float64 `gorm:"type:double precision;not null;default:0"`
+
+	// CleanParseShare is the share of names parsed without problems
+	// (parse quality 1).
+	CleanParseShare float64 `gorm:"type:double precision;not null;default:0"`
+
+	// MinorProblemsShare is the share of names parsed with minor problems
+	// (parse quality 2).
+	MinorProblemsShare float64 `gorm:"type:double precision;not null;default:0"`
+
+	// MajorProblemsShare is the share of names parsed with serious
+	// problems (parse quality 3 or more).
+	MajorProblemsShare float64 `gorm:"type:double precision;not null;default:0"`
+
+	// SurrogateShare is the share of surrogate names, such as
+	// "Carex sp. 3".
+	SurrogateShare float64 `gorm:"type:double precision;not null;default:0"`
+
+	// HybridShare is the share of named hybrids and hybrid formulas.
+	HybridShare float64 `gorm:"type:double precision;not null;default:0"`
+
+	// BareNameShare is the share of names that are neither taxa nor
+	// synonyms.
+	BareNameShare float64 `gorm:"type:double precision;not null;default:0"`
+
+	// UnresolvedSynonymShare is the share of synonyms whose accepted
+	// taxon ID is not found in the dataset. Such synonyms are handled
+	// by the on_dangling_synonym policy of the dataset.
+	UnresolvedSynonymShare float64 `gorm:"type:double precision;not null;default:0"`
+
+	// ClassificationShare is the share of names with a classification.
+	ClassificationShare float64 `gorm:"type:double precision;not null;default:0"`
+
+	// VernacularLanguageShare is the share of vernacular records with
+	// a language.
+	VernacularLanguageShare float64 `gorm:"type:double precision;not null;default:0"`
+}
+
 // NameString is a name-string extracted from a dataset.
 type NameString struct {
 	// UUID v5 generated from the name-string using DNS:"globalnames.org" as
@@ -164,7 +212,8 @@ type NameStringIndex struct {
 	// RecordID is a unique ID for record. We do our best to
 	// get it from the record IDs, either global or local,
 	// but if all fails, id is assigned by gnames in a format
-	// of 'gn_{int}'.
+	// of 'gn_{int}'. A pro parte synonym has one row for every
+	// accepted taxon, all with the same RecordID.
 	RecordID string `gorm:"type:varchar(255);primaryKey"`
 
 	// NameStringID is UUID5 of a full name-string from the dataset.
@@ -193,6 +242,8 @@ type NameStringIndex struct {
 	TaxonomicStatus string `gorm:"type:varchar(255)"`
 
 	// RecordID of a currently accepted name-string for the taxon.
+	// It is a part of the primary key, so a synonym can point
+	// to several accepted records.
 	AcceptedRecordID string `gorm:"type:varchar(255);primaryKey;index:accepted_record_id"`
 
 	// Pipe-delimited string containing classification supplied with the resource.
@@ -203,6 +254,32 @@ type NameStringIndex struct {
 
 	// Ranks of the classification elements.
 	ClassificationRanks string
+
+	// ReferenceID is the RecordID of the reference in source_references
+	// where the name was published.
+	ReferenceID string `gorm:"type:varchar(255)"`
+}
+
+// SourceReference is a bibliographic reference of a data source, for
+// example the publication where a name was originally described.
+type SourceReference struct {
+	// DataSourceID refers to a data-source ID.
+	DataSourceID int `gorm:"primaryKey;autoIncrement:false"`
+
+	// RecordID is the ID of the reference in the data source.
+	RecordID string `gorm:"type:varchar(255);primaryKey"`
+
+	// Citation is the full bibliographic citation of the reference.
+	Citation string
+
+	// DOI of the reference.
+	DOI string `gorm:"type:varchar(255)"`
+
+	// Year the reference was published, if known.
+	Year sql.NullInt16 `gorm:"type:int"`
+
+	// Link is a URL of the reference.
+	Link string
 }
 
 // Word is a word from a name-string.
@@ -0,0 +1,78 @@
+package sources
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxParseQuality is the worst parse quality assigned by gnparser.
+const MaxParseQuality = 4
+
+// Filters limit records of a data source imported by populate, so lean
+// domain-specific databases can be built from large sources. A record is
+// imported only if it passes all filters that are set. Name-strings and
+// vernacular names are imported only for imported records.
+type Filters struct {
+	// Subtree keeps taxa of classification subtrees and their synonyms.
+	// Roots of subtrees are given by taxon IDs or scientific names
+	// (e.g. "Aves"). Bare names have no classification and are excluded.
+	Subtree []string `yaml:"subtree,omitempty"`
+
+	// Ranks keeps names with these ranks (e.g. species, subspecies).
+	Ranks []string `yaml:"ranks,omitempty"`
+
+	// TaxonomicStatuses keeps records with these taxonomic statuses
+	// (e.g. accepted, synonym). Bare names have "bare name" status.
+	TaxonomicStatuses []string `yaml:"taxonomic_statuses,omitempty"`
+
+	// MaxParseQuality keeps names with gnparser parse quality up to this
+	// value (1 - clean parse ... 4 - worst). Names that cannot be parsed
+	// are excluded. Zero means no limit.
+	MaxParseQuality int `yaml:"max_parse_quality,omitempty"`
+}
+
+// IsEmpty returns true if no filter is set.
+func (f *Filters) IsEmpty() bool {
+	return f == nil ||
+		len(f.Subtree) == 0 &&
+			len(f.Ranks) == 0 &&
+			len(f.TaxonomicStatuses) == 0 &&
+			f.MaxParseQuality == 0
+}
+
+// normalize trims values of filters and converts ranks and statuses to
+// lower case, as they are stored in name indices. It returns a warning
+// message if max_parse_quality is out of range, the value is dropped
+// then.
+func (f *Filters) normalize() string {
+	f.Subtree = cleanValues(f.Subtree, false)
+	f.Ranks = cleanValues(f.Ranks, true)
+	f.TaxonomicStatuses = cleanValues(f.TaxonomicStatuses, true)
+
+	if f.MaxParseQuality < 0 || f.MaxParseQuality > MaxParseQuality {
+		msg := fmt.Sprintf(
+			"filters.max_parse_quality %d is out of range", f.MaxParseQuality,
+		)
+		f.MaxParseQuality = 0
+		return msg
+	}
+	return ""
+}
+
+// cleanValues trims values and removes empty ones and repeats.
+func cleanValues(vals []string, lower bool) []string {
+	var res []string
+	seen := make(map[string]bool)
+	for _, v := range vals {
+		v = strings.TrimSpace(v)
+		if lower {
+			v = strings.ToLower(v)
+		}
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		res = append(res, v)
+	}
+	return res
+}
@@ -0,0 +1,62 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFiltersYAML(t *testing.T) {
+	data := `
+data_sources:
+  - id: 1
+    parent: https://example.com/sfga/
+    filters:
+      subtree: [Aves, " 6DBT "]
+      ranks: [Species, subspecies, species]
+      taxonomic_statuses: [ACCEPTED]
+      max_parse_quality: 2
+  - id: 2
+    parent: https://example.com/sfga/
+    filters:
+      ranks: [" "]
+`
+	var cfg SourcesConfig
+	require.NoError(t, yaml.Unmarshal([]byte(data), &cfg))
+	require.NoError(t, cfg.Validate())
+	assert.Empty(t, cfg.Warnings)
+
+	f := cfg.DataSources[0].Filters
+	require.NotNil(t, f)
+	assert.Equal(t, []string{"Aves", "6DBT"}, f.Subtree)
+	assert.Equal(t, []string{"species", "subspecies"}, f.Ranks)
+	assert.Equal(t, []string{"accepted"}, f.TaxonomicStatuses)
+	assert.Equal(t, 2, f.MaxParseQuality)
+
+	assert.Nil(t, cfg.DataSources[1].Filters,
+		"Filters without values should be dropped")
+}
+
+func TestFiltersMaxParseQuality(t *testing.T) {
+	source := DataSourceConfig{
+		ID:      1,
+		Parent:  "https://example.com/sfga/",
+		Filters: &Filters{Ranks: []string{"species"}, MaxParseQuality: 7},
+	}
+	warnings, err := source.Validate(1)
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "filters", warnings[0].Field)
+	require.NotNil(t, source.Filters)
+	assert.Zero(t, source.Filters.MaxParseQuality)
+}
+
+func TestFiltersIsEmpty(t *testing.T) {
+	var f *Filters
+	assert.True(t, f.IsEmpty())
+	assert.True(t, (&Filters{}).IsEmpty())
+	assert.False(t, (&Filters{MaxParseQuality: 3}).IsEmpty())
+	assert.False(t, (&Filters{Subtree: []string{"Aves"}}).IsEmpty())
+}
@@ -69,6 +69,21 @@ func IsValidURL(str string) bool {
 	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
 }
 
+// IsS3URL checks if a string is an s3://bucket/prefix URL of
+// S3-compatible object storage.
+func IsS3URL(str string) bool {
+	u, err := url.Parse(str)
+	return err == nil && u.Scheme == "s3" && u.Host != ""
+}
+
+// fileParent returns the directory or URL prefix that contains a file.
+func fileParent(file string) string {
+	if IsValidURL(file) || IsS3URL(file) {
+		return file[:strings.LastIndex(file, "/")+1]
+	}
+	return filepath.Dir(file)
+}
+
 // filterSources filters data sources based on the filter string.
 // Returns filtered sources, warnings (for user display), and error (for fatal issues).
 // Supported filters:
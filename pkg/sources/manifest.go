@@ -0,0 +1,134 @@
+package sources
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ManifestFileName is the name of the manifest at a parent URL.
+const ManifestFileName = "index.json"
+
+// ManifestSchemaVersion is the newest version of the manifest schema
+// that gndb understands. Newer manifests might change the meaning of
+// fields, so they are not used.
+const ManifestSchemaVersion = 1
+
+// ErrManifestSchema is returned for manifests with a schema version
+// that gndb does not support.
+var ErrManifestSchema = errors.New("unsupported manifest schema version")
+
+// Manifest is a machine-readable listing of SFGA files published as
+// index.json at a parent URL. Large mirrors can split it into pages,
+// every page links to the next one.
+//
+// Example:
+//
+//	{
+//	  "schema_version": 1,
+//	  "files": [
+//	    {
+//	      "name": "0001-col-2025-10-01.sqlite.zip",
+//	      "source_id": 1,
+//	      "release_date": "2025-10-01",
+//	      "version": "2025.10",
+//	      "size": 1234567,
+//	      "sha256": "9f86d081884c7d65..."
+//	    }
+//	  ],
+//	  "next": "index-2.json"
+//	}
+type Manifest struct {
+	// SchemaVersion is the version of the manifest format.
+	SchemaVersion int `json:"schema_version"`
+
+	// Files are SFGA files of the page.
+	Files []ManifestFile `json:"files"`
+
+	// Next is the URL of the next page, relative to the current one.
+	// It is empty on the last page.
+	Next string `json:"next,omitempty"`
+}
+
+// ManifestFile describes one SFGA file of a manifest.
+type ManifestFile struct {
+	// Name is the file name, or a path relative to the manifest.
+	Name string `json:"name"`
+
+	// URL is the full address of the file, if it is not stored next to
+	// the manifest.
+	URL string `json:"url,omitempty"`
+
+	// SourceID is the ID of the data source of the file.
+	SourceID int `json:"source_id"`
+
+	// ReleaseDate is the release date of the data in YYYY-MM-DD format.
+	ReleaseDate string `json:"release_date,omitempty"`
+
+	// Version is the version of the data source release.
+	Version string `json:"version,omitempty"`
+
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size,omitempty"`
+
+	// SHA256 is the hex checksum of the file.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// ParseManifest reads one page of a manifest and checks its fields.
+// Manifests of a newer schema version return ErrManifestSchema.
+func ParseManifest(data []byte) (*Manifest, error) {
+	var res Manifest
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("invalid manifest JSON: %w", err)
+	}
+
+	if res.SchemaVersion < 1 {
+		return nil, fmt.Errorf("manifest schema_version is required")
+	}
+	if res.SchemaVersion > ManifestSchemaVersion {
+		return nil, fmt.Errorf("%w %d, newest supported is %d",
+			ErrManifestSchema, res.SchemaVersion, ManifestSchemaVersion)
+	}
+
+	for i, f := range res.Files {
+		if f.Name == "" && f.URL == "" {
+			return nil, fmt.Errorf("manifest file %d: name is required", i+1)
+		}
+		if f.SourceID < 1 {
+			return nil, fmt.Errorf(
+				"manifest file %s: source_id is required", f.Name,
+			)
+		}
+		if f.ReleaseDate != "" {
+			if _, err := time.Parse(time.DateOnly, f.ReleaseDate); err != nil {
+				return nil, fmt.Errorf(
+					"manifest file %s: release_date must be YYYY-MM-DD", f.Name,
+				)
+			}
+		}
+		if f.SHA256 != "" {
+			if _, err := hex.DecodeString(f.SHA256); err != nil ||
+				len(f.SHA256) != 64 {
+				return nil, fmt.Errorf(
+					"manifest file %s: sha256 must have 64 hex digits", f.Name,
+				)
+			}
+		}
+	}
+
+	return &res, nil
+}
+
+// SourceFiles returns files of a data source.
+func (m *Manifest) SourceFiles(id int) []ManifestFile {
+	var res []ManifestFile
+	for _, f := range m.Files {
+		if f.SourceID == id {
+			res = append(res, f)
+		}
+	}
+	return res
+}
@@ -0,0 +1,104 @@
+package sources
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifest(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name      string
+		data      string
+		wantFiles int
+		wantNext  string
+		wantErr   string
+	}{
+		{
+			name: "valid manifest",
+			data: `{"schema_version": 1, "next": "index-2.json", "files": [
+				{"name": "0001-col-2025-10-01.sqlite.zip", "source_id": 1,
+				 "release_date": "2025-10-01", "version": "2025.10",
+				 "size": 100, "sha256": "` + sum + `"},
+				{"name": "0003-itis.sqlite.zip", "source_id": 3}
+			]}`,
+			wantFiles: 2,
+			wantNext:  "index-2.json",
+		},
+		{
+			name:    "missing schema version",
+			data:    `{"files": []}`,
+			wantErr: "schema_version is required",
+		},
+		{
+			name:    "newer schema version",
+			data:    `{"schema_version": 2, "files": []}`,
+			wantErr: "unsupported manifest schema version 2",
+		},
+		{
+			name:    "missing source ID",
+			data:    `{"schema_version": 1, "files": [{"name": "a.sqlite"}]}`,
+			wantErr: "source_id is required",
+		},
+		{
+			name: "bad release date",
+			data: `{"schema_version": 1, "files": [
+				{"name": "a.sqlite", "source_id": 1, "release_date": "2025-13-01"}
+			]}`,
+			wantErr: "release_date",
+		},
+		{
+			name: "bad checksum",
+			data: `{"schema_version": 1, "files": [
+				{"name": "a.sqlite", "source_id": 1, "sha256": "abc"}
+			]}`,
+			wantErr: "sha256",
+		},
+		{
+			name:    "not JSON",
+			data:    `<html></html>`,
+			wantErr: "invalid manifest JSON",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseManifest([]byte(tt.data))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, m.Files, tt.wantFiles)
+			assert.Equal(t, tt.wantNext, m.Next)
+		})
+	}
+}
+
+func TestParseManifestSchemaError(t *testing.T) {
+	_, err := ParseManifest([]byte(`{"schema_version": 5}`))
+	assert.True(t, errors.Is(err, ErrManifestSchema))
+}
+
+func TestManifestSourceFiles(t *testing.T) {
+	m := Manifest{
+		SchemaVersion: 1,
+		Files: []ManifestFile{
+			{Name: "0001-a.sqlite", SourceID: 1},
+			{Name: "0002-b.sqlite", SourceID: 2},
+			{Name: "0001-c.sqlite", SourceID: 1},
+		},
+	}
+
+	files := m.SourceFiles(1)
+	require.Len(t, files, 2)
+	assert.Equal(t, "0001-a.sqlite", files[0].Name)
+	assert.Equal(t, "0001-c.sqlite", files[1].Name)
+	assert.Empty(t, m.SourceFiles(3))
+}
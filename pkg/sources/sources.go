@@ -10,6 +10,9 @@ package sources
 
 type Sources interface {
 	Load() (*SourcesConfig, error)
+
+	// AddCustom appends a data source to custom_sources.yaml.
+	AddCustom(ds DataSourceConfig) error
 }
 
 // SourcesConfig represents the complete sources.yaml configuration file.
@@ -59,6 +62,25 @@ type DataSourceConfig struct {
 	//   - ~/data/sfga/
 	Parent string `yaml:"parent"`
 
+	// File is an explicit SFGA file (local path or URL) of the source.
+	// It is set by `gndb populate --file` for sources that are not in
+	// sources.yaml, the file is imported as is instead of being looked up
+	// in Parent. If Parent is empty, it is set to the location of File.
+	File string `yaml:"-"`
+
+	// Format is the format of files in Parent: "sfga" (default), "dwca"
+	// for Darwin Core Archives, or "coldp" for Catalogue of Life Data
+	// Packages. DwC-A and CoLDP files are zip archives, populate converts
+	// them to SFGA before import.
+	Format string `yaml:"format,omitempty"`
+
+	// ReleaseDate and Version pin a release of the source, so builds are
+	// reproducible. Populate then imports the file with this release date
+	// (YYYY-MM-DD) and/or version instead of the latest one, and fails
+	// if there is no such file. Both are empty by default.
+	ReleaseDate string `yaml:"release_date,omitempty"`
+	Version     string `yaml:"version,omitempty"`
+
 	// Titles and description (override SFGA if needed)
 	Title       string `yaml:"title,omitempty"`       // Override SFGA col__title
 	TitleShort  string `yaml:"title_short,omitempty"` // Fallback: col__alias → truncate col__title
@@ -81,8 +103,93 @@ type DataSourceConfig struct {
 	// PreferFlatClassification indicates that this source should use
 	// flat classification (no hierarchy) when imported.
 	PreferFlatClassification bool `yaml:"prefer_flat_classification"`
+
+	// OnEmptyNameString decides what populate does when some names of the
+	// source have empty gn__scientific_name_string: "fallback" to
+	// col__scientific_name, "skip" the source, or "abort" the run.
+	// Empty means ask the user (or use the default in non-interactive mode).
+	OnEmptyNameString string `yaml:"on_empty_name_string,omitempty"`
+
+	// OnDuplicateRecordID decides what populate does when several name
+	// indices of the source have the same record_id: "fail" the import
+	// of the source, "keep-first" record and drop the rest, or "suffix"
+	// the repeated IDs to make them unique.
+	// Empty means ask the user (or use the default in non-interactive mode).
+	OnDuplicateRecordID string `yaml:"on_duplicate_record_id,omitempty"`
+
+	// OnDanglingSynonym decides what populate does with synonyms whose
+	// accepted taxon is missing from the source: "drop" them, import
+	// them as "bare-name" records, or "keep" them as synonyms of the
+	// missing taxon.
+	// Empty means ask the user (or use the default in non-interactive mode).
+	OnDanglingSynonym string `yaml:"on_dangling_synonym,omitempty"`
+
+	// Filters limit records imported from the source (see Filters).
+	// Nil means all records are imported.
+	Filters *Filters `yaml:"filters,omitempty"`
 }
 
+// Policies for names with empty gn__scientific_name_string.
+const (
+	// OnEmptyNameFallback uses col__scientific_name instead.
+	OnEmptyNameFallback = "fallback"
+	// OnEmptyNameSkip skips the data source.
+	OnEmptyNameSkip = "skip"
+	// OnEmptyNameAbort stops the populate run.
+	OnEmptyNameAbort = "abort"
+)
+
+// OnEmptyNamePolicies lists valid on_empty_name_string values. The first
+// one is the default.
+var OnEmptyNamePolicies = []string{
+	OnEmptyNameFallback, OnEmptyNameSkip, OnEmptyNameAbort,
+}
+
+// Policies for duplicate record IDs of name indices.
+const (
+	// OnDuplicateFail fails the import of the data source.
+	OnDuplicateFail = "fail"
+	// OnDuplicateKeepFirst keeps the first record with an ID.
+	OnDuplicateKeepFirst = "keep-first"
+	// OnDuplicateSuffix adds a suffix to repeated IDs.
+	OnDuplicateSuffix = "suffix"
+)
+
+// OnDuplicatePolicies lists valid on_duplicate_record_id values. The
+// first one is the default.
+var OnDuplicatePolicies = []string{
+	OnDuplicateFail, OnDuplicateKeepFirst, OnDuplicateSuffix,
+}
+
+// Policies for synonyms whose accepted taxon is missing.
+const (
+	// OnDanglingDrop does not import such synonyms.
+	OnDanglingDrop = "drop"
+	// OnDanglingBareName imports such synonyms as bare names.
+	OnDanglingBareName = "bare-name"
+	// OnDanglingKeep imports such synonyms as they are.
+	OnDanglingKeep = "keep"
+)
+
+// OnDanglingPolicies lists valid on_dangling_synonym values. The first
+// one is the default.
+var OnDanglingPolicies = []string{
+	OnDanglingDrop, OnDanglingBareName, OnDanglingKeep,
+}
+
+// Formats of data source files.
+const (
+	// FormatSFGA is the Species File Group Archive, used by populate.
+	FormatSFGA = "sfga"
+	// FormatDwCA is the Darwin Core Archive.
+	FormatDwCA = "dwca"
+	// FormatCoLDP is the Catalogue of Life Data Package.
+	FormatCoLDP = "coldp"
+)
+
+// Formats lists valid format values. The first one is the default.
+var Formats = []string{FormatSFGA, FormatDwCA, FormatCoLDP}
+
 // FileMetadata contains metadata extracted from SFGA filename.
 type FileMetadata struct {
 	ID          int    // Extracted from filename
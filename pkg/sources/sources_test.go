@@ -392,6 +392,211 @@ func TestValidateOutlink(t *testing.T) {
 	}
 }
 
+func TestValidateOnEmptyNameString(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		want        string
+		wantWarning bool
+	}{
+		{"not set", "", "", false},
+		{"fallback", "fallback", "fallback", false},
+		{"skip is normalized", " Skip ", "skip", false},
+		{"abort", "abort", "abort", false},
+		{"unknown value warns and is dropped", "ignore", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := DataSourceConfig{
+				ID:                1,
+				Parent:            "https://example.com/sfga/",
+				OnEmptyNameString: tt.value,
+			}
+			warnings, err := source.Validate(1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, source.OnEmptyNameString)
+			if tt.wantWarning {
+				require.Len(t, warnings, 1)
+				assert.Equal(t, "on_empty_name_string", warnings[0].Field)
+			} else {
+				assert.Empty(t, warnings)
+			}
+		})
+	}
+}
+
+func TestValidateOnDuplicateRecordID(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		want        string
+		wantWarning bool
+	}{
+		{"not set", "", "", false},
+		{"fail", "fail", "fail", false},
+		{"keep-first is normalized", " Keep-First ", "keep-first", false},
+		{"suffix", "suffix", "suffix", false},
+		{"unknown value warns and is dropped", "ignore", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := DataSourceConfig{
+				ID:                  1,
+				Parent:              "https://example.com/sfga/",
+				OnDuplicateRecordID: tt.value,
+			}
+			warnings, err := source.Validate(1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, source.OnDuplicateRecordID)
+			if tt.wantWarning {
+				require.Len(t, warnings, 1)
+				assert.Equal(t, "on_duplicate_record_id", warnings[0].Field)
+			} else {
+				assert.Empty(t, warnings)
+			}
+		})
+	}
+}
+
+func TestValidateOnDanglingSynonym(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		want        string
+		wantWarning bool
+	}{
+		{"not set", "", "", false},
+		{"drop", "drop", "drop", false},
+		{"bare-name is normalized", " Bare-Name ", "bare-name", false},
+		{"keep", "keep", "keep", false},
+		{"unknown value warns and is dropped", "fix", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := DataSourceConfig{
+				ID:                1,
+				Parent:            "https://example.com/sfga/",
+				OnDanglingSynonym: tt.value,
+			}
+			warnings, err := source.Validate(1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, source.OnDanglingSynonym)
+			if tt.wantWarning {
+				require.Len(t, warnings, 1)
+				assert.Equal(t, "on_dangling_synonym", warnings[0].Field)
+			} else {
+				assert.Empty(t, warnings)
+			}
+		})
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"not set defaults to sfga", "", "sfga", false},
+		{"dwca", "dwca", "dwca", false},
+		{"coldp is normalized", " CoLDP ", "coldp", false},
+		{"unknown format", "xlsx", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := DataSourceConfig{
+				ID:     1001,
+				Parent: "/data/dwca/",
+				Format: tt.value,
+			}
+			_, err := source.Validate(1)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, source.Format)
+		})
+	}
+}
+
+func TestValidateReleasePin(t *testing.T) {
+	tests := []struct {
+		name        string
+		date        string
+		version     string
+		wantDate    string
+		wantVersion string
+		wantErr     bool
+	}{
+		{"not set", "", "", "", "", false},
+		{"date and version", " 2025-08-25 ", " v1.0 ", "2025-08-25", "v1.0", false},
+		{"version only", "", "2024.1", "", "2024.1", false},
+		{"invalid date", "2025-13-01", "", "", "", true},
+		{"not a date", "latest", "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := DataSourceConfig{
+				ID:          1,
+				Parent:      "/data/sfga/",
+				ReleaseDate: tt.date,
+				Version:     tt.version,
+			}
+			_, err := source.Validate(1)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDate, source.ReleaseDate)
+			assert.Equal(t, tt.wantVersion, source.Version)
+		})
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		parent     string
+		file       string
+		wantParent string
+		wantErr    bool
+	}{
+		{"local file", "", "/data/new/1500.sqlite.zip", "/data/new", false},
+		{"relative file", "", "1500.sqlite", ".", false},
+		{
+			"remote file", "", "https://example.org/sfga/1500.sqlite.zip",
+			"https://example.org/sfga/", false,
+		},
+		{
+			"s3 file", "", "s3://bucket/sfga/1500.sqlite.zip",
+			"s3://bucket/sfga/", false,
+		},
+		{"parent is kept", "/data/", "/data/new/1500.sql", "/data/", false},
+		{"no file and parent", "", " ", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := DataSourceConfig{ID: 1500, Parent: tt.parent, File: tt.file}
+			_, err := source.Validate(1)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantParent, source.Parent)
+		})
+	}
+}
+
 func TestExtractOutlinkID(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -473,3 +678,23 @@ func TestIsValidURL(t *testing.T) {
 		})
 	}
 }
+
+func TestIsS3URL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{"bucket with prefix", "s3://sfga/archives/", true},
+		{"bucket only", "s3://sfga", true},
+		{"no bucket", "s3:///archives", false},
+		{"HTTP URL", "https://example.com/sfga", false},
+		{"local path", "/home/user/data", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsS3URL(tt.input))
+		})
+	}
+}
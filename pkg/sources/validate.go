@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 )
 
 // Validate checks the configuration for errors and applies defaults.
@@ -34,11 +35,41 @@ func (d *DataSourceConfig) Validate(index int) ([]ValidationWarning, error) {
 		return nil, fmt.Errorf("id is required")
 	}
 
+	// An explicit file is located in its parent.
+	d.File = strings.TrimSpace(d.File)
+	if d.Parent == "" && d.File != "" {
+		d.Parent = fileParent(d.File)
+	}
+
 	// Parent is required
 	if d.Parent == "" {
 		return nil, fmt.Errorf("parent directory or URL is required")
 	}
 
+	// format defaults to SFGA. An unknown format is an error, because
+	// its files cannot be imported.
+	d.Format = strings.ToLower(strings.TrimSpace(d.Format))
+	if d.Format == "" {
+		d.Format = FormatSFGA
+	}
+	if !slices.Contains(Formats, d.Format) {
+		return nil, fmt.Errorf(
+			"unknown format '%s', use one of: %v", d.Format, Formats,
+		)
+	}
+
+	// A pinned release date must be a valid date, otherwise no file
+	// can match it.
+	d.ReleaseDate = strings.TrimSpace(d.ReleaseDate)
+	d.Version = strings.TrimSpace(d.Version)
+	if d.ReleaseDate != "" {
+		if _, err := time.Parse(time.DateOnly, d.ReleaseDate); err != nil {
+			return nil, fmt.Errorf(
+				"release_date '%s' must be YYYY-MM-DD", d.ReleaseDate,
+			)
+		}
+	}
+
 	// is_outlink_ready is computed purely from outlink_url and outlink_id_column
 	// validity — any value set in the YAML is ignored.
 	d.IsOutlinkReady = false
@@ -133,5 +164,77 @@ func (d *DataSourceConfig) Validate(index int) ([]ValidationWarning, error) {
 		}
 	}
 
+	// on_empty_name_string must be one of the known policies. An unknown
+	// value is dropped, so populate asks the user as if it was not set.
+	if d.OnEmptyNameString != "" {
+		policy := strings.ToLower(strings.TrimSpace(d.OnEmptyNameString))
+		if slices.Contains(OnEmptyNamePolicies, policy) {
+			d.OnEmptyNameString = policy
+		} else {
+			warnings = append(warnings, ValidationWarning{
+				DataSourceID: d.ID,
+				Field:        "on_empty_name_string",
+				Message: fmt.Sprintf(
+					"unknown on_empty_name_string '%s'", d.OnEmptyNameString,
+				),
+				Suggestion: fmt.Sprintf("Use one of: %v", OnEmptyNamePolicies),
+			})
+			d.OnEmptyNameString = ""
+		}
+	}
+
+	// on_duplicate_record_id is checked the same way.
+	if d.OnDuplicateRecordID != "" {
+		policy := strings.ToLower(strings.TrimSpace(d.OnDuplicateRecordID))
+		if slices.Contains(OnDuplicatePolicies, policy) {
+			d.OnDuplicateRecordID = policy
+		} else {
+			warnings = append(warnings, ValidationWarning{
+				DataSourceID: d.ID,
+				Field:        "on_duplicate_record_id",
+				Message: fmt.Sprintf(
+					"unknown on_duplicate_record_id '%s'", d.OnDuplicateRecordID,
+				),
+				Suggestion: fmt.Sprintf("Use one of: %v", OnDuplicatePolicies),
+			})
+			d.OnDuplicateRecordID = ""
+		}
+	}
+
+	// on_dangling_synonym is checked the same way.
+	if d.OnDanglingSynonym != "" {
+		policy := strings.ToLower(strings.TrimSpace(d.OnDanglingSynonym))
+		if slices.Contains(OnDanglingPolicies, policy) {
+			d.OnDanglingSynonym = policy
+		} else {
+			warnings = append(warnings, ValidationWarning{
+				DataSourceID: d.ID,
+				Field:        "on_dangling_synonym",
+				Message: fmt.Sprintf(
+					"unknown on_dangling_synonym '%s'", d.OnDanglingSynonym,
+				),
+				Suggestion: fmt.Sprintf("Use one of: %v", OnDanglingPolicies),
+			})
+			d.OnDanglingSynonym = ""
+		}
+	}
+
+	// Empty filters are dropped, so populate does not prepare them.
+	if d.Filters != nil {
+		if msg := d.Filters.normalize(); msg != "" {
+			warnings = append(warnings, ValidationWarning{
+				DataSourceID: d.ID,
+				Field:        "filters",
+				Message:      msg,
+				Suggestion: fmt.Sprintf(
+					"Use a value from 1 to %d", MaxParseQuality,
+				),
+			})
+		}
+		if d.Filters.IsEmpty() {
+			d.Filters = nil
+		}
+	}
+
 	return warnings, nil
 }